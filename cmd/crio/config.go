@@ -62,6 +62,28 @@ stream_tls_key = "{{ .StreamTLSKey }}"
 # This file can change, and CRIO will automatically pick up the changes within 5 minutes.
 stream_tls_ca = "{{ .StreamTLSCA }}"
 
+# audit_log_path is the path of the JSON audit log of the security relevant
+# operations, like privileged container creations, exec sessions and image
+# pulls. The audit log is disabled when empty.
+audit_log_path = "{{ .AuditLogPath }}"
+
+# audit_log_max_size is the size in bytes past which the audit log gets
+# rotated, 0 to never rotate it.
+audit_log_max_size = {{ .AuditLogMaxSize }}
+
+# audit_log_max_files is the number of rotated audit logs kept around.
+audit_log_max_files = {{ .AuditLogMaxFiles }}
+
+# session_recording_dir is the directory the input, output and terminal resizes
+# of every exec and attach session get recorded to, in the asciicast v2 format.
+# Sessions aren't recorded when empty.
+session_recording_dir = "{{ .SessionRecordingDir }}"
+
+# session_recording_max_size is the size in bytes the session recordings may
+# take in total, past which the oldest ones get removed, 0 to never remove them.
+# The recordings of a container are removed along with it.
+session_recording_max_size = {{ .SessionRecordingMaxSize }}
+
 # file_locking is whether file-based locking will be used instead of
 # in-memory locking
 file_locking = {{ .FileLocking }}
@@ -118,12 +140,31 @@ selinux = {{ .SELinux }}
 # default for the runtime.
 seccomp_profile = "{{ .SeccompProfile }}"
 
+# seccomp_profile_root is the directory relative localhost/<path> seccomp
+# profiles are looked up in. When set, profiles outside of it are refused.
+# When empty, localhost/<path> profiles must be absolute paths.
+seccomp_profile_root = "{{ .SeccompProfileRoot }}"
+
+# seccomp_audit_dir is the directory the seccomp profiles generated for the
+# containers annotated with io.kubernetes.cri-o.SeccompAudit are written to.
+seccomp_audit_dir = "{{ .SeccompAuditDir }}"
+
+# seccomp_audit_log is where the syscalls logged by the seccomp audit are read
+# from: the kernel log device, or the log of the audit daemon when it runs.
+seccomp_audit_log = "{{ .SeccompAuditLog }}"
+
 # apparmor_profile is the apparmor profile name which is used as the
 # default for the runtime.
 apparmor_profile = "{{ .ApparmorProfile }}"
 
+# apparmor_profile_dir is a directory the apparmor profiles of which are loaded
+# at startup, and whenever its files change, so that containers can use them
+# as localhost/<name>.
+apparmor_profile_dir = "{{ .ApparmorProfileDir }}"
+
 # cgroup_manager is the cgroup management implementation to be used
-# for the runtime.
+# for the runtime, "cgroupfs" or "systemd". Both work with the legacy (v1)
+# and the unified (v2) cgroup hierarchies.
 cgroup_manager = "{{ .CgroupManager }}"
 
 # default_capabilities is the list of capabilities to add and can be modified here.
@@ -163,6 +204,46 @@ pids_limit = {{ .PidsLimit }}
 # Negative values indicate that no limit is imposed.
 log_size_max = {{ .LogSizeMax }}
 
+# log_rotate_files is the number of gzip compressed generations of the
+# container log files conmon keeps once it rotated them. The log files are
+# not rotated if it is 0.
+log_rotate_files = {{ .LogRotateFiles }}
+
+# log_rotate_size is the size in bytes the container log files are rotated
+# at. They are not rotated by size if it is 0.
+log_rotate_size = {{ .LogRotateSize }}
+
+# log_rotate_age is how long the container log files are written to before
+# being rotated, e.g. "24h". They are not rotated by age if it is empty.
+log_rotate_age = "{{ .LogRotateAge }}"
+
+# log_rate_limit_bytes is the number of bytes of output per second conmon
+# writes to the log of each container, dropping the output beyond, and writing
+# a line telling how much got dropped. The bytes written are not limited if it
+# is 0.
+log_rate_limit_bytes = {{ .LogRateLimitBytes }}
+
+# log_rate_limit_lines is the number of lines of output per second conmon
+# writes to the log of each container, dropping the lines beyond. The lines
+# written are not limited if it is 0.
+log_rate_limit_lines = {{ .LogRateLimitLines }}
+
+# log_rate_limit_burst is the number of seconds of output at the log rate
+# limits a container may write at once.
+log_rate_limit_burst = {{ .LogRateLimitBurst }}
+
+# log_drivers lists the log drivers conmon writes the output of the containers
+# with: "k8s-file" writes it to their log file, which the kubelet reads,
+# "journald" to the systemd journal, and "syslog" to the remote syslog server
+# at log_syslog_address.
+log_drivers = [
+{{ range $driver := .LogDrivers }}{{ printf "\t%q,\n" $driver }}{{ end }}]
+
+# log_syslog_address is the address of the remote syslog server the syslog log
+# driver sends the output of the containers to, as "udp://host:port" or
+# "tcp://host:port".
+log_syslog_address = "{{ .LogSyslogAddress }}"
+
 # read-only indicates whether all containers will run in read-only mode
 read_only = {{ .ReadOnly }}
 
@@ -179,6 +260,32 @@ uid_mappings = "{{ .UIDMappings }}"
 # ranges are separed by comma.
 gid_mappings = "{{ .GIDMappings }}"
 
+# The "crio.runtime.runtimes" table defines a list of named OCI compatible
+# runtimes, called runtime handlers. A pod selects one of them with the
+# "io.kubernetes.cri-o.RuntimeHandler" annotation, and all of its containers
+# then run with that runtime. Pods which don't select a runtime handler use
+# runtime or runtime_untrusted_workload, depending on their level of trust.
+# The annotation is the only way to select a runtime handler, as the CRI
+# version crio implements has no runtime handler field. The containers of a
+# runtime handler removed from this table can't be handled anymore.
+# Each runtime handler supports the following options:
+# - runtime_path is the path of the runtime binary.
+# - runtime_root is the root directory the runtime keeps its state in, passed
+#   as --root. If empty, the runtime uses its own default.
+# - runtime_type is the type of runtime, "oci" for runtimes implementing the
+#   OCI runtime command line interface, or "process" to run containers as plain
+#   processes on the host without any isolation, for testing only.
+#
+#[crio.runtime.runtimes.crun]
+#runtime_path = "/usr/bin/crun"
+#runtime_root = "/run/crun"
+#runtime_type = "oci"
+{{ range $name, $handler := .Runtimes }}
+[crio.runtime.runtimes.{{ $name }}]
+runtime_path = "{{ $handler.RuntimePath }}"
+runtime_root = "{{ $handler.RuntimeRoot }}"
+runtime_type = "{{ $handler.RuntimeType }}"
+{{ end }}
 [crio.image]
 
 # default_transport is the prefix we try prepending to an image name if the
@@ -218,6 +325,56 @@ image_volumes = "{{ .ImageVolumes }}"
 #registries = [
 # {{ range $opt := .Registries }}{{ printf "\t%q,\n#" $opt }}{{ end }}]
 
+# image_gc_high_threshold is the percentage of the image filesystem usage above
+# which unused images get removed, least recently used first. Images used by
+# containers, the pause_image and the pinned_images are never removed. The
+# image garbage collection is disabled if it's 0.
+image_gc_high_threshold = {{ .ImageGCHighThreshold }}
+
+# image_gc_low_threshold is the percentage of the image filesystem usage the
+# image garbage collection frees space down to.
+image_gc_low_threshold = {{ .ImageGCLowThreshold }}
+
+# image_gc_min_age is the minimum time since an image got pulled or last used
+# by a container before the image garbage collection can remove it.
+image_gc_min_age = "{{ .ImageGCMinAge }}"
+
+# pinned_images lists the images never removed by the image garbage collection.
+pinned_images = [
+{{ range $image := .PinnedImages }}{{ printf "\t%q,\n" $image }}{{ end }}]
+
+# The "crio.image.registry_configs" table holds the configuration of the
+# registries, by host[:port]. Each registry supports the following options:
+# - mirrors are the locations tried in order before the registry itself when
+#   pulling an image from it. A mirror is a host[:port], optionally followed
+#   by a repository namespace, which replaces the registry in the image name.
+#   The credentials of the pull requests are not sent to the mirrors.
+# - blocked prevents the registry from being contacted at all. Its images can
+#   still be pulled through its mirrors or the registry rewrites.
+# - ca_dir is a directory holding the CA certificates (*.crt) to trust for the
+#   registry, and the client certificates (*.cert and *.key) to use.
+#
+#[crio.image.registry_configs."docker.io"]
+#mirrors = ["registry.example.com:5000/docker.io"]
+#blocked = true
+{{ range $registry, $config := .RegistryConfigs }}
+[crio.image.registry_configs.{{ printf "%q" $registry }}]
+mirrors = [
+{{ range $mirror := $config.Mirrors }}{{ printf "\t%q,\n" $mirror }}{{ end }}]
+blocked = {{ $config.Blocked }}
+ca_dir = "{{ $config.CADir }}"
+{{ end }}
+# The "crio.image.registry_rewrites" table maps repository name prefixes to
+# the locations images get pulled from instead. The pulled images keep their
+# original names. Rewrites apply before the registry mirrors, and the longest
+# matching prefix wins. The credentials of the pull requests are not sent to
+# the locations on other registries.
+#
+#[crio.image.registry_rewrites]
+#"docker.io/library" = "registry.example.com/library"
+{{ if .RegistryRewrites }}
+[crio.image.registry_rewrites]
+{{ range $prefix, $location := .RegistryRewrites }}{{ printf "%q = %q\n" $prefix $location }}{{ end }}{{ end }}
 # The "crio.network" table contains settings pertaining to the
 # management of CNI plugins.
 [crio.network]
@@ -53,6 +53,59 @@ func validateConfig(config *server.Config) error {
 	if config.LogSizeMax >= 0 && config.LogSizeMax < oci.BufSize {
 		return fmt.Errorf("log size max should be negative or >= %d", oci.BufSize)
 	}
+
+	if _, err := config.RuntimeConfig.LogRotation(); err != nil {
+		return err
+	}
+	if err := lib.ValidateLogDrivers(config.LogDrivers, config.LogSyslogAddress); err != nil {
+		return err
+	}
+	if _, err := config.RuntimeConfig.LogRateLimit(); err != nil {
+		return err
+	}
+
+	if config.AuditLogMaxSize < 0 {
+		return fmt.Errorf("audit log max size should not be negative")
+	}
+	if config.AuditLogMaxFiles < 0 {
+		return fmt.Errorf("audit log max files should not be negative")
+	}
+
+	if config.SessionRecordingDir != "" && !filepath.IsAbs(config.SessionRecordingDir) {
+		return fmt.Errorf("session recording dir %q should be an absolute path", config.SessionRecordingDir)
+	}
+	if config.SessionRecordingMaxSize < 0 {
+		return fmt.Errorf("session recording max size should not be negative")
+	}
+
+	if config.SeccompProfileRoot != "" && !filepath.IsAbs(config.SeccompProfileRoot) {
+		return fmt.Errorf("seccomp profile root %q should be an absolute path", config.SeccompProfileRoot)
+	}
+
+	if config.ImageGCHighThreshold != 0 {
+		if config.ImageGCHighThreshold < 0 || config.ImageGCHighThreshold > 100 {
+			return fmt.Errorf("image gc high threshold should be between 0 and 100")
+		}
+		if config.ImageGCLowThreshold < 0 || config.ImageGCLowThreshold >= config.ImageGCHighThreshold {
+			return fmt.Errorf("image gc low threshold should be between 0 and the high threshold")
+		}
+		if _, err := time.ParseDuration(config.ImageGCMinAge); err != nil {
+			return fmt.Errorf("invalid image gc min age %q: %v", config.ImageGCMinAge, err)
+		}
+	}
+
+	for name, handler := range config.Runtimes {
+		switch handler.RuntimeType {
+		case "", oci.RuntimeTypeOCI:
+			if handler.RuntimePath == "" {
+				return fmt.Errorf("runtime_path is not set for runtime handler %q", name)
+			}
+		case oci.RuntimeTypeProcess:
+			logrus.Warnf("runtime handler %q runs containers as plain processes, without any isolation", name)
+		default:
+			return fmt.Errorf("unsupported runtime_type %q for runtime handler %q", handler.RuntimeType, name)
+		}
+	}
 	return nil
 }
 
@@ -105,6 +158,18 @@ func mergeConfig(config *server.Config, ctx *cli.Context) error {
 	if ctx.GlobalIsSet("registry") {
 		config.Registries = ctx.GlobalStringSlice("registry")
 	}
+	if ctx.GlobalIsSet("image-gc-high-threshold") {
+		config.ImageGCHighThreshold = ctx.GlobalInt("image-gc-high-threshold")
+	}
+	if ctx.GlobalIsSet("image-gc-low-threshold") {
+		config.ImageGCLowThreshold = ctx.GlobalInt("image-gc-low-threshold")
+	}
+	if ctx.GlobalIsSet("image-gc-min-age") {
+		config.ImageGCMinAge = ctx.GlobalString("image-gc-min-age")
+	}
+	if ctx.GlobalIsSet("pinned-image") {
+		config.PinnedImages = ctx.GlobalStringSlice("pinned-image")
+	}
 	if ctx.GlobalIsSet("default-transport") {
 		config.DefaultTransport = ctx.GlobalString("default-transport")
 	}
@@ -117,6 +182,21 @@ func mergeConfig(config *server.Config, ctx *cli.Context) error {
 	if ctx.GlobalIsSet("stream-port") {
 		config.StreamPort = ctx.GlobalString("stream-port")
 	}
+	if ctx.GlobalIsSet("audit-log") {
+		config.AuditLogPath = ctx.GlobalString("audit-log")
+	}
+	if ctx.GlobalIsSet("audit-log-max-size") {
+		config.AuditLogMaxSize = ctx.GlobalInt64("audit-log-max-size")
+	}
+	if ctx.GlobalIsSet("audit-log-max-files") {
+		config.AuditLogMaxFiles = ctx.GlobalInt("audit-log-max-files")
+	}
+	if ctx.GlobalIsSet("session-recording-dir") {
+		config.SessionRecordingDir = ctx.GlobalString("session-recording-dir")
+	}
+	if ctx.GlobalIsSet("session-recording-max-size") {
+		config.SessionRecordingMaxSize = ctx.GlobalInt64("session-recording-max-size")
+	}
 	if ctx.GlobalIsSet("runtime") {
 		config.Runtime = ctx.GlobalString("runtime")
 	}
@@ -126,9 +206,21 @@ func mergeConfig(config *server.Config, ctx *cli.Context) error {
 	if ctx.GlobalIsSet("seccomp-profile") {
 		config.SeccompProfile = ctx.GlobalString("seccomp-profile")
 	}
+	if ctx.GlobalIsSet("seccomp-profile-root") {
+		config.SeccompProfileRoot = ctx.GlobalString("seccomp-profile-root")
+	}
+	if ctx.GlobalIsSet("seccomp-audit-dir") {
+		config.SeccompAuditDir = ctx.GlobalString("seccomp-audit-dir")
+	}
+	if ctx.GlobalIsSet("seccomp-audit-log") {
+		config.SeccompAuditLog = ctx.GlobalString("seccomp-audit-log")
+	}
 	if ctx.GlobalIsSet("apparmor-profile") {
 		config.ApparmorProfile = ctx.GlobalString("apparmor-profile")
 	}
+	if ctx.GlobalIsSet("apparmor-profile-dir") {
+		config.ApparmorProfileDir = ctx.GlobalString("apparmor-profile-dir")
+	}
 	if ctx.GlobalIsSet("cgroup-manager") {
 		config.CgroupManager = ctx.GlobalString("cgroup-manager")
 	}
@@ -150,6 +242,30 @@ func mergeConfig(config *server.Config, ctx *cli.Context) error {
 	if ctx.GlobalIsSet("log-size-max") {
 		config.LogSizeMax = ctx.GlobalInt64("log-size-max")
 	}
+	if ctx.GlobalIsSet("log-rotate-files") {
+		config.LogRotateFiles = ctx.GlobalInt("log-rotate-files")
+	}
+	if ctx.GlobalIsSet("log-rotate-size") {
+		config.LogRotateSize = ctx.GlobalInt64("log-rotate-size")
+	}
+	if ctx.GlobalIsSet("log-rotate-age") {
+		config.LogRotateAge = ctx.GlobalString("log-rotate-age")
+	}
+	if ctx.GlobalIsSet("log-rate-limit-bytes") {
+		config.LogRateLimitBytes = ctx.GlobalInt64("log-rate-limit-bytes")
+	}
+	if ctx.GlobalIsSet("log-rate-limit-lines") {
+		config.LogRateLimitLines = ctx.GlobalInt64("log-rate-limit-lines")
+	}
+	if ctx.GlobalIsSet("log-rate-limit-burst") {
+		config.LogRateLimitBurst = ctx.GlobalInt("log-rate-limit-burst")
+	}
+	if ctx.GlobalIsSet("log-driver") {
+		config.LogDrivers = ctx.GlobalStringSlice("log-driver")
+	}
+	if ctx.GlobalIsSet("log-syslog-address") {
+		config.LogSyslogAddress = ctx.GlobalString("log-syslog-address")
+	}
 	if ctx.GlobalIsSet("cni-config-dir") {
 		config.NetworkDir = ctx.GlobalString("cni-config-dir")
 	}
@@ -244,6 +360,28 @@ func main() {
 			Name:  "stream-port",
 			Usage: "bind port for streaming socket (default: \"0\")",
 		},
+		cli.StringFlag{
+			Name:  "audit-log",
+			Usage: "path of the JSON audit log of security relevant operations, empty to disable it",
+		},
+		cli.Int64Flag{
+			Name:  "audit-log-max-size",
+			Value: server.DefaultAuditLogMaxSize,
+			Usage: "size in bytes past which the audit log gets rotated, 0 to never rotate it",
+		},
+		cli.IntFlag{
+			Name:  "audit-log-max-files",
+			Value: server.DefaultAuditLogMaxFiles,
+			Usage: "number of rotated audit logs kept around",
+		},
+		cli.StringFlag{
+			Name:  "session-recording-dir",
+			Usage: "directory exec and attach sessions get recorded to, empty to not record them",
+		},
+		cli.Int64Flag{
+			Name:  "session-recording-max-size",
+			Usage: "size in bytes the session recordings may take in total before removing the oldest ones, 0 to never remove them",
+		},
 		cli.StringFlag{
 			Name:  "log",
 			Value: "",
@@ -299,6 +437,22 @@ func main() {
 			Name:  "registry",
 			Usage: "registry to be prepended when pulling unqualified images, can be specified multiple times",
 		},
+		cli.IntFlag{
+			Name:  "image-gc-high-threshold",
+			Usage: "percentage of the image filesystem usage above which unused images get removed, 0 to disable the image garbage collection",
+		},
+		cli.IntFlag{
+			Name:  "image-gc-low-threshold",
+			Usage: "percentage of the image filesystem usage the image garbage collection frees space down to",
+		},
+		cli.StringFlag{
+			Name:  "image-gc-min-age",
+			Usage: "minimum age of the images removed by the image garbage collection",
+		},
+		cli.StringSliceFlag{
+			Name:  "pinned-image",
+			Usage: "image never removed by the image garbage collection, can be specified multiple times",
+		},
 		cli.StringFlag{
 			Name:  "default-transport",
 			Usage: "default transport",
@@ -311,10 +465,26 @@ func main() {
 			Name:  "seccomp-profile",
 			Usage: "default seccomp profile path",
 		},
+		cli.StringFlag{
+			Name:  "seccomp-profile-root",
+			Usage: "directory localhost/<path> seccomp profiles are looked up in",
+		},
+		cli.StringFlag{
+			Name:  "seccomp-audit-dir",
+			Usage: "directory the seccomp profiles generated by the seccomp audit are written to",
+		},
+		cli.StringFlag{
+			Name:  "seccomp-audit-log",
+			Usage: "kernel log device or audit daemon log the seccomp audit reads syscalls from",
+		},
 		cli.StringFlag{
 			Name:  "apparmor-profile",
 			Usage: "default apparmor profile name (default: \"crio-default\")",
 		},
+		cli.StringFlag{
+			Name:  "apparmor-profile-dir",
+			Usage: "directory of apparmor profiles loaded at startup and on change",
+		},
 		cli.BoolFlag{
 			Name:  "selinux",
 			Usage: "enable selinux support",
@@ -333,6 +503,39 @@ func main() {
 			Value: lib.DefaultLogSizeMax,
 			Usage: "maximum log size in bytes for a container",
 		},
+		cli.IntFlag{
+			Name:  "log-rotate-files",
+			Usage: "number of compressed generations of the container log files kept once rotated, 0 not to rotate them",
+		},
+		cli.Int64Flag{
+			Name:  "log-rotate-size",
+			Usage: "size in bytes the container log files are rotated at, 0 not to rotate them by size",
+		},
+		cli.StringFlag{
+			Name:  "log-rotate-age",
+			Usage: "how long the container log files are written to before being rotated, e.g. 24h, empty not to rotate them by age",
+		},
+		cli.Int64Flag{
+			Name:  "log-rate-limit-bytes",
+			Usage: "bytes of output per second written to the log of each container, 0 not to limit them",
+		},
+		cli.Int64Flag{
+			Name:  "log-rate-limit-lines",
+			Usage: "lines of output per second written to the log of each container, 0 not to limit them",
+		},
+		cli.IntFlag{
+			Name:  "log-rate-limit-burst",
+			Value: lib.DefaultLogRateLimitBurst,
+			Usage: "seconds of output at the log rate limits a container may write at once",
+		},
+		cli.StringSliceFlag{
+			Name:  "log-driver",
+			Usage: "log driver the output of the containers is written with, k8s-file (default), journald or syslog, can be specified multiple times",
+		},
+		cli.StringFlag{
+			Name:  "log-syslog-address",
+			Usage: "address of the remote syslog server the syslog log driver sends the output of the containers to, as udp://host:port or tcp://host:port",
+		},
 		cli.StringFlag{
 			Name:  "cni-config-dir",
 			Usage: "CNI configuration files directory",
@@ -489,6 +692,15 @@ func main() {
 			return fmt.Errorf("invalid --runtime value %q", err)
 		}
 
+		for name, handler := range config.Runtimes {
+			if handler.RuntimeType == oci.RuntimeTypeProcess {
+				continue
+			}
+			if _, err := os.Stat(handler.RuntimePath); os.IsNotExist(err) {
+				return fmt.Errorf("invalid runtime_path for runtime handler %q: %v", name, err)
+			}
+		}
+
 		if err := os.MkdirAll(filepath.Dir(config.Listen), 0755); err != nil {
 			return err
 		}
@@ -504,7 +716,12 @@ func main() {
 			logrus.Fatalf("failed to listen: %v", err)
 		}
 
-		s := grpc.NewServer()
+		var grpcOptions []grpc.ServerOption
+		if config.AuditLogPath != "" {
+			// Identify the clients for the audit log.
+			grpcOptions = append(grpcOptions, grpc.Creds(server.NewPeerCredentials()))
+		}
+		s := grpc.NewServer(grpcOptions...)
 
 		service, err := server.New(ctx, config)
 		if err != nil {
@@ -537,6 +754,10 @@ func main() {
 		go func() {
 			service.StartExitMonitor()
 		}()
+		go service.StartOOMMonitor()
+		go service.StartImageGC()
+		go service.StartSeccompAuditMonitor()
+		go service.StartAppArmorProfileMonitor()
 		hookSync := make(chan error, 2)
 		if service.ContainerServer.Hooks == nil {
 			hookSync <- err // so we don't block during cleanup
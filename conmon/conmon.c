@@ -3,6 +3,7 @@
 #include <errno.h>
 #include <fcntl.h>
 #include <limits.h>
+#include <netdb.h>
 #include <stdbool.h>
 #include <stdio.h>
 #include <stdlib.h>
@@ -14,10 +15,13 @@
 #include <sys/un.h>
 #include <sys/wait.h>
 #include <sys/eventfd.h>
+#include <sys/inotify.h>
 #include <sys/stat.h>
+#include <sys/statfs.h>
 #include <sys/uio.h>
 #include <sys/ioctl.h>
 #include <termios.h>
+#include <time.h>
 #include <syslog.h>
 #include <unistd.h>
 #include <inttypes.h>
@@ -30,6 +34,7 @@
 
 #include <glib.h>
 #include <glib-unix.h>
+#include <zlib.h>
 
 #include "cmsg.h"
 #include "config.h"
@@ -83,6 +88,7 @@ static gboolean opt_syslog = FALSE;
 static char *opt_cid = NULL;
 static char *opt_cuuid = NULL;
 static char *opt_runtime_path = NULL;
+static gchar **opt_runtime_args = NULL;
 static char *opt_bundle_path = NULL;
 static char *opt_container_pid_file = NULL;
 static char *opt_conmon_pid_file = NULL;
@@ -95,6 +101,17 @@ static char *opt_log_path = NULL;
 static char *opt_exit_dir = NULL;
 static int opt_timeout = 0;
 static int64_t opt_log_size_max = -1;
+static int opt_log_rotate_files = 0;
+static int64_t opt_log_rotate_size = 0;
+static int opt_log_rotate_age = 0;
+static gchar **opt_log_drivers = NULL;
+static char *opt_log_syslog_address = NULL;
+static char *opt_name = NULL;
+static char *opt_pod_name = NULL;
+static char *opt_pod_namespace = NULL;
+static int64_t opt_log_rate_limit_bytes = 0;
+static int64_t opt_log_rate_limit_lines = 0;
+static int opt_log_rate_limit_burst = 1;
 static char *opt_socket_path = DEFAULT_SOCKET_PATH;
 static gboolean opt_no_new_keyring = FALSE;
 static char *opt_exit_command = NULL;
@@ -107,6 +124,8 @@ static GOptionEntry opt_entries[] = {
 	{"cid", 'c', 0, G_OPTION_ARG_STRING, &opt_cid, "Container ID", NULL},
 	{"cuuid", 'u', 0, G_OPTION_ARG_STRING, &opt_cuuid, "Container UUID", NULL},
 	{"runtime", 'r', 0, G_OPTION_ARG_STRING, &opt_runtime_path, "Runtime path", NULL},
+	{"runtime-arg", 0, 0, G_OPTION_ARG_STRING_ARRAY, &opt_runtime_args,
+	 "Additional global arg to pass to the runtime.  Can be specified multiple times", NULL},
 	{"restore", 0, 0, G_OPTION_ARG_STRING, &opt_restore_path, "Restore a container from a checkpoint", NULL},
 	{"no-new-keyring", 0, 0, G_OPTION_ARG_NONE, &opt_no_new_keyring, "Do not create a new session keyring for the container", NULL},
 	{"no-pivot", 0, 0, G_OPTION_ARG_NONE, &opt_no_pivot, "Do not use pivot_root", NULL},
@@ -126,6 +145,24 @@ static GOptionEntry opt_entries[] = {
 	{"log-path", 'l', 0, G_OPTION_ARG_STRING, &opt_log_path, "Log file path", NULL},
 	{"timeout", 'T', 0, G_OPTION_ARG_INT, &opt_timeout, "Timeout in seconds", NULL},
 	{"log-size-max", 0, 0, G_OPTION_ARG_INT64, &opt_log_size_max, "Maximum size of log file", NULL},
+	{"log-rotate-files", 0, 0, G_OPTION_ARG_INT, &opt_log_rotate_files,
+	 "Number of compressed generations of the log file kept when rotating it", NULL},
+	{"log-rotate-size", 0, 0, G_OPTION_ARG_INT64, &opt_log_rotate_size, "Size of the log file to rotate it at", NULL},
+	{"log-rotate-age", 0, 0, G_OPTION_ARG_INT, &opt_log_rotate_age,
+	 "Seconds the log file gets written to before rotating it", NULL},
+	{"log-driver", 0, 0, G_OPTION_ARG_STRING_ARRAY, &opt_log_drivers,
+	 "Log driver to write the container output with, k8s-file (default), journald or syslog.  Can be specified multiple times", NULL},
+	{"log-syslog-address", 0, 0, G_OPTION_ARG_STRING, &opt_log_syslog_address,
+	 "Address of the remote syslog server for the syslog log driver, udp://host:port or tcp://host:port", NULL},
+	{"name", 0, 0, G_OPTION_ARG_STRING, &opt_name, "Container name, for the journald log driver", NULL},
+	{"pod-name", 0, 0, G_OPTION_ARG_STRING, &opt_pod_name, "Pod name, for the journald log driver", NULL},
+	{"pod-namespace", 0, 0, G_OPTION_ARG_STRING, &opt_pod_namespace, "Pod namespace, for the journald log driver", NULL},
+	{"log-rate-limit-bytes", 0, 0, G_OPTION_ARG_INT64, &opt_log_rate_limit_bytes,
+	 "Bytes of container output per second written to the log, beyond which it is dropped", NULL},
+	{"log-rate-limit-lines", 0, 0, G_OPTION_ARG_INT64, &opt_log_rate_limit_lines,
+	 "Lines of container output per second written to the log, beyond which they are dropped", NULL},
+	{"log-rate-limit-burst", 0, 0, G_OPTION_ARG_INT, &opt_log_rate_limit_burst,
+	 "Seconds of container output at the log rate limits which may be written at once", NULL},
 	{"socket-dir-path", 0, 0, G_OPTION_ARG_STRING, &opt_socket_path, "Location of container attach sockets", NULL},
 	{"version", 0, 0, G_OPTION_ARG_NONE, &opt_version, "Print the version and exit", NULL},
 	{"syslog", 0, 0, G_OPTION_ARG_NONE, &opt_syslog, "Log to syslog (use with cgroupfs cgroup manager)", NULL},
@@ -136,7 +173,71 @@ static GOptionEntry opt_entries[] = {
 
 #define CGROUP_ROOT "/sys/fs/cgroup"
 
+#ifndef CGROUP2_SUPER_MAGIC
+#define CGROUP2_SUPER_MAGIC 0x63677270
+#endif
+
 static int log_fd = -1;
+/* The size of the log file, and the time it got opened at. */
+static int64_t log_size = 0;
+static gint64 log_opened_at = 0;
+/* The process compressing the last rotated generation of the log file. */
+static pid_t log_compress_pid = 0;
+
+/* The log drivers the container output is written with. */
+static bool log_to_file = TRUE;
+static bool log_to_journald = FALSE;
+static bool log_to_syslog = FALSE;
+
+#define JOURNALD_SOCKET "/run/systemd/journal/socket"
+
+/* The socket of journald, and the fields of every entry sent to it. */
+static int journald_fd = -1;
+static GString *journald_fields = NULL;
+
+/* How long to wait for the remote syslog server, before trying again. */
+#define SYSLOG_TIMEOUT_SECS 1
+#define SYSLOG_RETRY_INTERVAL_SECS 10
+
+/*
+ * The host and the port of the remote syslog server, whether it's reached
+ * over TCP, the socket connected to it, and the header of the messages sent
+ * to it following their priority and timestamp.
+ */
+static char *syslog_host = NULL;
+static char *syslog_port = NULL;
+static bool syslog_stream = FALSE;
+static int syslog_fd = -1;
+static char *syslog_header = NULL;
+
+/* The file of the bundle the counts of the output dropped over the log rate
+ * limit are written to. Sync with const in oci/log_dropped.go */
+#define LOG_DROPPED_FILE "log-dropped.json"
+
+/*
+ * The log rate limit allowances left, in bytes and lines, the last time they
+ * got refilled, and the counts of the output dropped over it: in total, and
+ * by stream since the last marker written to the log.
+ */
+static double log_rate_bytes = 0;
+static double log_rate_lines = 0;
+static gint64 log_rate_refilled_at = 0;
+static uint64_t log_dropped_bytes = 0;
+static uint64_t log_dropped_lines = 0;
+static uint64_t log_unmarked_bytes[STDERR_PIPE + 1] = {0};
+static uint64_t log_unmarked_lines[STDERR_PIPE + 1] = {0};
+
+/*
+ * Whether the rest of the line last read from a stream, which didn't end
+ * yet, gets written to the log or dropped. The log rate limit applies to
+ * whole lines, whatever the parts they get read in.
+ */
+enum log_line_state {
+	LOG_LINE_START,
+	LOG_LINE_WRITTEN,
+	LOG_LINE_DROPPED,
+};
+static enum log_line_state log_line_states[STDERR_PIPE + 1] = {LOG_LINE_START};
 
 #define pexit(s) \
 	do { \
@@ -343,6 +444,9 @@ const char *stdpipe_name(stdpipe_t pipe)
  */
 static void reopen_log_file(void)
 {
+	if (!log_to_file)
+		return;
+
 	_cleanup_free_ char *opt_log_path_tmp = g_strdup_printf("%s.tmp", opt_log_path);
 
 	/* Close the current log_fd */
@@ -357,6 +461,376 @@ static void reopen_log_file(void)
 	if (rename(opt_log_path_tmp, opt_log_path) < 0) {
 		pexit("Failed to rename log file");
 	}
+
+	log_size = 0;
+	log_opened_at = g_get_monotonic_time();
+}
+
+/*
+ * log_generation_path returns the path of a generation of the log file,
+ * compressed or not.
+ */
+static char *log_generation_path(int generation, bool compressed)
+{
+	return g_strdup_printf("%s.%d%s", opt_log_path, generation, compressed ? ".gz" : "");
+}
+
+/*
+ * compress_log_file compresses a rotated generation of the log file, and
+ * removes it once compressed. It runs in a child process of conmon.
+ */
+static int compress_log_file(const char *path)
+{
+	_cleanup_free_ char *gz_path = g_strdup_printf("%s.gz", path);
+	_cleanup_free_ char *gz_path_tmp = g_strdup_printf("%s.gz.tmp", path);
+	char buf[BUF_SIZE];
+	ssize_t num_read;
+	gzFile gz;
+	int in_fd, out_fd;
+
+	in_fd = open(path, O_RDONLY | O_CLOEXEC);
+	if (in_fd < 0) {
+		nwarnf("Failed to open log file %s", path);
+		return -1;
+	}
+	out_fd = open(gz_path_tmp, O_WRONLY | O_TRUNC | O_CREAT | O_CLOEXEC, 0600);
+	if (out_fd < 0) {
+		nwarnf("Failed to open log file %s", gz_path_tmp);
+		close(in_fd);
+		return -1;
+	}
+	gz = gzdopen(out_fd, "wb");
+	if (gz == NULL) {
+		nwarnf("Failed to compress log file %s", path);
+		close(out_fd);
+		goto fail;
+	}
+	while ((num_read = read(in_fd, buf, sizeof buf)) != 0) {
+		if (num_read < 0) {
+			if (errno == EINTR)
+				continue;
+			nwarnf("Failed to read log file %s", path);
+			gzclose(gz);
+			goto fail;
+		}
+		if (gzwrite(gz, buf, num_read) != num_read) {
+			nwarnf("Failed to compress log file %s", path);
+			gzclose(gz);
+			goto fail;
+		}
+	}
+	if (gzclose(gz) != Z_OK) {
+		nwarnf("Failed to compress log file %s", path);
+		goto fail;
+	}
+	close(in_fd);
+
+	if (rename(gz_path_tmp, gz_path) < 0) {
+		nwarnf("Failed to rename log file %s", gz_path_tmp);
+		unlink(gz_path_tmp);
+		return -1;
+	}
+	if (unlink(path) < 0) {
+		nwarnf("Failed to remove log file %s", path);
+		return -1;
+	}
+	return 0;
+
+fail:
+	close(in_fd);
+	unlink(gz_path_tmp);
+	return -1;
+}
+
+/*
+ * log_rotation_due returns whether the log file is to be rotated before
+ * writing len more bytes to it. An empty log file never gets rotated, so a
+ * log file is rotated by age on the first write after it's old enough.
+ */
+static bool log_rotation_due(int64_t len)
+{
+	if (opt_log_rotate_files <= 0 || log_size == 0)
+		return FALSE;
+	if (opt_log_rotate_size > 0 && log_size + len > opt_log_rotate_size)
+		return TRUE;
+	if (opt_log_rotate_age > 0 && g_get_monotonic_time() - log_opened_at >= (gint64)opt_log_rotate_age * G_USEC_PER_SEC)
+		return TRUE;
+	return FALSE;
+}
+
+/*
+ * rotate_log_file renames the log file to its first generation, shifting
+ * the previous ones and dropping the oldest, opens a new log file and
+ * compresses the first generation in a child process.
+ */
+static void rotate_log_file(void)
+{
+	_cleanup_free_ char *first = log_generation_path(1, FALSE);
+	int status;
+	pid_t pid;
+
+	/* The previous generation is to be compressed before being shifted. */
+	if (log_compress_pid > 0) {
+		while (waitpid(log_compress_pid, &status, 0) < 0 && errno == EINTR)
+			;
+		log_compress_pid = 0;
+	}
+
+	for (int i = opt_log_rotate_files; i > 1; i--) {
+		for (int compressed = 0; compressed <= 1; compressed++) {
+			_cleanup_free_ char *from = log_generation_path(i - 1, compressed);
+			_cleanup_free_ char *to = log_generation_path(i, compressed);
+
+			if (rename(from, to) < 0 && errno != ENOENT)
+				nwarnf("Failed to rename log file %s", from);
+		}
+	}
+
+	if (rename(opt_log_path, first) < 0) {
+		nwarnf("Failed to rotate log file %s", opt_log_path);
+		/* Don't try again before the log file grows or ages again. */
+		log_size = 0;
+		log_opened_at = g_get_monotonic_time();
+		return;
+	}
+	reopen_log_file();
+
+	pid = fork();
+	if (pid < 0) {
+		nwarn("Failed to fork the log file compression");
+		return;
+	}
+	if (pid == 0)
+		_exit(compress_log_file(first) < 0 ? EXIT_FAILURE : EXIT_SUCCESS);
+	log_compress_pid = pid;
+}
+
+/*
+ * journald_append_field appends a field to a journal entry, in the binary
+ * format of the journald native protocol so that the value may hold any byte.
+ */
+static void journald_append_field(GString *entry, const char *name, const char *value, size_t len)
+{
+	uint64_t le_len = GUINT64_TO_LE(len);
+
+	g_string_append(entry, name);
+	g_string_append_c(entry, '\n');
+	g_string_append_len(entry, (const char *)&le_len, sizeof le_len);
+	g_string_append_len(entry, value, len);
+	g_string_append_c(entry, '\n');
+}
+
+/*
+ * init_journald_log opens the socket of journald and sets up the fields
+ * identifying the container in the entries sent to it.
+ */
+static void init_journald_log(void)
+{
+	_cleanup_free_ char *short_id = g_strndup(opt_cid, 12);
+
+	journald_fd = socket(AF_UNIX, SOCK_DGRAM | SOCK_CLOEXEC, 0);
+	if (journald_fd < 0)
+		pexit("Failed to create the journald socket");
+
+	journald_fields = g_string_new(NULL);
+	journald_append_field(journald_fields, "CONTAINER_ID", short_id, strlen(short_id));
+	journald_append_field(journald_fields, "CONTAINER_ID_FULL", opt_cid, strlen(opt_cid));
+	journald_append_field(journald_fields, "SYSLOG_IDENTIFIER", short_id, strlen(short_id));
+	if (opt_name)
+		journald_append_field(journald_fields, "CONTAINER_NAME", opt_name, strlen(opt_name));
+	if (opt_pod_name)
+		journald_append_field(journald_fields, "POD_NAME", opt_pod_name, strlen(opt_pod_name));
+	if (opt_pod_namespace)
+		journald_append_field(journald_fields, "POD_NAMESPACE", opt_pod_namespace, strlen(opt_pod_namespace));
+}
+
+/*
+ * write_journald_log sends an entry to the journal for every line in buf,
+ * with the priority telling its stream. The final line of buf is sent as a
+ * partial message if buf is not terminated by a newline.
+ */
+static void write_journald_log(stdpipe_t pipe, const char *buf, ssize_t buflen)
+{
+	static bool warned = FALSE;
+	struct sockaddr_un addr = {.sun_family = AF_UNIX};
+	const char *priority = pipe == STDERR_PIPE ? "3" : "6";
+
+	strncpy(addr.sun_path, JOURNALD_SOCKET, sizeof(addr.sun_path) - 1);
+
+	while (buflen > 0) {
+		const char *line_end = memchr(buf, '\n', buflen);
+		bool partial = line_end == NULL;
+		ptrdiff_t line_len = partial ? buflen : line_end - buf + 1;
+		GString *entry = g_string_new_len(journald_fields->str, journald_fields->len);
+
+		/* The newline isn't part of the message. */
+		journald_append_field(entry, "MESSAGE", buf, partial ? line_len : line_len - 1);
+		journald_append_field(entry, "PRIORITY", priority, 1);
+		if (partial)
+			journald_append_field(entry, "CONTAINER_PARTIAL_MESSAGE", "true", 4);
+
+		if (sendto(journald_fd, entry->str, entry->len, MSG_NOSIGNAL, (struct sockaddr *)&addr, sizeof addr) < 0) {
+			/* Don't flood the logs if journald isn't running. */
+			if (!warned) {
+				nwarnf("Failed to write to the journal: %s", strerror(errno));
+				warned = TRUE;
+			}
+		}
+		g_string_free(entry, TRUE);
+
+		buf += line_len;
+		buflen -= line_len;
+	}
+}
+
+/*
+ * init_syslog_log parses the address of the remote syslog server, as
+ * udp://host:port or tcp://host:port, and sets up the header identifying the
+ * container in the messages sent to it. The server gets connected to when
+ * the first message is sent.
+ */
+static void init_syslog_log(void)
+{
+	_cleanup_free_ char *short_id = g_strndup(opt_cid, 12);
+	char hostname[HOST_NAME_MAX + 1] = "-";
+	const char *address = NULL;
+	const char *port = NULL;
+
+	if (g_str_has_prefix(opt_log_syslog_address, "udp://"))
+		syslog_stream = FALSE;
+	else if (g_str_has_prefix(opt_log_syslog_address, "tcp://"))
+		syslog_stream = TRUE;
+	else
+		nexitf("Unsupported syslog address %s", opt_log_syslog_address);
+
+	address = opt_log_syslog_address + strlen("udp://");
+	port = strrchr(address, ':');
+	if (port == NULL || port[1] == '\0')
+		nexitf("No port in syslog address %s", opt_log_syslog_address);
+	/* IPv6 addresses are enclosed in brackets. */
+	if (address[0] == '[' && port - address > 2 && port[-1] == ']')
+		syslog_host = g_strndup(address + 1, port - address - 2);
+	else
+		syslog_host = g_strndup(address, port - address);
+	syslog_port = g_strdup(port + 1);
+
+	if (gethostname(hostname, sizeof hostname - 1) < 0 || hostname[0] == '\0')
+		strcpy(hostname, "-");
+	/* HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA */
+	syslog_header = g_strdup_printf("%s %s - - - ", hostname, short_id);
+}
+
+/*
+ * connect_syslog connects to the remote syslog server, waiting for it at most
+ * SYSLOG_TIMEOUT_SECS, which also applies to the messages sent to it.
+ */
+static int connect_syslog(void)
+{
+	struct addrinfo hints = {.ai_family = AF_UNSPEC, .ai_socktype = syslog_stream ? SOCK_STREAM : SOCK_DGRAM};
+	struct addrinfo *addrs = NULL;
+	struct timeval timeout = {.tv_sec = SYSLOG_TIMEOUT_SECS};
+	int fd = -1;
+	int ret;
+
+	ret = getaddrinfo(syslog_host, syslog_port, &hints, &addrs);
+	if (ret != 0) {
+		nwarnf("Failed to resolve the syslog server %s: %s", syslog_host, gai_strerror(ret));
+		return -1;
+	}
+	for (struct addrinfo *ai = addrs; ai != NULL; ai = ai->ai_next) {
+		fd = socket(ai->ai_family, ai->ai_socktype | SOCK_CLOEXEC, ai->ai_protocol);
+		if (fd < 0)
+			continue;
+		/* On Linux, the send timeout applies to connect too. */
+		if (setsockopt(fd, SOL_SOCKET, SO_SNDTIMEO, &timeout, sizeof timeout) == 0 && connect(fd, ai->ai_addr, ai->ai_addrlen) == 0)
+			break;
+		close(fd);
+		fd = -1;
+	}
+	freeaddrinfo(addrs);
+	if (fd < 0)
+		nwarnf("Failed to connect to the syslog server %s: %s", opt_log_syslog_address, strerror(errno));
+	return fd;
+}
+
+/*
+ * send_syslog_message sends a message to the remote syslog server, connecting
+ * to it first if needed. The messages are dropped while it can't be reached,
+ * until trying again after SYSLOG_RETRY_INTERVAL_SECS.
+ */
+static void send_syslog_message(const char *msg, size_t len)
+{
+	static gint64 retry_at = 0;
+	ssize_t sent;
+
+	if (syslog_fd < 0) {
+		if (g_get_monotonic_time() < retry_at)
+			return;
+		syslog_fd = connect_syslog();
+		if (syslog_fd < 0) {
+			retry_at = g_get_monotonic_time() + SYSLOG_RETRY_INTERVAL_SECS * G_USEC_PER_SEC;
+			return;
+		}
+	}
+
+	while (len > 0) {
+		sent = send(syslog_fd, msg, len, MSG_NOSIGNAL);
+		if (sent < 0 && errno == EINTR)
+			continue;
+		if (sent < 0) {
+			/* The errors of connected UDP sockets come from ICMP
+			   messages about earlier datagrams, this one is lost. */
+			nwarnf("Failed to send to the syslog server %s: %s", opt_log_syslog_address, strerror(errno));
+			if (syslog_stream) {
+				close(syslog_fd);
+				syslog_fd = -1;
+			}
+			return;
+		}
+		msg += sent;
+		len -= sent;
+	}
+}
+
+/*
+ * write_syslog_log sends a RFC 5424 message to the remote syslog server for
+ * every line in buf, with the severity telling its stream. The final line of
+ * buf is sent on its own if buf is not terminated by a newline. Over TCP, the
+ * messages are framed by octet counting, as RFC 6587 describes.
+ */
+static void write_syslog_log(stdpipe_t pipe, const char *buf, ssize_t buflen)
+{
+	int priority = LOG_USER | (pipe == STDERR_PIPE ? LOG_ERR : LOG_INFO);
+	gint64 now = g_get_real_time();
+	time_t secs = now / G_USEC_PER_SEC;
+	char timestamp[64];
+	struct tm tm;
+
+	/* Use the same timestamp for every line, like for the log file. */
+	if (gmtime_r(&secs, &tm) == NULL)
+		return;
+	snprintf(timestamp, sizeof timestamp, "%d-%02d-%02dT%02d:%02d:%02d.%06" PRId64 "Z", tm.tm_year + 1900, tm.tm_mon + 1,
+		 tm.tm_mday, tm.tm_hour, tm.tm_min, tm.tm_sec, (int64_t)(now % G_USEC_PER_SEC));
+
+	while (buflen > 0) {
+		const char *line_end = memchr(buf, '\n', buflen);
+		bool partial = line_end == NULL;
+		ptrdiff_t line_len = partial ? buflen : line_end - buf + 1;
+		GString *msg = g_string_new(NULL);
+
+		g_string_printf(msg, "<%d>1 %s %s", priority, timestamp, syslog_header);
+		/* The newline isn't part of the message. */
+		g_string_append_len(msg, buf, partial ? line_len : line_len - 1);
+		if (syslog_stream) {
+			_cleanup_free_ char *frame = g_strdup_printf("%" G_GSIZE_FORMAT " ", msg->len);
+			g_string_prepend(msg, frame);
+		}
+		send_syslog_message(msg->str, msg->len);
+		g_string_free(msg, TRUE);
+
+		buf += line_len;
+		buflen -= line_len;
+	}
 }
 
 /*
@@ -402,6 +876,22 @@ static int write_k8s_log(int fd, stdpipe_t pipe, const char *buf, ssize_t buflen
 			bytes_to_be_written += 1;
 		}
 
+		/*
+		 * We rotate the log file if it's due, before writing out the line.
+		 */
+		if (log_rotation_due(bytes_to_be_written)) {
+			bytes_written = 0;
+
+			if (writev_buffer_flush(fd, &bufv) < 0) {
+				nwarn("failed to flush buffer to log");
+				bufv.iovcnt = 0;
+			}
+			rotate_log_file();
+
+			/* Reassign to the new log file fd */
+			fd = log_fd;
+		}
+
 		/*
 		 * We re-open the log file if writing out the bytes will exceed the max
 		 * log size. We also reset the state so that the new file is started with
@@ -458,6 +948,7 @@ static int write_k8s_log(int fd, stdpipe_t pipe, const char *buf, ssize_t buflen
 		}
 
 		bytes_written += bytes_to_be_written;
+		log_size += bytes_to_be_written;
 	next:
 		/* Update the head of the buffer remaining to output. */
 		buf += line_len;
@@ -472,11 +963,166 @@ static int write_k8s_log(int fd, stdpipe_t pipe, const char *buf, ssize_t buflen
 }
 
 /*
- * Returns the path for specified controller name for a pid.
+ * write_log writes buf to the log of the container with every log driver.
+ */
+static int write_log(stdpipe_t pipe, const char *buf, ssize_t buflen)
+{
+	if (log_to_journald)
+		write_journald_log(pipe, buf, buflen);
+
+	if (log_to_syslog)
+		write_syslog_log(pipe, buf, buflen);
+
+	if (log_to_file && write_k8s_log(log_fd, pipe, buf, buflen) < 0) {
+		nwarn("write_k8s_log failed");
+		return -1;
+	}
+	return 0;
+}
+
+static bool log_rate_limited(void)
+{
+	return opt_log_rate_limit_bytes > 0 || opt_log_rate_limit_lines > 0;
+}
+
+/*
+ * refill_log_rate adds to the log rate limit allowances what the time since
+ * they got last refilled allows, up to the burst.
+ */
+static void refill_log_rate(void)
+{
+	gint64 now = g_get_monotonic_time();
+	double elapsed = (double)(now - log_rate_refilled_at) / G_USEC_PER_SEC;
+
+	log_rate_refilled_at = now;
+	log_rate_bytes = MIN(log_rate_bytes + elapsed * opt_log_rate_limit_bytes, (double)opt_log_rate_limit_bytes * opt_log_rate_limit_burst);
+	log_rate_lines = MIN(log_rate_lines + elapsed * opt_log_rate_limit_lines, (double)opt_log_rate_limit_lines * opt_log_rate_limit_burst);
+}
+
+/*
+ * rate_limit_log copies to out the lines of buf the log rate limit allows,
+ * counting the ones dropped, and returns the length of out. A line longer
+ * than the burst allows is written once the allowances are full, which they
+ * then owe. A line read in several parts is written or dropped as a whole,
+ * as decided when its first part got read, for the parts written to the log
+ * to join back: the allowances owe the bytes of the parts written after it.
+ */
+static ssize_t rate_limit_log(stdpipe_t pipe, const char *buf, ssize_t buflen, char *out)
+{
+	double max_bytes = (double)opt_log_rate_limit_bytes * opt_log_rate_limit_burst;
+	ssize_t outlen = 0;
+
+	refill_log_rate();
+
+	while (buflen > 0) {
+		const char *line_end = memchr(buf, '\n', buflen);
+		ptrdiff_t line_len = line_end == NULL ? buflen : line_end - buf + 1;
+		bool write = log_line_states[pipe] == LOG_LINE_WRITTEN;
+
+		if (log_line_states[pipe] == LOG_LINE_START) {
+			bool bytes_allowed = opt_log_rate_limit_bytes <= 0 || log_rate_bytes >= line_len || log_rate_bytes >= max_bytes;
+			bool lines_allowed = opt_log_rate_limit_lines <= 0 || log_rate_lines >= 1;
+
+			write = bytes_allowed && lines_allowed;
+			if (write && opt_log_rate_limit_lines > 0)
+				log_rate_lines -= 1;
+			if (!write) {
+				log_dropped_lines++;
+				log_unmarked_lines[pipe]++;
+			}
+		}
+
+		if (write) {
+			if (opt_log_rate_limit_bytes > 0)
+				log_rate_bytes -= line_len;
+			memcpy(out + outlen, buf, line_len);
+			outlen += line_len;
+		} else {
+			log_dropped_bytes += line_len;
+			log_unmarked_bytes[pipe] += line_len;
+		}
+
+		if (line_end != NULL)
+			log_line_states[pipe] = LOG_LINE_START;
+		else
+			log_line_states[pipe] = write ? LOG_LINE_WRITTEN : LOG_LINE_DROPPED;
+
+		buf += line_len;
+		buflen -= line_len;
+	}
+
+	return outlen;
+}
+
+/*
+ * mark_log_dropped writes a line to the log of every stream output got
+ * dropped from since the last time, telling how much, and writes the total
+ * counts to the bundle for cri-o to report them.
+ */
+static void mark_log_dropped(void)
+{
+	_cleanup_free_ char *dropped_path = NULL;
+	_cleanup_free_ char *dropped = NULL;
+	GError *err = NULL;
+	bool marked = FALSE;
+
+	for (stdpipe_t pipe = STDOUT_PIPE; pipe <= STDERR_PIPE; pipe++) {
+		/* The marker can't go in the middle of a line being written. */
+		if (log_unmarked_lines[pipe] == 0 || log_line_states[pipe] == LOG_LINE_WRITTEN)
+			continue;
+
+		_cleanup_free_ char *marker = g_strdup_printf("[conmon: dropped %" PRIu64 " lines (%" PRIu64
+							      " bytes) of output over the log rate limit]\n",
+							      log_unmarked_lines[pipe], log_unmarked_bytes[pipe]);
+		write_log(pipe, marker, strlen(marker));
+		log_unmarked_lines[pipe] = 0;
+		log_unmarked_bytes[pipe] = 0;
+		marked = TRUE;
+	}
+	if (!marked || opt_bundle_path == NULL)
+		return;
+
+	dropped_path = g_build_filename(opt_bundle_path, LOG_DROPPED_FILE, NULL);
+	dropped = g_strdup_printf("{\"lines\":%" PRIu64 ",\"bytes\":%" PRIu64 "}\n", log_dropped_lines, log_dropped_bytes);
+	if (!g_file_set_contents(dropped_path, dropped, -1, &err)) {
+		nwarnf("Failed to write %s: %s", dropped_path, err->message);
+		g_error_free(err);
+	}
+}
+
+static gboolean log_dropped_cb(G_GNUC_UNUSED gpointer user_data)
+{
+	mark_log_dropped();
+	return G_SOURCE_CONTINUE;
+}
+
+/*
+ * Returns whether the host uses the unified cgroup hierarchy only, where all
+ * the controllers share the same cgroups.
+ */
+static bool is_cgroup_v2(void)
+{
+	static int cgroup_v2 = -1;
+	struct statfs stfs;
+
+	if (cgroup_v2 == -1) {
+		if (statfs(CGROUP_ROOT, &stfs) < 0) {
+			nwarnf("Failed to stat %s", CGROUP_ROOT);
+			return FALSE;
+		}
+		cgroup_v2 = stfs.f_type == CGROUP2_SUPER_MAGIC;
+	}
+	return cgroup_v2;
+}
+
+/*
+ * Returns the path for specified controller name for a pid, which on the
+ * unified hierarchy is the path of its only cgroup.
  * Returns NULL on error.
  */
 static char *process_cgroup_subsystem_path(int pid, const char *subsystem)
 {
+	bool cgroup_v2 = is_cgroup_v2();
 	_cleanup_free_ char *cgroups_file_path = g_strdup_printf("/proc/%d/cgroup", pid);
 	_cleanup_fclose_ FILE *fp = NULL;
 	fp = fopen(cgroups_file_path, "re");
@@ -506,6 +1152,14 @@ static char *process_cgroup_subsystem_path(int pid, const char *subsystem)
 		}
 		*path = 0;
 		path++;
+		if (cgroup_v2) {
+			/* The unified hierarchy is "0::<path>". */
+			if (strcmp(line, "0:") != 0)
+				continue;
+			subsystem_path = g_strdup_printf("%s%s", CGROUP_ROOT, path);
+			subsystem_path[strcspn(subsystem_path, "\n")] = '\0';
+			return subsystem_path;
+		}
 		subsystems = g_strsplit(ptr, ",", -1);
 		for (i = 0; subsystems[i] != NULL; i++) {
 			if (strcmp(subsystems[i], subsystem) == 0) {
@@ -608,6 +1262,10 @@ struct conn_sock_s {
 GPtrArray *conn_socks = NULL;
 
 static int oom_event_fd = -1;
+/* The memory.events file of the cgroup on the unified hierarchy, and the
+ * count of processes killed by the OOM killer it held last. */
+static char *memory_events_path = NULL;
+static int64_t oom_kill_count = 0;
 static int attach_socket_fd = -1;
 static int console_socket_fd = -1;
 static int terminal_ctrl_fd = -1;
@@ -664,8 +1322,13 @@ static bool read_stdio(int fd, stdpipe_t pipe, gboolean *eof)
 		nwarnf("stdio_input read failed %s", strerror(errno));
 		return false;
 	} else {
-		if (write_k8s_log(log_fd, pipe, buf, num_read) < 0) {
-			nwarn("write_k8s_log failed");
+		if (log_rate_limited()) {
+			char log_buf[STDIO_BUF_SIZE];
+			ssize_t log_len = rate_limit_log(pipe, buf, num_read, log_buf);
+
+			if (log_len > 0 && write_log(pipe, log_buf, log_len) < 0)
+				return G_SOURCE_CONTINUE;
+		} else if (write_log(pipe, buf, num_read) < 0) {
 			return G_SOURCE_CONTINUE;
 		}
 
@@ -730,6 +1393,8 @@ static void check_child_processes(GHashTable *pid_to_handler)
 			return;
 
 		/* If we got here, pid > 0, so we have a valid pid to check.  */
+		if (pid == log_compress_pid)
+			log_compress_pid = 0;
 		cb = g_hash_table_lookup(pid_to_handler, &pid);
 		if (cb)
 			cb(pid, status, 0);
@@ -804,6 +1469,83 @@ static gboolean timeout_cb(G_GNUC_UNUSED gpointer user_data)
 	return G_SOURCE_REMOVE;
 }
 
+static void write_oom_file(void)
+{
+	ninfo("OOM received");
+	int fd = open("oom", O_CREAT | O_CLOEXEC, 0666);
+	if (fd < 0) {
+		nwarn("Failed to write oom file");
+		return;
+	}
+	close(fd);
+}
+
+/*
+ * Returns the oom_kill count of a memory.events file, or -1 if it can't be
+ * read or the kernel doesn't count OOM kills (before Linux 4.13).
+ */
+static int64_t read_oom_kill_count(const char *path)
+{
+	_cleanup_fclose_ FILE *fp = fopen(path, "re");
+	if (fp == NULL)
+		return -1;
+
+	_cleanup_free_ char *line = NULL;
+	size_t len = 0;
+	unsigned long long count;
+	while (getline(&line, &len, fp) != -1) {
+		if (sscanf(line, "oom_kill %llu", &count) == 1)
+			return count;
+	}
+	return -1;
+}
+
+/*
+ * Writes the oom file if processes of the cgroup got killed by the OOM killer
+ * since the last check, on the unified hierarchy.
+ */
+static void check_oom_kill_count(void)
+{
+	if (memory_events_path == NULL)
+		return;
+
+	int64_t count = read_oom_kill_count(memory_events_path);
+	if (count > oom_kill_count) {
+		oom_kill_count = count;
+		write_oom_file();
+	}
+}
+
+static gboolean oom_cgroup_v2_cb(int fd, GIOCondition condition, G_GNUC_UNUSED gpointer user_data)
+{
+	char events[sizeof(struct inotify_event) + NAME_MAX + 1] __attribute__((aligned(__alignof__(struct inotify_event))));
+	bool removed = FALSE;
+	ssize_t num_read;
+
+	if ((condition & G_IO_IN) != 0) {
+		while ((num_read = read(fd, events, sizeof(events))) > 0) {
+			char *p = events;
+			while (p < events + num_read) {
+				struct inotify_event *event = (struct inotify_event *)p;
+				/* The cgroup got removed. */
+				if (event->mask & IN_IGNORED)
+					removed = TRUE;
+				p += sizeof(struct inotify_event) + event->len;
+			}
+		}
+		if (num_read < 0 && errno != EAGAIN && errno != EINTR)
+			nwarn("Failed to read inotify events of memory.events");
+		check_oom_kill_count();
+		if (!removed)
+			return G_SOURCE_CONTINUE;
+	}
+
+	/* End of input */
+	close(fd);
+	oom_event_fd = -1;
+	return G_SOURCE_REMOVE;
+}
+
 static gboolean oom_cb(int fd, GIOCondition condition, G_GNUC_UNUSED gpointer user_data)
 {
 	uint64_t oom_event;
@@ -819,10 +1561,7 @@ static gboolean oom_cb(int fd, GIOCondition condition, G_GNUC_UNUSED gpointer us
 		if (num_read > 0) {
 			if (num_read != sizeof(uint64_t))
 				nwarn("Failed to read full oom event from eventfd");
-			ninfo("OOM received");
-			if (open("oom", O_CREAT, 0666) < 0) {
-				nwarn("Failed to write oom file");
-			}
+			write_oom_file();
 			return G_SOURCE_CONTINUE;
 		}
 	}
@@ -1191,6 +1930,36 @@ static void setup_terminal_control_fifo()
 	ninfof("terminal_ctrl_fd: %d", terminal_ctrl_fd);
 }
 
+/*
+ * The unified hierarchy has no OOM notification: memory.events, which counts
+ * the OOM kills, gets watched for changes instead.
+ */
+static void setup_oom_handling_cgroup_v2(const char *memory_cgroup_path)
+{
+	memory_events_path = g_build_filename(memory_cgroup_path, "memory.events", NULL);
+
+	/* Only the OOM kills from now on are the container's. */
+	oom_kill_count = read_oom_kill_count(memory_events_path);
+	if (oom_kill_count < 0) {
+		nwarnf("Failed to read the OOM kill count of %s", memory_events_path);
+		g_free(memory_events_path);
+		memory_events_path = NULL;
+		return;
+	}
+
+	if ((oom_event_fd = inotify_init1(IN_NONBLOCK | IN_CLOEXEC)) == -1)
+		pexit("Failed to create inotify fd");
+
+	if (inotify_add_watch(oom_event_fd, memory_events_path, IN_MODIFY) == -1) {
+		nwarnf("Failed to watch %s", memory_events_path);
+		close(oom_event_fd);
+		oom_event_fd = -1;
+		return;
+	}
+
+	g_unix_fd_add(oom_event_fd, G_IO_IN, oom_cgroup_v2_cb, NULL);
+}
+
 static void setup_oom_handling(int container_pid)
 {
 	/* Setup OOM notification for container process */
@@ -1201,6 +1970,11 @@ static void setup_oom_handling(int container_pid)
 		nexit("Failed to get memory cgroup path");
 	}
 
+	if (is_cgroup_v2()) {
+		setup_oom_handling_cgroup_v2(memory_cgroup_path);
+		return;
+	}
+
 	_cleanup_free_ char *memory_cgroup_file_path = g_build_filename(memory_cgroup_path, "cgroup.event_control", NULL);
 
 	if ((cfd = open(memory_cgroup_file_path, O_WRONLY | O_CLOEXEC)) == -1) {
@@ -1271,6 +2045,7 @@ int main(int argc, char *argv[])
 	_cleanup_close_ int dev_null_r = -1;
 	_cleanup_close_ int dev_null_w = -1;
 	int fds[2];
+	struct stat log_st;
 
 	main_loop = g_main_loop_new(NULL, FALSE);
 
@@ -1327,8 +2102,24 @@ int main(int argc, char *argv[])
 		opt_container_pid_file = default_pid_file;
 	}
 
-	if (opt_log_path == NULL)
+	if (opt_log_drivers != NULL) {
+		log_to_file = FALSE;
+		for (gchar **driver = opt_log_drivers; *driver != NULL; driver++) {
+			if (strcmp(*driver, "k8s-file") == 0)
+				log_to_file = TRUE;
+			else if (strcmp(*driver, "journald") == 0)
+				log_to_journald = TRUE;
+			else if (strcmp(*driver, "syslog") == 0)
+				log_to_syslog = TRUE;
+			else
+				nexitf("Unsupported log driver %s", *driver);
+		}
+	}
+
+	if (log_to_file && opt_log_path == NULL)
 		nexit("Log file path not provided. Use --log-path");
+	if (log_to_syslog && opt_log_syslog_address == NULL)
+		nexit("Syslog address not provided. Use --log-syslog-address");
 
 	start_pipe_fd = get_pipe_fd_from_env("_OCI_STARTPIPE");
 	if (start_pipe_fd >= 0) {
@@ -1377,9 +2168,18 @@ int main(int argc, char *argv[])
 	sync_pipe_fd = get_pipe_fd_from_env("_OCI_SYNCPIPE");
 
 	/* Open the log path file. */
-	log_fd = open(opt_log_path, O_WRONLY | O_APPEND | O_CREAT | O_CLOEXEC, 0600);
-	if (log_fd < 0)
-		pexit("Failed to open log file");
+	if (log_to_file) {
+		log_fd = open(opt_log_path, O_WRONLY | O_APPEND | O_CREAT | O_CLOEXEC, 0600);
+		if (log_fd < 0)
+			pexit("Failed to open log file");
+		if (fstat(log_fd, &log_st) == 0)
+			log_size = log_st.st_size;
+		log_opened_at = g_get_monotonic_time();
+	}
+	if (log_to_journald)
+		init_journald_log();
+	if (log_to_syslog)
+		init_syslog_log();
 
 	/*
 	 * Set self as subreaper so we can wait for container process
@@ -1430,6 +2230,11 @@ int main(int argc, char *argv[])
 	runtime_argv = g_ptr_array_new();
 	add_argv(runtime_argv, opt_runtime_path, NULL);
 
+	/* Global runtime options have to come before the command. */
+	if (opt_runtime_args)
+		for (int i = 0; opt_runtime_args[i]; i++)
+			add_argv(runtime_argv, opt_runtime_args[i], NULL);
+
 	/* Generate the cmdline. */
 	if (!opt_exec && opt_systemd_cgroup)
 		add_argv(runtime_argv, "--systemd-cgroup", NULL);
@@ -1654,6 +2459,14 @@ int main(int argc, char *argv[])
 		g_timeout_add_seconds(opt_timeout, timeout_cb, NULL);
 	}
 
+	if (log_rate_limited()) {
+		/* The allowances start full. */
+		refill_log_rate();
+		log_rate_bytes = (double)opt_log_rate_limit_bytes * opt_log_rate_limit_burst;
+		log_rate_lines = (double)opt_log_rate_limit_lines * opt_log_rate_limit_burst;
+		g_timeout_add_seconds(1, log_dropped_cb, NULL);
+	}
+
 	check_child_processes(pid_to_handler);
 
 	g_main_loop_run(main_loop);
@@ -1669,6 +2482,11 @@ int main(int argc, char *argv[])
 		while (read_stdio(masterfd_stderr, STDERR_PIPE, NULL))
 			;
 	}
+	mark_log_dropped();
+
+	/* The container may have been OOM killed since the last events got
+	 * handled. */
+	check_oom_kill_count();
 
 	int exit_status = -1;
 	const char *exit_message = NULL;
@@ -0,0 +1,346 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/containers/storage/pkg/archive"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// checkpointDirectory holds the CRIU images in the checkpoint archive.
+	checkpointDirectory = "checkpoint"
+	// checkpointSpecFile holds the container spec in the checkpoint archive.
+	checkpointSpecFile = "config.json"
+	// checkpointStateFile holds the container state in the checkpoint
+	// archive.
+	checkpointStateFile = "state.dump"
+	// checkpointRootfsDiffFile holds the changes made to the container
+	// root filesystem in the checkpoint archive.
+	checkpointRootfsDiffFile = "rootfs-diff.tar"
+	// restoreDirectory is where checkpoint archives get extracted in the
+	// container bundle.
+	restoreDirectory = "restore"
+	// checkpointArchivesDirectory is where the checkpoint archives of a
+	// container are kept in its storage directory.
+	checkpointArchivesDirectory = "checkpoints"
+)
+
+// ContainerCheckpointOptions are the options of a container checkpoint.
+type ContainerCheckpointOptions struct {
+	// Archive is the name of the checkpoint archive to write, in the
+	// checkpoints directory of the container. If empty, a temporary
+	// archive is written there, for the caller to remove.
+	Archive string
+	// LeaveRunning keeps the container running after the checkpoint.
+	LeaveRunning bool
+	// TCPEstablished checkpoints the established TCP connections.
+	TCPEstablished bool
+}
+
+// CheckpointArchivePath returns the path of the named checkpoint archive of
+// the container, in the checkpoints directory of its storage directory. The
+// name must be relative and not refer to a parent directory, for the archive
+// to stay in that directory.
+func (c *ContainerServer) CheckpointArchivePath(ctr *oci.Container, name string) (string, error) {
+	if err := validateCheckpointArchiveName(name); err != nil {
+		return "", err
+	}
+	dir, err := c.checkpointArchivesDir(ctr)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func validateCheckpointArchiveName(name string) error {
+	if filepath.IsAbs(name) {
+		return errors.Errorf("invalid checkpoint archive name %q: absolute path", name)
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return errors.Errorf("invalid checkpoint archive name %q: parent directory", name)
+		}
+	}
+	if filepath.Clean(name) == "." {
+		return errors.Errorf("invalid checkpoint archive name %q", name)
+	}
+	return nil
+}
+
+func (c *ContainerServer) checkpointArchivesDir(ctr *oci.Container) (string, error) {
+	dir, err := c.store.ContainerDirectory(ctr.ID())
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find storage directory of container %s", ctr.ID())
+	}
+	return filepath.Join(dir, checkpointArchivesDirectory), nil
+}
+
+// ContainerCheckpoint checkpoints a running container with CRIU, writes the
+// CRIU images, the changes made to the container root filesystem, and the
+// container spec and state to a tar archive, and returns the path of the
+// archive.
+func (c *ContainerServer) ContainerCheckpoint(ctx context.Context, container string, opts *ContainerCheckpointOptions) (string, error) {
+	ctr, err := c.LookupContainer(container)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find container %s", container)
+	}
+	dir, err := c.checkpointArchivesDir(ctr)
+	if err != nil {
+		return "", err
+	}
+	var target string
+	if opts.Archive != "" {
+		if target, err = c.CheckpointArchivePath(ctr, opts.Archive); err != nil {
+			return "", err
+		}
+	}
+
+	cStatus := c.runtime.ContainerStatus(ctr)
+	if cStatus.Status != oci.ContainerStateRunning {
+		return "", errors.Errorf("cannot checkpoint container %s in state %s", ctr.ID(), cStatus.Status)
+	}
+
+	imagePath := filepath.Join(ctr.BundlePath(), checkpointDirectory)
+	if err := os.RemoveAll(imagePath); err != nil {
+		return "", errors.Wrapf(err, "failed to clean up checkpoint directory of container %s", ctr.ID())
+	}
+	defer os.RemoveAll(imagePath)
+
+	if err := c.runtime.CheckpointContainer(ctr, &oci.CheckpointOptions{
+		ImagePath:      imagePath,
+		WorkPath:       ctr.BundlePath(),
+		LeaveRunning:   opts.LeaveRunning,
+		TCPEstablished: opts.TCPEstablished,
+	}); err != nil {
+		return "", err
+	}
+	if !opts.LeaveRunning {
+		if err := c.runtime.UpdateStatus(ctr); err != nil {
+			logrus.Warnf("failed to update status of container %s: %v", ctr.ID(), err)
+		}
+		c.ContainerStateToDisk(ctr)
+	}
+
+	archiveDir := dir
+	if target != "" {
+		archiveDir = filepath.Dir(target)
+	}
+	if err := os.MkdirAll(archiveDir, 0700); err != nil {
+		return "", errors.Wrapf(err, "failed to create checkpoints directory of container %s", ctr.ID())
+	}
+	var output *os.File
+	if target == "" {
+		output, err = ioutil.TempFile(dir, ".checkpoint-")
+	} else {
+		output, err = os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create checkpoint archive of container %s", ctr.ID())
+	}
+	err = c.exportCheckpoint(ctr, output)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output.Name())
+		return "", errors.Wrapf(err, "failed to write checkpoint archive of container %s", ctr.ID())
+	}
+	return output.Name(), nil
+}
+
+// exportCheckpoint writes the checkpoint archive of the container from its
+// bundle, where the CRIU images already are.
+func (c *ContainerServer) exportCheckpoint(ctr *oci.Container, output io.Writer) error {
+	diffFile := filepath.Join(ctr.BundlePath(), checkpointRootfsDiffFile)
+	if err := c.exportRootfsDiff(ctr, diffFile); err != nil {
+		return err
+	}
+	defer os.Remove(diffFile)
+
+	return writeCheckpointArchive(ctr, output)
+}
+
+// writeCheckpointArchive writes the CRIU images, the spec and the root
+// filesystem changes found in the bundle of the container, along with its
+// state, to the checkpoint archive.
+func writeCheckpointArchive(ctr *oci.Container, output io.Writer) error {
+	bundlePath := ctr.BundlePath()
+
+	stateFile := filepath.Join(bundlePath, checkpointStateFile)
+	state, err := json.Marshal(ctr.State())
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(stateFile, state, 0600); err != nil {
+		return err
+	}
+	defer os.Remove(stateFile)
+
+	input, err := archive.TarWithOptions(bundlePath, &archive.TarOptions{
+		Compression: archive.Uncompressed,
+		IncludeFiles: []string{
+			checkpointDirectory,
+			checkpointSpecFile,
+			checkpointStateFile,
+			checkpointRootfsDiffFile,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	_, err = io.Copy(output, input)
+	return err
+}
+
+// exportRootfsDiff writes the changes made to the root filesystem of the
+// container, compared to its image, as a tar archive.
+func (c *ContainerServer) exportRootfsDiff(ctr *oci.Container, target string) error {
+	storageCtr, err := c.store.Container(ctr.ID())
+	if err != nil {
+		return err
+	}
+	diff, err := c.store.Diff("", storageCtr.LayerID, nil)
+	if err != nil {
+		return err
+	}
+	defer diff.Close()
+
+	output, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	_, err = io.Copy(output, diff)
+	return err
+}
+
+// ContainerRestore restores a stopped container from a checkpoint archive
+// written by ContainerCheckpoint. The container must have been created from
+// the same image and configuration as the checkpointed one.
+func (c *ContainerServer) ContainerRestore(ctx context.Context, container string, archive io.Reader) (string, error) {
+	ctr, err := c.LookupContainer(container)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find container %s", container)
+	}
+
+	cStatus := c.runtime.ContainerStatus(ctr)
+	switch cStatus.Status {
+	case oci.ContainerStateRunning, oci.ContainerStatePaused:
+		return "", errors.Errorf("cannot restore container %s in state %s", ctr.ID(), cStatus.Status)
+	}
+
+	restorePath := filepath.Join(ctr.BundlePath(), restoreDirectory)
+	if err := os.RemoveAll(restorePath); err != nil {
+		return "", errors.Wrapf(err, "failed to clean up restore directory of container %s", ctr.ID())
+	}
+	defer os.RemoveAll(restorePath)
+	if err := importCheckpoint(archive, restorePath); err != nil {
+		return "", errors.Wrapf(err, "failed to extract checkpoint archive of container %s", ctr.ID())
+	}
+
+	if err := checkCheckpointSpec(ctr, filepath.Join(restorePath, checkpointSpecFile)); err != nil {
+		return "", err
+	}
+	state, err := readCheckpointState(filepath.Join(restorePath, checkpointStateFile))
+	if err != nil {
+		return "", err
+	}
+	if err := c.importRootfsDiff(ctr, filepath.Join(restorePath, checkpointRootfsDiffFile)); err != nil {
+		return "", errors.Wrapf(err, "failed to apply root filesystem changes to container %s", ctr.ID())
+	}
+
+	// The runtime can't restore a container it still knows about.
+	if err := c.runtime.DeleteContainer(ctr); err != nil {
+		logrus.Debugf("failed to delete container %s before restoring it: %v", ctr.ID(), err)
+	}
+	if err := os.Remove(filepath.Join(c.Config().RuntimeConfig.ContainerExitsDir, ctr.ID())); err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to remove container exit file %s", ctr.ID())
+	}
+
+	var cgroupParent string
+	if sb := c.GetSandbox(ctr.Sandbox()); sb != nil {
+		cgroupParent = sb.CgroupParent()
+	}
+	if err := c.runtime.RestoreContainer(ctr, cgroupParent, filepath.Join(restorePath, checkpointDirectory)); err != nil {
+		return "", errors.Wrapf(err, "failed to restore container %s", ctr.ID())
+	}
+	ctr.RestoreState(state)
+	c.ContainerStateToDisk(ctr)
+
+	return ctr.ID(), nil
+}
+
+func importCheckpoint(input io.Reader, restorePath string) error {
+	if err := os.MkdirAll(restorePath, 0700); err != nil {
+		return err
+	}
+	if err := archive.Untar(input, restorePath, nil); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(restorePath, checkpointDirectory)); err != nil {
+		return errors.Wrapf(err, "missing CRIU images")
+	}
+	return nil
+}
+
+// checkCheckpointSpec makes sure the checkpointed container was running the
+// same process as the container to restore.
+func checkCheckpointSpec(ctr *oci.Container, specFile string) error {
+	content, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read checkpointed spec")
+	}
+	var spec rspec.Spec
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return errors.Wrapf(err, "failed to decode checkpointed spec")
+	}
+
+	ctrSpec := ctr.Spec()
+	if spec.Process == nil || ctrSpec.Process == nil || !reflect.DeepEqual(spec.Process.Args, ctrSpec.Process.Args) {
+		return errors.Errorf("checkpoint doesn't match the process of container %s", ctr.ID())
+	}
+	return nil
+}
+
+// readCheckpointState reads the state of the checkpointed container.
+func readCheckpointState(stateFile string) (*oci.ContainerState, error) {
+	content, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read checkpointed state")
+	}
+	state := &oci.ContainerState{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode checkpointed state")
+	}
+	return state, nil
+}
+
+// importRootfsDiff applies the root filesystem changes found in the
+// checkpoint archive to the container.
+func (c *ContainerServer) importRootfsDiff(ctr *oci.Container, diffFile string) error {
+	diff, err := os.Open(diffFile)
+	if err != nil {
+		return err
+	}
+	defer diff.Close()
+
+	storageCtr, err := c.store.Container(ctr.ID())
+	if err != nil {
+		return err
+	}
+	_, err = c.store.ApplyDiff(storageCtr.LayerID, diff)
+	return err
+}
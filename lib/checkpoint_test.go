@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestValidateCheckpointArchiveName(t *testing.T) {
+	for _, name := range []string{"checkpoint.tar", "daily/checkpoint.tar", "./checkpoint.tar", "a..tar"} {
+		if err := validateCheckpointArchiveName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "/tmp/checkpoint.tar", "../checkpoint.tar", "daily/../../checkpoint.tar", "daily/.."} {
+		if err := validateCheckpointArchiveName(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestCheckpointArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bundlePath := filepath.Join(dir, "bundle")
+	if err := os.MkdirAll(filepath.Join(bundlePath, checkpointDirectory), 0700); err != nil {
+		t.Fatal(err)
+	}
+	spec := &rspec.Spec{Process: &rspec.Process{Args: []string{"sleep", "600"}}}
+	content, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{
+		filepath.Join(checkpointDirectory, "pages-1.img"): []byte("pages"),
+		checkpointSpecFile:       content,
+		checkpointRootfsDiffFile: []byte("diff"),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(bundlePath, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	created := time.Now().Add(-time.Hour).UTC()
+	ctr, err := oci.NewContainer("checkpointed", "checkpointed", bundlePath, "", "", nil, nil, nil, "", "", "", nil, "sandbox", false, false, false, false, false, "", bundlePath, created, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctr.SetSpec(spec)
+	state := ctr.State()
+	state.Status = oci.ContainerStateRunning
+	state.Started = created.Add(time.Minute)
+	ctr.AddOOMKills(2, created.Add(2*time.Minute))
+
+	var archive bytes.Buffer
+	if err := writeCheckpointArchive(ctr, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(bundlePath, checkpointStateFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the state file to be removed from the bundle: %v", err)
+	}
+
+	restored, err := oci.NewContainer("restored", "restored", filepath.Join(dir, "restored"), "", "", nil, nil, nil, "", "", "", nil, "sandbox", false, false, false, false, false, "", dir, time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.SetSpec(spec)
+	restorePath := filepath.Join(dir, restoreDirectory)
+	if err := importCheckpoint(&archive, restorePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCheckpointSpec(restored, filepath.Join(restorePath, checkpointSpecFile)); err != nil {
+		t.Error(err)
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(restorePath, checkpointDirectory, "pages-1.img")); err != nil || string(content) != "pages" {
+		t.Errorf("expected the CRIU images to be extracted, got %q: %v", content, err)
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(restorePath, checkpointRootfsDiffFile)); err != nil || string(content) != "diff" {
+		t.Errorf("expected the root filesystem changes to be extracted, got %q: %v", content, err)
+	}
+
+	checkpointed, err := readCheckpointState(filepath.Join(restorePath, checkpointStateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.RestoreState(checkpointed)
+	restoredState := restored.State()
+	if !restoredState.Created.Equal(created) || !restoredState.Started.Equal(created.Add(time.Minute)) {
+		t.Errorf("expected the container to be created at %v and started at %v, got %v and %v",
+			created, created.Add(time.Minute), restoredState.Created, restoredState.Started)
+	}
+	if restoredState.OOMKillCount != 2 || !restoredState.LastOOMKill.Equal(created.Add(2*time.Minute)) {
+		t.Errorf("expected the OOM kills to be restored, got %d at %v", restoredState.OOMKillCount, restoredState.LastOOMKill)
+	}
+	if restoredState.Status != "" {
+		t.Errorf("expected the status of the restored container to be kept, got %q", restoredState.Status)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/containers/image/types"
 	"github.com/containers/storage"
 	"github.com/kubernetes-incubator/cri-o/oci"
+	pkgstorage "github.com/kubernetes-incubator/cri-o/pkg/storage"
 	"github.com/projectatomic/libpod/pkg/hooks"
 )
 
@@ -50,6 +51,18 @@ const (
 	// DefaultLogSizeMax is the default value for the maximum log size
 	// allowed for a container. Negative values mean that no limit is imposed.
 	DefaultLogSizeMax = -1
+
+	// DefaultLogRateLimitBurst is the default number of seconds of output
+	// at the log rate limits a container may write at once.
+	DefaultLogRateLimitBurst = 1
+
+	// DefaultImageGCLowThreshold is the default percentage of the image
+	// filesystem usage the image garbage collection frees space down to.
+	DefaultImageGCLowThreshold = 80
+
+	// DefaultImageGCMinAge is the default minimum age of the images the
+	// image garbage collection removes.
+	DefaultImageGCMinAge = "2m"
 )
 
 // DefaultCapabilities for the capabilities option in the crio.conf file
@@ -128,6 +141,13 @@ type RuntimeConfig struct {
 	// container runtime for all containers.
 	DefaultWorkloadTrust string `toml:"default_workload_trust"`
 
+	// Runtimes defines a list of named OCI compatible runtimes. A pod picks
+	// one of them through the io.kubernetes.cri-o.RuntimeHandler annotation,
+	// and all of its containers then run with that runtime. Pods which don't
+	// pick a runtime handler use Runtime or RuntimeUntrustedWorkload,
+	// depending on the level of trust of the workload.
+	Runtimes map[string]oci.RuntimeHandler `toml:"runtimes"`
+
 	// NoPivot instructs the runtime to not use `pivot_root`, but instead use `MS_MOVE`
 	NoPivot bool `toml:"no_pivot"`
 
@@ -144,10 +164,26 @@ type RuntimeConfig struct {
 	// default for the runtime.
 	SeccompProfile string `toml:"seccomp_profile"`
 
+	// SeccompProfileRoot is the directory localhost/<path> seccomp profiles
+	// are looked up in. When set, profiles outside of it are refused.
+	SeccompProfileRoot string `toml:"seccomp_profile_root"`
+
+	// SeccompAuditDir is the directory the profiles generated by the seccomp
+	// audit of containers are written to.
+	SeccompAuditDir string `toml:"seccomp_audit_dir"`
+
+	// SeccompAuditLog is the kernel log device or audit daemon log the
+	// syscalls logged by the seccomp audit are read from.
+	SeccompAuditLog string `toml:"seccomp_audit_log"`
+
 	// ApparmorProfile is the apparmor profile name which is used as the
 	// default for the runtime.
 	ApparmorProfile string `toml:"apparmor_profile"`
 
+	// ApparmorProfileDir is a directory the apparmor profiles of which are
+	// loaded at startup, and whenever its files change.
+	ApparmorProfileDir string `toml:"apparmor_profile_dir"`
+
 	// CgroupManager is the manager implementation name which is used to
 	// handle cgroups for containers.
 	CgroupManager string `toml:"cgroup_manager"`
@@ -173,6 +209,44 @@ type RuntimeConfig struct {
 	// Negative values indicate that the log file won't be truncated.
 	LogSizeMax int64 `toml:"log_size_max"`
 
+	// LogRotateFiles is the number of gzip compressed generations of the
+	// log files of the containers conmon keeps once it rotated them.
+	// Conmon doesn't rotate the log files if it's 0.
+	LogRotateFiles int `toml:"log_rotate_files"`
+
+	// LogRotateSize is the size in bytes the log files of the containers
+	// get rotated at. They don't get rotated by size if it's 0.
+	LogRotateSize int64 `toml:"log_rotate_size"`
+
+	// LogRotateAge is how long the log files of the containers get written
+	// to before being rotated. They don't get rotated by age if it's empty.
+	LogRotateAge string `toml:"log_rotate_age"`
+
+	// LogDrivers are the log drivers conmon writes the output of the
+	// containers with: k8s-file writes it to their log file, journald to
+	// the systemd journal, and syslog to the remote syslog server at
+	// LogSyslogAddress.
+	LogDrivers []string `toml:"log_drivers"`
+
+	// LogSyslogAddress is the address of the remote syslog server the
+	// syslog log driver sends the output of the containers to, as
+	// udp://host:port or tcp://host:port.
+	LogSyslogAddress string `toml:"log_syslog_address"`
+
+	// LogRateLimitBytes is the number of bytes of output per second conmon
+	// writes to the log of each container, dropping the output beyond. The
+	// bytes written aren't limited if it's 0.
+	LogRateLimitBytes int64 `toml:"log_rate_limit_bytes"`
+
+	// LogRateLimitLines is the number of lines of output per second conmon
+	// writes to the log of each container, dropping the lines beyond. The
+	// lines written aren't limited if it's 0.
+	LogRateLimitLines int64 `toml:"log_rate_limit_lines"`
+
+	// LogRateLimitBurst is the number of seconds of output at the log rate
+	// limits a container may write at once.
+	LogRateLimitBurst int `toml:"log_rate_limit_burst"`
+
 	// ContainerExitsDir is the directory in which container exit files are
 	// written to by conmon.
 	ContainerExitsDir string `toml:"container_exits_dir"`
@@ -228,6 +302,26 @@ type ImageConfig struct {
 	ImageVolumes ImageVolumesType `toml:"image_volumes"`
 	// Registries holds a list of registries used to pull unqualified images
 	Registries []string `toml:"registries"`
+	// RegistryConfigs holds the configuration of the registries, by
+	// host[:port].
+	RegistryConfigs map[string]pkgstorage.RegistryConfig `toml:"registry_configs"`
+	// RegistryRewrites maps repository name prefixes to the locations images
+	// get pulled from instead, like "docker.io/library" to
+	// "registry.example.com/library".
+	RegistryRewrites map[string]string `toml:"registry_rewrites"`
+	// ImageGCHighThreshold is the percentage of the image filesystem usage
+	// above which unused images get garbage collected. The image garbage
+	// collection is disabled if it's zero.
+	ImageGCHighThreshold int `toml:"image_gc_high_threshold"`
+	// ImageGCLowThreshold is the percentage of the image filesystem usage
+	// the image garbage collection frees space down to.
+	ImageGCLowThreshold int `toml:"image_gc_low_threshold"`
+	// ImageGCMinAge is the minimum age of the images the image garbage
+	// collection removes, as a duration like "1h30m".
+	ImageGCMinAge string `toml:"image_gc_min_age"`
+	// PinnedImages lists the images the image garbage collection never
+	// removes, along with PauseImage.
+	PinnedImages []string `toml:"pinned_images"`
 }
 
 // NetworkConfig represents the "crio.network" TOML config table
@@ -320,6 +414,7 @@ func DefaultConfig() *Config {
 			Runtime:                  "/usr/bin/runc",
 			RuntimeUntrustedWorkload: "",
 			DefaultWorkloadTrust:     "trusted",
+			Runtimes:                 map[string]oci.RuntimeHandler{},
 
 			Conmon: conmonPath,
 			ConmonEnv: []string{
@@ -327,12 +422,16 @@ func DefaultConfig() *Config {
 			},
 			SELinux:             selinuxEnabled(),
 			SeccompProfile:      seccompProfilePath,
+			SeccompAuditDir:     seccompAuditDir,
+			SeccompAuditLog:     seccompAuditLog,
 			ApparmorProfile:     apparmorProfileName,
 			CgroupManager:       cgroupManager,
 			PidsLimit:           DefaultPidsLimit,
 			ContainerExitsDir:   containerExitsDir,
 			HooksDirPath:        hooks.DefaultDir,
 			LogSizeMax:          DefaultLogSizeMax,
+			LogDrivers:          []string{oci.LogDriverKubernetesFile},
+			LogRateLimitBurst:   DefaultLogRateLimitBurst,
 			DefaultMountsFile:   "",
 			DefaultCapabilities: DefaultCapabilities,
 		},
@@ -344,6 +443,10 @@ func DefaultConfig() *Config {
 			ImageVolumes:        ImageVolumesMkdir,
 			Registries:          registries,
 			InsecureRegistries:  insecureRegistries,
+			RegistryConfigs:     map[string]pkgstorage.RegistryConfig{},
+			RegistryRewrites:    map[string]string{},
+			ImageGCLowThreshold: DefaultImageGCLowThreshold,
+			ImageGCMinAge:       DefaultImageGCMinAge,
 		},
 		NetworkConfig: NetworkConfig{
 			NetworkDir: cniConfigDir,
@@ -8,6 +8,8 @@ import "github.com/kubernetes-incubator/cri-o/oci"
 const (
 	conmonPath         = "/usr/local/libexec/crio/conmon"
 	seccompProfilePath = "/etc/crio/seccomp.json"
+	seccompAuditDir    = "/var/lib/crio/seccomp-audit"
+	seccompAuditLog    = "/dev/kmsg"
 	cniConfigDir       = "/etc/cni/net.d/"
 	cniBinDir          = "/opt/cni/bin/"
 	lockPath           = "/run/crio.lock"
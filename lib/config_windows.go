@@ -8,6 +8,8 @@ import "github.com/kubernetes-incubator/cri-o/oci"
 const (
 	conmonPath         = "C:\\crio\\bin\\conmon"
 	seccompProfilePath = "C:\\crio\\etc\\seccomp.json"
+	seccompAuditDir    = "C:\\crio\\seccomp-audit"
+	seccompAuditLog    = ""
 	cniConfigDir       = "C:\\cni\\etc\\net.d\\"
 	cniBinDir          = "C:\\cni\\bin\\"
 	lockPath           = "C:\\crio\\run\\crio.lock"
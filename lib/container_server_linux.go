@@ -3,14 +3,18 @@
 package lib
 
 import (
+	"fmt"
 	"path/filepath"
+	"runtime"
 	"time"
 
 	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/cgroupv2"
 	"github.com/opencontainers/runc/libcontainer"
 	selinux "github.com/opencontainers/selinux/go-selinux"
 	"github.com/opencontainers/selinux/go-selinux/label"
+	"github.com/sirupsen/logrus"
 )
 
 // libcontainerStats gets the stats for the container with the given id from runc/libcontainer
@@ -57,6 +61,30 @@ func loadFactory(root string) (libcontainer.Factory, error) {
 }
 
 func (c *ContainerServer) getContainerStats(ctr *oci.Container, previousStats *ContainerStats) (*ContainerStats, error) {
+	// runc's libcontainer only reads the cgroups of the legacy hierarchy.
+	unified, err := cgroupv2.IsUnifiedMode()
+	if err != nil {
+		return nil, err
+	}
+	var stats *ContainerStats
+	if unified {
+		stats, err = c.cgroupv2Stats(ctr, previousStats)
+	} else {
+		stats, err = c.cgroupv1Stats(ctr, previousStats)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dropped, err := ctr.LogDropped(); err != nil {
+		logrus.Debugf("failed to read the log output dropped of container %s: %v", ctr.ID(), err)
+	} else {
+		stats.LogDroppedLines, stats.LogDroppedBytes = dropped.Lines, dropped.Bytes
+	}
+
+	return stats, nil
+}
+
+func (c *ContainerServer) cgroupv1Stats(ctr *oci.Container, previousStats *ContainerStats) (*ContainerStats, error) {
 	previousCPU := previousStats.CPUNano
 	previousSystem := previousStats.SystemNano
 	libcontainerStats, err := c.libcontainerStats(ctr)
@@ -68,13 +96,48 @@ func (c *ContainerServer) getContainerStats(ctr *oci.Container, previousStats *C
 	stats.Container = ctr.ID()
 	stats.CPUNano = cgroupStats.CpuStats.CpuUsage.TotalUsage
 	stats.SystemNano = time.Now().UnixNano()
-	stats.CPU = calculateCPUPercent(libcontainerStats, previousCPU, previousSystem)
+	stats.CPU = calculateCPUPercent(stats.CPUNano, len(cgroupStats.CpuStats.CpuUsage.PercpuUsage), previousCPU, previousSystem)
 	stats.MemUsage = cgroupStats.MemoryStats.Usage.Usage
 	stats.MemLimit = getMemLimit(cgroupStats.MemoryStats.Usage.Limit)
 	stats.MemPerc = float64(stats.MemUsage) / float64(stats.MemLimit)
 	stats.PIDs = cgroupStats.PidsStats.Current
 	stats.BlockInput, stats.BlockOutput = calculateBlockIO(libcontainerStats)
 	stats.NetInput, stats.NetOutput = getContainerNetIO(libcontainerStats)
+	return stats, nil
+}
 
+// cgroupv2Stats reads the stats of the container from its cgroup of the
+// unified hierarchy, found through its process whichever the cgroup manager.
+func (c *ContainerServer) cgroupv2Stats(ctr *oci.Container, previousStats *ContainerStats) (*ContainerStats, error) {
+	state := ctr.State()
+	if state.Pid <= 0 {
+		return nil, fmt.Errorf("container %s is not running", ctr.ID())
+	}
+	cgroupPath, err := cgroupv2.ProcessPath(state.Pid)
+	if err != nil {
+		return nil, err
+	}
+	cgroupStats, err := cgroupv2.ReadStats(cgroupPath)
+	if err != nil {
+		return nil, err
+	}
+	stats := new(ContainerStats)
+	stats.Container = ctr.ID()
+	stats.CPUNano = cgroupStats.CPU.UsageUsec * uint64(time.Microsecond)
+	stats.SystemNano = time.Now().UnixNano()
+	stats.CPU = calculateCPUPercent(stats.CPUNano, runtime.NumCPU(), previousStats.CPUNano, previousStats.SystemNano)
+	stats.MemUsage = cgroupStats.Memory.Usage
+	stats.MemLimit = getMemLimit(cgroupStats.Memory.Limit)
+	stats.MemPerc = float64(stats.MemUsage) / float64(stats.MemLimit)
+	stats.PIDs = cgroupStats.Pids.Current
+	stats.BlockInput, stats.BlockOutput = cgroupStats.IO.ReadBytes, cgroupStats.IO.WriteBytes
+	// The cgroups don't account for the network, which is read from the
+	// network namespace of the container instead, unless it's the one of
+	// the host.
+	if sb := c.GetSandbox(ctr.Sandbox()); sb != nil && !sb.HostNetwork() {
+		if stats.NetInput, stats.NetOutput, err = getProcessNetIO(state.Pid); err != nil {
+			return nil, err
+		}
+	}
 	return stats, nil
 }
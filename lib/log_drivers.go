@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
+)
+
+// ValidateLogDrivers checks that the log drivers are known ones, listed once,
+// and that the address of the remote syslog server is valid, and set if the
+// syslog log driver is.
+func ValidateLogDrivers(drivers []string, syslogAddress string) error {
+	if len(drivers) == 0 {
+		return fmt.Errorf("no log driver set")
+	}
+	if syslogAddress != "" {
+		if err := validateSyslogAddress(syslogAddress); err != nil {
+			return fmt.Errorf("invalid syslog address %q: %v", syslogAddress, err)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, driver := range drivers {
+		switch driver {
+		case oci.LogDriverKubernetesFile, oci.LogDriverJournald:
+		case oci.LogDriverSyslog:
+			if syslogAddress == "" {
+				return fmt.Errorf("no syslog address set for the syslog log driver")
+			}
+		default:
+			return fmt.Errorf("unsupported log driver %q", driver)
+		}
+		if seen[driver] {
+			return fmt.Errorf("log driver %q set more than once", driver)
+		}
+		seen[driver] = true
+	}
+	return nil
+}
+
+// validateSyslogAddress checks that the address of a remote syslog server is
+// udp://host:port or tcp://host:port, as conmon expects it.
+func validateSyslogAddress(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return fmt.Errorf("unsupported protocol %q", u.Scheme)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return fmt.Errorf("expected %s://host:port", u.Scheme)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("no host set")
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// ContainerLogDrivers returns the log drivers conmon writes the output of a
+// container with: the ones set by the annotation of the container, or else
+// of its pod, override the default ones.
+func ContainerLogDrivers(drivers []string, syslogAddress string, containerAnnotations, podAnnotations map[string]string) ([]string, error) {
+	value, ok := containerAnnotations[annotations.LogDrivers]
+	if !ok {
+		value, ok = podAnnotations[annotations.LogDrivers]
+	}
+	if ok {
+		drivers = nil
+		for _, driver := range strings.Split(value, ",") {
+			drivers = append(drivers, strings.TrimSpace(driver))
+		}
+	}
+	if err := ValidateLogDrivers(drivers, syslogAddress); err != nil {
+		return nil, fmt.Errorf("invalid log drivers %q: %v", strings.Join(drivers, ","), err)
+	}
+	return drivers, nil
+}
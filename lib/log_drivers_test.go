@@ -0,0 +1,54 @@
+package lib
+
+import (
+	"testing"
+)
+
+func TestValidateLogDrivers(t *testing.T) {
+	for _, tc := range []struct {
+		drivers       []string
+		syslogAddress string
+		valid         bool
+	}{
+		{[]string{"k8s-file"}, "", true},
+		{[]string{"k8s-file", "journald"}, "", true},
+		{[]string{"syslog"}, "udp://127.0.0.1:514", true},
+		{[]string{"journald", "syslog"}, "tcp://logs.example.com:6514", true},
+		{[]string{"k8s-file"}, "tcp://[::1]:514", true},
+		{[]string{}, "", false},
+		{[]string{"k8s-file", "k8s-file"}, "", false},
+		{[]string{"fluentd"}, "", false},
+		// The syslog log driver needs an address.
+		{[]string{"syslog"}, "", false},
+		{[]string{"k8s-file"}, "127.0.0.1:514", false},
+		{[]string{"k8s-file"}, "unix:///dev/log", false},
+		{[]string{"k8s-file"}, "udp://127.0.0.1", false},
+		{[]string{"k8s-file"}, "udp://:514", false},
+		{[]string{"k8s-file"}, "udp://127.0.0.1:syslog", false},
+		{[]string{"k8s-file"}, "udp://127.0.0.1:514/path", false},
+	} {
+		err := ValidateLogDrivers(tc.drivers, tc.syslogAddress)
+		if tc.valid && err != nil {
+			t.Errorf("expected log drivers %v with syslog address %q to be valid: %v", tc.drivers, tc.syslogAddress, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected log drivers %v with syslog address %q to be invalid", tc.drivers, tc.syslogAddress)
+		}
+	}
+}
+
+func TestContainerLogDrivers(t *testing.T) {
+	drivers, err := ContainerLogDrivers([]string{"k8s-file"}, "udp://127.0.0.1:514", map[string]string{
+		"io.kubernetes.cri-o.LogDrivers": "k8s-file, syslog",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drivers) != 2 || drivers[1] != "syslog" {
+		t.Errorf("expected the drivers of the annotation, got %v", drivers)
+	}
+	if _, err := ContainerLogDrivers([]string{"k8s-file"}, "", nil, map[string]string{
+		"io.kubernetes.cri-o.LogDrivers": "syslog",
+	}); err == nil {
+		t.Error("expected the syslog log driver to need an address")
+	}
+}
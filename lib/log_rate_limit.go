@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
+)
+
+// LogRateLimit returns how conmon limits the rate of the output of the
+// containers written to their log, unless their annotations lower it.
+func (c *RuntimeConfig) LogRateLimit() (oci.LogRateLimit, error) {
+	limit := oci.LogRateLimit{
+		Bytes: c.LogRateLimitBytes,
+		Lines: c.LogRateLimitLines,
+		Burst: c.LogRateLimitBurst,
+	}
+	if limit.Bytes < 0 {
+		return limit, fmt.Errorf("log rate limit bytes should not be negative")
+	}
+	if limit.Lines < 0 {
+		return limit, fmt.Errorf("log rate limit lines should not be negative")
+	}
+	if limit.Burst < 1 {
+		return limit, fmt.Errorf("log rate limit burst should be at least 1")
+	}
+	return limit, nil
+}
+
+// ContainerLogRateLimit returns how conmon limits the rate of the output of
+// a container written to its log: the annotations of the container, or else
+// of its pod, may only lower the default limits, so that a container can't
+// write more than the node allows.
+func ContainerLogRateLimit(limit oci.LogRateLimit, containerAnnotations, podAnnotations map[string]string) (oci.LogRateLimit, error) {
+	containerLimit, err := logRateLimitAnnotations(containerAnnotations)
+	if err != nil {
+		return limit, err
+	}
+	podLimit, err := logRateLimitAnnotations(podAnnotations)
+	if err != nil {
+		return limit, err
+	}
+	lower := func(limit, containerLimit, podLimit int64) int64 {
+		n := containerLimit
+		if n == 0 {
+			n = podLimit
+		}
+		if n > 0 && (limit == 0 || n < limit) {
+			return n
+		}
+		return limit
+	}
+	limit.Bytes = lower(limit.Bytes, containerLimit.Bytes, podLimit.Bytes)
+	limit.Lines = lower(limit.Lines, containerLimit.Lines, podLimit.Lines)
+	limit.Burst = int(lower(int64(limit.Burst), int64(containerLimit.Burst), int64(podLimit.Burst)))
+	return limit, nil
+}
+
+// logRateLimitAnnotations returns the log rate limit set by annotations, 0
+// for the limits they don't set.
+func logRateLimitAnnotations(annots map[string]string) (oci.LogRateLimit, error) {
+	var limit oci.LogRateLimit
+	value := func(key string) (int64, error) {
+		v, ok := annots[key]
+		if !ok {
+			return 0, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid %s annotation %q: should be a positive number", key, v)
+		}
+		return n, nil
+	}
+	var err error
+	if limit.Bytes, err = value(annotations.LogRateLimitBytes); err != nil {
+		return limit, err
+	}
+	if limit.Lines, err = value(annotations.LogRateLimitLines); err != nil {
+		return limit, err
+	}
+	burst, err := value(annotations.LogRateLimitBurst)
+	limit.Burst = int(burst)
+	return limit, err
+}
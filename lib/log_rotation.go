@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
+)
+
+// LogRotation returns how conmon rotates the log files of the containers,
+// unless their annotations say otherwise.
+func (c *RuntimeConfig) LogRotation() (oci.LogRotation, error) {
+	rotation := oci.LogRotation{
+		Files: c.LogRotateFiles,
+		Size:  c.LogRotateSize,
+	}
+	if c.LogRotateAge != "" {
+		age, err := time.ParseDuration(c.LogRotateAge)
+		if err != nil {
+			return rotation, fmt.Errorf("invalid log rotate age %q: %v", c.LogRotateAge, err)
+		}
+		rotation.Age = age
+	}
+	return rotation, validateLogRotation(rotation)
+}
+
+// ContainerLogRotation returns how conmon rotates the log file of a
+// container: the rotation settings set by the annotations of the container,
+// or else of its pod, override the default ones.
+func ContainerLogRotation(rotation oci.LogRotation, containerAnnotations, podAnnotations map[string]string) (oci.LogRotation, error) {
+	value := func(key string) (string, bool) {
+		if v, ok := containerAnnotations[key]; ok {
+			return v, true
+		}
+		v, ok := podAnnotations[key]
+		return v, ok
+	}
+	if v, ok := value(annotations.LogRotateFiles); ok {
+		files, err := strconv.Atoi(v)
+		if err != nil {
+			return rotation, fmt.Errorf("invalid %s annotation %q: %v", annotations.LogRotateFiles, v, err)
+		}
+		rotation.Files = files
+	}
+	if v, ok := value(annotations.LogRotateSize); ok {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return rotation, fmt.Errorf("invalid %s annotation %q: %v", annotations.LogRotateSize, v, err)
+		}
+		rotation.Size = size
+	}
+	if v, ok := value(annotations.LogRotateAge); ok {
+		age, err := time.ParseDuration(v)
+		if err != nil {
+			return rotation, fmt.Errorf("invalid %s annotation %q: %v", annotations.LogRotateAge, v, err)
+		}
+		rotation.Age = age
+	}
+	return rotation, validateLogRotation(rotation)
+}
+
+func validateLogRotation(rotation oci.LogRotation) error {
+	if rotation.Files < 0 {
+		return fmt.Errorf("log rotate files should not be negative")
+	}
+	if rotation.Size < 0 {
+		return fmt.Errorf("log rotate size should not be negative")
+	}
+	if rotation.Age < 0 || (rotation.Age > 0 && rotation.Age < time.Second) {
+		return fmt.Errorf("log rotate age should be 0 or at least 1s")
+	}
+	return nil
+}
@@ -1,23 +1,33 @@
 package lib
 
 import (
-	"path"
-	"strings"
+	"context"
+	"fmt"
 	"time"
 
-	"github.com/hpcloud/tail"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/logs"
 )
 
 // LogOptions contains all of the options for displaying logs in podman
 type LogOptions struct {
+	// Details prefixes each line with the time it got logged at and its
+	// stream.
 	Details   bool
 	Follow    bool
 	SinceTime time.Time
+	UntilTime time.Time
 	Tail      uint64
+	// Stream only displays the lines of a stream, stdout or stderr, if set.
+	Stream string
 }
 
-// GetLogs gets each line of a log file and, if it matches the criteria in logOptions, sends it down logChan
-func (c *ContainerServer) GetLogs(container string, logChan chan string, opts LogOptions) error {
+// GetLogs gets each line of the log file of the container, or of the
+// journal if it's not logged to a file, and, if it matches the criteria in
+// logOptions, sends it down logChan. Long lines split across partial lines
+// of the log are rejoined. Following the logs goes on until the context is
+// done.
+func (c *ContainerServer) GetLogs(ctx context.Context, container string, logChan chan string, opts LogOptions) error {
 	defer close(logChan)
 	// Get the full ID of the container
 	ctr, err := c.LookupContainer(container)
@@ -25,53 +35,35 @@ func (c *ContainerServer) GetLogs(container string, logChan chan string, opts Lo
 		return err
 	}
 
-	containerID := ctr.ID()
-	sandbox := ctr.Sandbox()
-	if sandbox == "" {
-		sandbox = containerID
+	switch logs.Stream(opts.Stream) {
+	case "", logs.Stdout, logs.Stderr:
+	default:
+		return fmt.Errorf("invalid log stream %q", opts.Stream)
 	}
-	// Read the log line by line and pass it into the pipe
-	logsFile := path.Join(c.config.LogDir, sandbox, containerID+".log")
-
-	seekInfo := &tail.SeekInfo{Offset: 0, Whence: 0}
-	if opts.Tail > 0 {
-		// seek to correct position in logs files
-		seekInfo.Offset = int64(opts.Tail)
-		seekInfo.Whence = 2
+	logOpts := &logs.Options{
+		Stream: logs.Stream(opts.Stream),
+		Since:  opts.SinceTime,
+		Until:  opts.UntilTime,
+		Tail:   opts.Tail,
+		Follow: opts.Follow,
 	}
-
-	t, err := tail.TailFile(logsFile, tail.Config{Follow: false, ReOpen: false, Location: seekInfo})
-	for line := range t.Lines {
-		if since, err := logSinceTime(opts.SinceTime, line.Text); err != nil || !since {
-			continue
+	fn := func(msg *logs.Message) error {
+		line := msg.Content
+		if opts.Details {
+			line = fmt.Sprintf("%s %s %s", msg.Timestamp.Format(time.RFC3339Nano), msg.Stream, msg.Content)
+		}
+		select {
+		case logChan <- line:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		logMessage := line.Text[secondSpaceIndex(line.Text):]
-		logChan <- logMessage
-	}
-	return err
-}
-
-func logSinceTime(sinceTime time.Time, logStr string) (bool, error) {
-	timestamp := strings.Split(logStr, " ")[0]
-	logTime, err := time.Parse("2006-01-02T15:04:05.999999999-07:00", timestamp)
-	if err != nil {
-		return false, err
-	}
-	return logTime.After(sinceTime) || logTime.Equal(sinceTime), nil
-}
-
-// secondSpaceIndex returns the index of the second space in a string
-// In a line of the logs, the first two tokens are a timestamp and stdout/stderr,
-// followed by the message itself.  This allows us to get the index of the message
-// and avoid sending the other information back to the caller of GetLogs()
-func secondSpaceIndex(line string) int {
-	index := strings.Index(line, " ")
-	if index == -1 {
-		return 0
 	}
-	index = strings.Index(line[index:], " ")
-	if index == -1 {
-		return 0
+	if !ctr.HasLogDriver(oci.LogDriverKubernetesFile) {
+		if !ctr.HasLogDriver(oci.LogDriverJournald) {
+			return fmt.Errorf("logs of container %s are only sent to a remote syslog server", ctr.ID())
+		}
+		return logs.ReadJournal(ctx, ctr.ID(), logOpts, fn)
 	}
-	return index
+	return logs.ReadLogs(ctx, ctr.LogPath(), logOpts, fn)
 }
@@ -92,6 +92,7 @@ type Sandbox struct {
 	cgroupParent   string
 	privileged     bool
 	trusted        bool
+	runtimeHandler string
 	resolvPath     string
 	hostnamePath   string
 	hostname       string
@@ -126,7 +127,7 @@ var (
 // New creates and populates a new pod sandbox
 // New sandboxes have no containers, no infra container, and no network namespaces associated with them
 // An infra container must be attached before the sandbox is added to the state
-func New(id, namespace, name, kubeName, logDir string, labels, annotations map[string]string, processLabel, mountLabel string, metadata *pb.PodSandboxMetadata, shmPath, cgroupParent string, privileged, trusted bool, resolvPath, hostname string, portMappings []*hostport.PortMapping, hostNetwork bool) (*Sandbox, error) {
+func New(id, namespace, name, kubeName, logDir string, labels, annotations map[string]string, processLabel, mountLabel string, metadata *pb.PodSandboxMetadata, shmPath, cgroupParent string, privileged, trusted bool, runtimeHandler, resolvPath, hostname string, portMappings []*hostport.PortMapping, hostNetwork bool) (*Sandbox, error) {
 	sb := new(Sandbox)
 	sb.id = id
 	sb.namespace = namespace
@@ -143,6 +144,7 @@ func New(id, namespace, name, kubeName, logDir string, labels, annotations map[s
 	sb.cgroupParent = cgroupParent
 	sb.privileged = privileged
 	sb.trusted = trusted
+	sb.runtimeHandler = runtimeHandler
 	sb.resolvPath = resolvPath
 	sb.hostname = hostname
 	sb.portMappings = portMappings
@@ -264,6 +266,12 @@ func (s *Sandbox) Trusted() bool {
 	return s.trusted
 }
 
+// RuntimeHandler returns the name of the runtime handler the sandbox and its
+// containers run with, or an empty string for the default runtime.
+func (s *Sandbox) RuntimeHandler() string {
+	return s.runtimeHandler
+}
+
 // HostNetwork returns whether the sandbox runs in the host network namespace
 func (s *Sandbox) HostNetwork() bool {
 	return s.hostNetwork
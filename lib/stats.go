@@ -18,6 +18,10 @@ type ContainerStats struct {
 	BlockInput  uint64
 	BlockOutput uint64
 	PIDs        uint64
+	// LogDroppedLines and LogDroppedBytes count the output of the container
+	// dropped over its log rate limit.
+	LogDroppedLines uint64
+	LogDroppedBytes uint64
 }
 
 // GetContainerStats gets the running stats for a given container
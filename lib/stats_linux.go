@@ -1,6 +1,11 @@
 package lib
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -17,16 +22,62 @@ func getContainerNetIO(stats *libcontainer.Stats) (received uint64, transmitted
 	return
 }
 
-func calculateCPUPercent(stats *libcontainer.Stats, previousCPU uint64, previousSystem int64) float64 {
+// getProcessNetIO returns the total number of bytes received and transmitted
+// by the interfaces of the network namespace of the process, but loopback.
+func getProcessNetIO(pid int) (received uint64, transmitted uint64, err error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return parseNetDev(f)
+}
+
+// parseNetDev sums the bytes received and transmitted by the interfaces
+// listed in the format of /proc/net/dev, but loopback.
+func parseNetDev(r io.Reader) (received uint64, transmitted uint64, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			// The header lines.
+			continue
+		}
+		if strings.TrimSpace(line[:i]) == "lo" {
+			continue
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) < 9 {
+			return 0, 0, fmt.Errorf("invalid interface statistics %q", line)
+		}
+		rx, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid interface statistics %q: %v", line, err)
+		}
+		tx, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid interface statistics %q: %v", line, err)
+		}
+		received += rx
+		transmitted += tx
+	}
+	return received, transmitted, scanner.Err()
+}
+
+// calculateCPUPercent returns the CPU usage of the container since the
+// previous stats, in percent, given its total CPU usage and the number of
+// CPUs.
+func calculateCPUPercent(cpuUsage uint64, cpus int, previousCPU uint64, previousSystem int64) float64 {
 	var (
 		cpuPercent  = 0.0
-		cpuDelta    = float64(stats.CgroupStats.CpuStats.CpuUsage.TotalUsage - previousCPU)
+		cpuDelta    = float64(cpuUsage - previousCPU)
 		systemDelta = float64(uint64(time.Now().UnixNano()) - uint64(previousSystem))
 	)
 	if systemDelta > 0.0 && cpuDelta > 0.0 {
 		// gets a ratio of container cpu usage total, multiplies it by the number of cores (4 cores running
 		// at 100% utilization should be 400% utilization), and multiplies that by 100 to get a percentage
-		cpuPercent = (cpuDelta / systemDelta) * float64(len(stats.CgroupStats.CpuStats.CpuUsage.PercpuUsage)) * 100
+		cpuPercent = (cpuDelta / systemDelta) * float64(cpus) * 100
 	}
 	return cpuPercent
 }
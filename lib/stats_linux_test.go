@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNetDev(t *testing.T) {
+	received, transmitted, err := parseNetDev(strings.NewReader(
+		"Inter-|   Receive                                                |  Transmit\n" +
+			" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+			"    lo:    4096      40    0    0    0     0          0         0     4096      40    0    0    0     0       0          0\n" +
+			"  eth0:    1500      10    0    0    0     0          0         0      700       7    0    0    0     0       0          0\n" +
+			"  eth1:     500       5    0    0    0     0          0         0      300       3    0    0    0     0       0          0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != 2000 || transmitted != 1000 {
+		t.Errorf("expected 2000 bytes received and 1000 transmitted, got %d and %d", received, transmitted)
+	}
+	if _, _, err := parseNetDev(strings.NewReader("  eth0: 1500 10\n")); err == nil {
+		t.Error("expected truncated interface statistics to be invalid")
+	}
+}
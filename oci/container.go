@@ -36,9 +36,13 @@ type Container struct {
 	stdinOnce       bool
 	privileged      bool
 	trusted         bool
+	runtimeHandler  string
 	state           *ContainerState
 	metadata        *pb.ContainerMetadata
 	opLock          sync.Locker
+	// execSessionsLock guards the exec sessions and their state file
+	execSessionsLock sync.Mutex
+	execSessions     execSessions
 	// this is the /var/run/storage/... directory, erased on reboot
 	bundlePath string
 	// this is the /var/lib/storage/... directory
@@ -53,6 +57,36 @@ type Container struct {
 
 	idMappings             *idtools.IDMappings
 	intermediateMountPoint string
+	logRotation            LogRotation
+	logRateLimit           LogRateLimit
+	logDrivers             []string
+	logSyslogAddress       string
+	podName                string
+	podNamespace           string
+}
+
+// LogRotation is how conmon rotates the log file of a container.
+type LogRotation struct {
+	// Files is the number of gzip compressed generations of the log file
+	// kept once rotated. The log file doesn't get rotated if it's 0.
+	Files int
+	// Size is the size the log file gets rotated at, if positive.
+	Size int64
+	// Age is how long the log file gets written to before being rotated,
+	// if positive.
+	Age time.Duration
+}
+
+// LogRateLimit is how conmon limits the rate of the output of a container
+// written to its log, dropping the output beyond.
+type LogRateLimit struct {
+	// Bytes is the number of bytes per second written, if positive.
+	Bytes int64
+	// Lines is the number of lines per second written, if positive.
+	Lines int64
+	// Burst is the number of seconds of output at the rates which may be
+	// written at once.
+	Burst int
 }
 
 // ContainerVolume is a bind mount for the container.
@@ -71,10 +105,14 @@ type ContainerState struct {
 	ExitCode  int32     `json:"exitCode,omitempty"`
 	OOMKilled bool      `json:"oomKilled,omitempty"`
 	Error     string    `json:"error,omitempty"`
+	// OOMKillCount is the number of processes of the container killed by
+	// the OOM killer, including the ones not causing the container to exit.
+	OOMKillCount uint64    `json:"oomKillCount,omitempty"`
+	LastOOMKill  time.Time `json:"lastOOMKill,omitempty"`
 }
 
 // NewContainer creates a container object.
-func NewContainer(id string, name string, bundlePath string, logPath string, netns string, labels map[string]string, crioAnnotations map[string]string, annotations map[string]string, image string, imageName string, imageRef string, metadata *pb.ContainerMetadata, sandbox string, terminal bool, stdin bool, stdinOnce bool, privileged bool, trusted bool, dir string, created time.Time, stopSignal string) (*Container, error) {
+func NewContainer(id string, name string, bundlePath string, logPath string, netns string, labels map[string]string, crioAnnotations map[string]string, annotations map[string]string, image string, imageName string, imageRef string, metadata *pb.ContainerMetadata, sandbox string, terminal bool, stdin bool, stdinOnce bool, privileged bool, trusted bool, runtimeHandler string, dir string, created time.Time, stopSignal string) (*Container, error) {
 	state := &ContainerState{}
 	state.Created = created
 	c := &Container{
@@ -90,6 +128,7 @@ func NewContainer(id string, name string, bundlePath string, logPath string, net
 		stdinOnce:       stdinOnce,
 		privileged:      privileged,
 		trusted:         trusted,
+		runtimeHandler:  runtimeHandler,
 		metadata:        metadata,
 		annotations:     annotations,
 		crioAnnotations: crioAnnotations,
@@ -170,6 +209,82 @@ func (c *Container) SeccompProfilePath() string {
 	return c.seccompProfilePath
 }
 
+// SetLogRotation sets how conmon rotates the log file of the container
+func (c *Container) SetLogRotation(rotation LogRotation) {
+	c.logRotation = rotation
+}
+
+// LogRotation returns how conmon rotates the log file of the container
+func (c *Container) LogRotation() LogRotation {
+	return c.logRotation
+}
+
+// SetLogRateLimit sets how conmon limits the rate of the output of the
+// container written to its log
+func (c *Container) SetLogRateLimit(limit LogRateLimit) {
+	c.logRateLimit = limit
+}
+
+// LogRateLimit returns how conmon limits the rate of the output of the
+// container written to its log
+func (c *Container) LogRateLimit() LogRateLimit {
+	return c.logRateLimit
+}
+
+// SetLogDrivers sets the log drivers conmon writes the output of the
+// container with
+func (c *Container) SetLogDrivers(drivers []string) {
+	c.logDrivers = drivers
+}
+
+// LogDrivers returns the log drivers conmon writes the output of the
+// container with
+func (c *Container) LogDrivers() []string {
+	if len(c.logDrivers) == 0 {
+		return []string{LogDriverKubernetesFile}
+	}
+	return c.logDrivers
+}
+
+// HasLogDriver returns whether conmon writes the output of the container
+// with the log driver
+func (c *Container) HasLogDriver(driver string) bool {
+	for _, d := range c.LogDrivers() {
+		if d == driver {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLogSyslogAddress sets the address of the remote syslog server conmon
+// sends the output of the container to with the syslog log driver
+func (c *Container) SetLogSyslogAddress(address string) {
+	c.logSyslogAddress = address
+}
+
+// LogSyslogAddress returns the address of the remote syslog server conmon
+// sends the output of the container to with the syslog log driver
+func (c *Container) LogSyslogAddress() string {
+	return c.logSyslogAddress
+}
+
+// SetPodName sets the name and the namespace of the pod of the container
+func (c *Container) SetPodName(name, namespace string) {
+	c.podName = name
+	c.podNamespace = namespace
+}
+
+// PodName returns the name of the pod of the container
+func (c *Container) PodName() string {
+	return c.podName
+}
+
+// PodNamespace returns the namespace of the pod of the container
+func (c *Container) PodNamespace() string {
+	return c.podNamespace
+}
+
 // BundlePath returns the bundlePath of the container.
 func (c *Container) BundlePath() string {
 	return c.bundlePath
@@ -195,6 +310,12 @@ func (c *Container) CrioAnnotations() map[string]string {
 	return c.crioAnnotations
 }
 
+// RuntimeHandler returns the name of the runtime handler the container runs
+// with, or an empty string if it uses the default runtime.
+func (c *Container) RuntimeHandler() string {
+	return c.runtimeHandler
+}
+
 // Image returns the image of the container.
 func (c *Container) Image() string {
 	return c.image
@@ -292,3 +413,24 @@ func (c *Container) IDMappings() *idtools.IDMappings {
 func (c *Container) SetState(state *ContainerState) {
 	c.state = state
 }
+
+// RestoreState applies the state of the checkpointed container the container
+// got restored from: when it got created and started, and its OOM kills. Its
+// status and process stay the ones of the restored container.
+func (c *Container) RestoreState(state *ContainerState) {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	c.state.Created = state.Created
+	c.state.Started = state.Started
+	c.state.OOMKillCount = state.OOMKillCount
+	c.state.LastOOMKill = state.LastOOMKill
+}
+
+// AddOOMKills records n more processes of the container got killed by the
+// OOM killer, the last one at the given time.
+func (c *Container) AddOOMKills(n uint64, last time.Time) {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	c.state.OOMKillCount += n
+	c.state.LastOOMKill = last
+}
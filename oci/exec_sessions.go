@@ -0,0 +1,250 @@
+package oci
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/sirupsen/logrus"
+)
+
+// execSessionsFile is the name of the state file of the exec sessions, in
+// the bundle of the container.
+const execSessionsFile = "exec-sessions.json"
+
+// execSessionsPersistDelay is how long the exec sessions of a container are
+// only kept in memory, before their state file gets written. The ones ending
+// earlier, like most probes, never get written.
+var execSessionsPersistDelay = time.Second
+
+// ExecSession is an exec running in a container. The exec sessions of a
+// container are tracked in a state file in its bundle, for the processes
+// they leave behind to be found once the daemon restarted.
+type ExecSession struct {
+	ID string `json:"id"`
+	// Pid is the PID of the runtime running the exec, and StartTime its
+	// start time in clock ticks since boot.
+	Pid       int    `json:"pid"`
+	StartTime uint64 `json:"start_time"`
+	// ProcessPid is the PID of the exec process, the child of the runtime,
+	// and ProcessStartTime its start time. They're found when the session
+	// gets written to the state file.
+	ProcessPid       int       `json:"process_pid,omitempty"`
+	ProcessStartTime uint64    `json:"process_start_time,omitempty"`
+	Command          []string  `json:"command"`
+	Tty              bool      `json:"tty"`
+	Sync             bool      `json:"sync"`
+	Started          time.Time `json:"started"`
+}
+
+// execSessions are the exec sessions of a container, guarded by its
+// execSessionsLock.
+type execSessions struct {
+	sessions map[string]*ExecSession
+	// persisted is whether the state file holds sessions.
+	persisted bool
+	// timer writes the state file once the sessions changed.
+	timer *time.Timer
+}
+
+// running returns whether the runtime process of the session still runs,
+// and isn't another process which reused its PID.
+func (s *ExecSession) running() bool {
+	return processRunning(s.Pid, s.StartTime)
+}
+
+// processRunning returns whether the process started at startTime still
+// runs, and isn't another process which reused its PID.
+func processRunning(pid int, startTime uint64) bool {
+	stat, err := readProcStat(pid)
+	return err == nil && stat.startTime == startTime && stat.state != "Z"
+}
+
+// findProcess finds the exec process of the session, once the runtime
+// started it.
+func (s *ExecSession) findProcess() {
+	if s.ProcessPid != 0 {
+		return
+	}
+	pids, err := childProcesses(s.Pid)
+	if err != nil || len(pids) == 0 {
+		return
+	}
+	stat, err := readProcStat(pids[0])
+	if err != nil {
+		return
+	}
+	s.ProcessPid, s.ProcessStartTime = pids[0], stat.startTime
+}
+
+func (c *Container) readExecSessions() ([]ExecSession, error) {
+	data, err := ioutil.ReadFile(filepath.Join(c.bundlePath, execSessionsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []ExecSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (c *Container) writeExecSessions(sessions []ExecSession) error {
+	path := filepath.Join(c.bundlePath, execSessionsFile)
+	if len(sessions) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+	return ioutils.AtomicWriteFile(path, data, 0600)
+}
+
+// trackExecSession adds the exec the runtime running as pid got started for
+// to the exec sessions of the container. Failing to track it doesn't fail the
+// exec, which then only can't be reaped after a restart. Neither can the
+// ones ending before the state file gets written.
+func (c *Container) trackExecSession(pid int, command []string, tty, sync bool) *ExecSession {
+	session := &ExecSession{
+		ID:      stringid.GenerateNonCryptoID(),
+		Pid:     pid,
+		Command: command,
+		Tty:     tty,
+		Sync:    sync,
+		Started: time.Now(),
+	}
+	stat, err := readProcStat(session.Pid)
+	if err != nil {
+		logrus.Debugf("failed to track exec session of container %s: %v", c.id, err)
+		return nil
+	}
+	session.StartTime = stat.startTime
+
+	c.execSessionsLock.Lock()
+	defer c.execSessionsLock.Unlock()
+	if c.execSessions.sessions == nil {
+		c.execSessions.sessions = make(map[string]*ExecSession)
+	}
+	c.execSessions.sessions[session.ID] = session
+	c.persistExecSessionsLater()
+	return session
+}
+
+// untrackExecSession removes the exec session, once it ended, from the exec
+// sessions of the container.
+func (c *Container) untrackExecSession(session *ExecSession) {
+	if session == nil {
+		return
+	}
+	c.execSessionsLock.Lock()
+	defer c.execSessionsLock.Unlock()
+	delete(c.execSessions.sessions, session.ID)
+	if c.execSessions.persisted {
+		c.persistExecSessionsLater()
+	} else if len(c.execSessions.sessions) == 0 && c.execSessions.timer != nil {
+		c.execSessions.timer.Stop()
+		c.execSessions.timer = nil
+	}
+}
+
+// persistExecSessionsLater writes the state file of the exec sessions once
+// execSessionsPersistDelay passed, along with any other change made to them
+// in the meantime. It must be called with the execSessionsLock held.
+func (c *Container) persistExecSessionsLater() {
+	if c.execSessions.timer != nil {
+		return
+	}
+	c.execSessions.timer = time.AfterFunc(execSessionsPersistDelay, func() {
+		c.execSessionsLock.Lock()
+		defer c.execSessionsLock.Unlock()
+		c.execSessions.timer = nil
+		c.persistExecSessions()
+	})
+}
+
+// persistExecSessions writes the state file of the exec sessions. It must be
+// called with the execSessionsLock held.
+func (c *Container) persistExecSessions() {
+	sessions := make([]ExecSession, 0, len(c.execSessions.sessions))
+	for _, session := range c.execSessions.sessions {
+		session.findProcess()
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+	if err := c.writeExecSessions(sessions); err != nil {
+		logrus.Warnf("failed to write the exec sessions of container %s: %v", c.id, err)
+		return
+	}
+	c.execSessions.persisted = len(sessions) > 0
+}
+
+// ReapExecSessions kills the processes of the exec sessions a previous
+// instance of the daemon left running in the container, as their clients
+// went away with it, and forgets about the sessions. It's meant to be
+// called on the containers restored when the daemon starts.
+func (r *Runtime) ReapExecSessions(c *Container) error {
+	c.execSessionsLock.Lock()
+	defer c.execSessionsLock.Unlock()
+	sessions, err := c.readExecSessions()
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		reapExecSession(c, &sessions[i])
+	}
+	c.execSessions.persisted = false
+	return c.writeExecSessions(nil)
+}
+
+// reapExecSession kills the exec process of the session, which may have been
+// left without its runtime, and the other processes the runtime waits for as
+// its children, and then the runtime if it doesn't exit in time.
+func reapExecSession(c *Container, session *ExecSession) {
+	if session.ProcessPid != 0 && processRunning(session.ProcessPid, session.ProcessStartTime) {
+		logrus.Infof("killing process %d of exec session %s of container %s left behind: %q", session.ProcessPid, session.ID, c.id, session.Command)
+		if err := kill(session.ProcessPid); err != nil {
+			logrus.Warnf("failed to kill exec process %d: %v", session.ProcessPid, err)
+		}
+	}
+	if !session.running() {
+		waitOrphan(session.Pid, session.StartTime)
+		if session.ProcessPid != 0 {
+			waitOrphan(session.ProcessPid, session.ProcessStartTime)
+		}
+		return
+	}
+	logrus.Infof("killing exec session %s of container %s left behind: %q", session.ID, c.id, session.Command)
+	pids, err := childProcesses(session.Pid)
+	if err != nil {
+		logrus.Warnf("failed to find the processes of exec session %s to kill: %v", session.ID, err)
+	}
+	for _, pid := range pids {
+		if err := kill(pid); err != nil {
+			logrus.Warnf("failed to kill exec process %d: %v", pid, err)
+		}
+	}
+	deadline := time.Now().Add(execSyncDrainTimeout)
+	for session.running() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if session.running() {
+		if err := kill(session.Pid); err != nil {
+			logrus.Warnf("failed to kill the runtime of exec session %s: %v", session.ID, err)
+		}
+	}
+	waitOrphan(session.Pid, session.StartTime)
+}
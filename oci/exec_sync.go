@@ -0,0 +1,159 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxExecSyncOutputSize is the number of bytes of each output stream of
+	// a synchronous exec kept for the response, the rest gets dropped.
+	maxExecSyncOutputSize = 16 * 1024 * 1024
+
+	// execSyncDrainTimeout is how long the output of a synchronous exec is
+	// read for once its process exited, or how long the runtime gets to exit
+	// once the process got killed.
+	execSyncDrainTimeout = time.Second
+)
+
+// superviseExecSync does for the runtime of a synchronous exec, which waits
+// for the command and exits with its status, what conmon does for the other
+// execs, without a process of its own: it sends the pid of the runtime and
+// then the exit status of the command, or it timing out, on the sync pipe.
+// It kills the command and the runtime once timed out.
+func superviseExecSync(cmd *exec.Cmd, timeout int64, syncPipe io.WriteCloser) {
+	defer syncPipe.Close()
+	encoder := json.NewEncoder(syncPipe)
+	if err := encoder.Encode(syncInfo{Pid: cmd.Process.Pid}); err != nil {
+		logrus.Debugf("failed to send the pid of exec sync runtime %d: %v", cmd.Process.Pid, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(time.Duration(timeout) * time.Second)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	ec := exitCodeInfo{}
+	select {
+	case err := <-done:
+		if err != nil {
+			ec.ExitCode = processExitCode(cmd.ProcessState)
+			if _, ok := err.(*exec.ExitError); !ok {
+				ec.Message = err.Error()
+			}
+		}
+	case <-timeoutCh:
+		// The runtime can't pass SIGKILL on to the command it waits for.
+		pids, err := childProcesses(cmd.Process.Pid)
+		if err != nil {
+			logrus.Warnf("failed to find the command of exec sync runtime %d: %v", cmd.Process.Pid, err)
+		}
+		for _, pid := range pids {
+			kill(pid)
+		}
+		cmd.Process.Kill()
+		<-done
+		ec = exitCodeInfo{ExitCode: -1, Message: "command timed out"}
+	}
+	if err := encoder.Encode(ec); err != nil {
+		logrus.Debugf("failed to send the exit status of exec sync runtime %d: %v", cmd.Process.Pid, err)
+	}
+}
+
+// cappedBuffer keeps what gets written to it up to its maximum size, and
+// drops the rest, for the writers not to block.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := b.max - b.buf.Len()
+	if len(p) > room {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// execSyncOutput reads the stdout and stderr of a synchronous exec from
+// pipes.
+type execSyncOutput struct {
+	stdout  cappedBuffer
+	stderr  cappedBuffer
+	readers []*os.File
+	writers []*os.File
+	reading sync.WaitGroup
+}
+
+// attach gives pipes to cmd for its stdout and stderr.
+func (o *execSyncOutput) attach(cmd *exec.Cmd) error {
+	o.stdout.max = maxExecSyncOutputSize
+	o.stderr.max = maxExecSyncOutputSize
+	for _, stream := range []*io.Writer{&cmd.Stdout, &cmd.Stderr} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			o.close()
+			return err
+		}
+		o.readers = append(o.readers, r)
+		o.writers = append(o.writers, w)
+		*stream = w
+	}
+	return nil
+}
+
+// started closes the ends of the pipes handed over to the command once it
+// got started, and starts reading its output.
+func (o *execSyncOutput) started() {
+	for _, w := range o.writers {
+		w.Close()
+	}
+	for i, buf := range []*cappedBuffer{&o.stdout, &o.stderr} {
+		o.reading.Add(1)
+		go func(r io.Reader, buf *cappedBuffer) {
+			defer o.reading.Done()
+			io.Copy(buf, r)
+		}(o.readers[i], buf)
+	}
+}
+
+// wait waits for the output to be read until all the processes holding the
+// pipes closed them, or the timeout expires.
+func (o *execSyncOutput) wait(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		o.reading.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		o.close()
+	case <-time.After(timeout):
+		// Closing the pipes stops the reads.
+		o.close()
+		<-done
+	}
+}
+
+func (o *execSyncOutput) close() {
+	for _, f := range append(o.readers, o.writers...) {
+		f.Close()
+	}
+}
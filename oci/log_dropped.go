@@ -0,0 +1,36 @@
+package oci
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// logDroppedFile is the file of the bundle conmon writes the counts of the
+// output of the container it dropped over the log rate limit to.
+const logDroppedFile = "log-dropped.json"
+
+// LogDropped counts the output of a container conmon dropped over its log
+// rate limit.
+type LogDropped struct {
+	Lines uint64 `json:"lines"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// LogDropped returns the counts of the output of the container conmon
+// dropped over its log rate limit.
+func (c *Container) LogDropped() (*LogDropped, error) {
+	dropped := &LogDropped{}
+	data, err := ioutil.ReadFile(filepath.Join(c.bundlePath, logDroppedFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dropped, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, dropped); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
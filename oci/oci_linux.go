@@ -3,18 +3,24 @@
 package oci
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/containerd/cgroups"
+	"github.com/kubernetes-incubator/cri-o/pkg/cgroupv2"
 	"github.com/kubernetes-incubator/cri-o/utils"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
-func (r *Runtime) createContainerPlatform(c *Container, cgroupParent string, pid int) error {
+func (r *runtimeOCI) createContainerPlatform(c *Container, cgroupParent string, pid int) error {
 	// Move conmon to specified cgroup
 	if r.cgroupManager == SystemdCgroupsManager {
 		logrus.Debugf("Running conmon under slice %s and unitName %s", cgroupParent, createUnitName("crio-conmon", c.id))
@@ -24,9 +30,18 @@ func (r *Runtime) createContainerPlatform(c *Container, cgroupParent string, pid
 		return nil
 	}
 
-	control, err := cgroups.New(cgroups.V1, cgroups.StaticPath(filepath.Join(cgroupParent, "/crio-conmon-"+c.id)), &rspec.LinuxResources{})
+	cgroupPath := filepath.Join(cgroupParent, "/crio-conmon-"+c.id)
+	if unified, err := cgroupv2.IsUnifiedMode(); err == nil && unified {
+		if err := cgroupv2.AddProcess(cgroupPath, pid); err != nil {
+			logrus.Warnf("Failed to add conmon to cgroupfs sandbox cgroup: %v", err)
+		}
+		return nil
+	}
+
+	control, err := cgroups.New(cgroups.V1, cgroups.StaticPath(cgroupPath), &rspec.LinuxResources{})
 	if err != nil {
 		logrus.Warnf("Failed to add conmon to cgroupfs sandbox cgroup: %v", err)
+		return nil
 	}
 
 	// Here we should defer a crio-connmon- cgroup hierarchy deletion, but it will
@@ -47,6 +62,75 @@ func sysProcAttrPlatform() *syscall.SysProcAttr {
 	}
 }
 
+// procStat is the status of a process, read from /proc/<pid>/stat.
+type procStat struct {
+	state string
+	ppid  int
+	// startTime is the time the process started at, in clock ticks since
+	// boot, telling it apart from another process reusing its PID.
+	startTime uint64
+}
+
+// readProcStat returns the status of the process.
+func readProcStat(pid int) (*procStat, error) {
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	// The command name, in parentheses, may hold spaces: the fields are
+	// counted from the one after it, the third.
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("invalid stat of process %d: %q", pid, stat)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent of process %d: %v", pid, err)
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time of process %d: %v", pid, err)
+	}
+	return &procStat{
+		state:     fields[0],
+		ppid:      ppid,
+		startTime: startTime,
+	}, nil
+}
+
+// childProcesses returns the PIDs of the children of the process.
+func childProcesses(pid int) ([]int, error) {
+	dirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var children []int
+	for _, dir := range dirs {
+		child, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := readProcStat(child)
+		if err != nil {
+			// The process exited in the meantime.
+			continue
+		}
+		if stat.ppid == pid {
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+// waitOrphan reaps the process started at startTime, which got killed or
+// exited, if the daemon inherited it being the init of its PID namespace or
+// a subreaper.
+func waitOrphan(pid int, startTime uint64) {
+	if stat, err := readProcStat(pid); err == nil && stat.startTime == startTime && stat.ppid == os.Getpid() {
+		unix.Wait4(pid, nil, 0, nil)
+	}
+}
+
 // newPipe creates a unix socket pair for communication
 func newPipe() (parent *os.File, child *os.File, err error) {
 	fds, err := unix.Socketpair(unix.AF_LOCAL, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
@@ -4,9 +4,15 @@ package oci
 
 import (
 	"fmt"
+	"io"
 	"os/exec"
 
+	"github.com/docker/docker/pkg/pools"
+	"github.com/kr/pty"
 	"golang.org/x/sys/unix"
+	"k8s.io/client-go/tools/remotecommand"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+	"k8s.io/kubernetes/pkg/util/term"
 )
 
 const (
@@ -32,3 +38,33 @@ func getExitCode(err error) int32 {
 	}
 	return -1
 }
+
+// ttyCmd runs execCmd in a new pty, calling started, if not nil, once it got
+// started.
+func ttyCmd(execCmd *exec.Cmd, stdin io.Reader, stdout io.WriteCloser, resize <-chan remotecommand.TerminalSize, started func()) error {
+	p, err := pty.Start(execCmd)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	if started != nil {
+		started()
+	}
+
+	// make sure to close the stdout stream
+	defer stdout.Close()
+
+	kubecontainer.HandleResizing(resize, func(size remotecommand.TerminalSize) {
+		term.SetSize(p.Fd(), size)
+	})
+
+	if stdin != nil {
+		go pools.Copy(p, stdin)
+	}
+
+	if stdout != nil {
+		go pools.Copy(stdout, p)
+	}
+
+	return execCmd.Wait()
+}
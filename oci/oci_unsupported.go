@@ -3,11 +3,12 @@
 package oci
 
 import (
+	"fmt"
 	"os"
 	"syscall"
 )
 
-func (r *Runtime) createContainerPlatform(c *Container, cgroupParent string, pid int) error {
+func (r *runtimeOCI) createContainerPlatform(c *Container, cgroupParent string, pid int) error {
 	return nil
 }
 
@@ -15,6 +16,23 @@ func sysProcAttrPlatform() *syscall.SysProcAttr {
 	return &syscall.SysProcAttr{}
 }
 
+type procStat struct {
+	state     string
+	ppid      int
+	startTime uint64
+}
+
+func readProcStat(pid int) (*procStat, error) {
+	return nil, fmt.Errorf("reading the status of processes is not supported")
+}
+
+func childProcesses(pid int) ([]int, error) {
+	return nil, nil
+}
+
+func waitOrphan(pid int, startTime uint64) {
+}
+
 func newPipe() (parent *os.File, child *os.File, err error) {
 	return os.Pipe()
 }
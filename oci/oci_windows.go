@@ -1,10 +1,13 @@
 package oci
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 
 	"golang.org/x/sys/windows"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
 const (
@@ -30,3 +33,7 @@ func getExitCode(err error) int32 {
 	}
 	return -1
 }
+
+func ttyCmd(execCmd *exec.Cmd, stdin io.Reader, stdout io.WriteCloser, resize <-chan remotecommand.TerminalSize, started func()) error {
+	return fmt.Errorf("unsupported")
+}
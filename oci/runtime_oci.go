@@ -0,0 +1,703 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/pkg/pools"
+	"github.com/kubernetes-incubator/cri-o/pkg/findprocess"
+	"github.com/kubernetes-incubator/cri-o/utils"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/utils/exec"
+)
+
+// runtimeOCI is the RuntimeImpl running containers with an OCI compatible
+// runtime binary (runc, crun...), monitored by conmon.
+type runtimeOCI struct {
+	*Runtime
+}
+
+func newRuntimeOCI(r *Runtime) RuntimeImpl {
+	return &runtimeOCI{Runtime: r}
+}
+
+// syncInfo is used to return data from monitor process to daemon
+type syncInfo struct {
+	Pid     int    `json:"pid"`
+	Message string `json:"message,omitempty"`
+}
+
+// exitCodeInfo is used to return the monitored process exit code to the daemon
+type exitCodeInfo struct {
+	ExitCode int32  `json:"exit_code"`
+	Message  string `json:"message,omitempty"`
+}
+
+// CreateContainer creates a container.
+func (r *runtimeOCI) CreateContainer(c *Container, cgroupParent string) error {
+	return r.createContainer(c, cgroupParent, "")
+}
+
+// createContainer creates the container through conmon, restoring it from
+// the CRIU images found in restorePath if it's set.
+func (r *runtimeOCI) createContainer(c *Container, cgroupParent string, restorePath string) (err error) {
+	var stderrBuf bytes.Buffer
+	parentPipe, childPipe, err := newPipe()
+	childStartPipe, parentStartPipe, err := newPipe()
+	if err != nil {
+		return fmt.Errorf("error creating socket pair: %v", err)
+	}
+	defer parentPipe.Close()
+	defer parentStartPipe.Close()
+
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if r.cgroupManager == SystemdCgroupsManager {
+		args = append(args, "-s")
+	}
+	if r.cgroupManager == CgroupfsCgroupsManager {
+		args = append(args, "--syslog")
+	}
+	args = append(args, "-c", c.id)
+	args = append(args, "-u", c.id)
+	args = append(args, "-r", runtimePath)
+	args = append(args, r.conmonRuntimeArgs(c)...)
+	args = append(args, "-b", c.bundlePath)
+	args = append(args, "-p", filepath.Join(c.bundlePath, "pidfile"))
+	args = append(args, "-l", c.logPath)
+	args = append(args, "--exit-dir", r.containerExitsDir)
+	args = append(args, "--socket-dir-path", ContainerAttachSocketDir)
+	if r.logSizeMax >= 0 {
+		args = append(args, "--log-size-max", fmt.Sprintf("%v", r.logSizeMax))
+	}
+	if rotation := c.logRotation; rotation.Files > 0 {
+		args = append(args, "--log-rotate-files", fmt.Sprintf("%d", rotation.Files))
+		if rotation.Size > 0 {
+			args = append(args, "--log-rotate-size", fmt.Sprintf("%d", rotation.Size))
+		}
+		if rotation.Age > 0 {
+			args = append(args, "--log-rotate-age", fmt.Sprintf("%d", int64(rotation.Age/time.Second)))
+		}
+	}
+	if limit := c.logRateLimit; limit.Bytes > 0 || limit.Lines > 0 {
+		args = append(args, "--log-rate-limit-bytes", fmt.Sprintf("%d", limit.Bytes))
+		args = append(args, "--log-rate-limit-lines", fmt.Sprintf("%d", limit.Lines))
+		args = append(args, "--log-rate-limit-burst", fmt.Sprintf("%d", limit.Burst))
+	}
+	for _, driver := range c.LogDrivers() {
+		args = append(args, "--log-driver", driver)
+	}
+	if c.HasLogDriver(LogDriverJournald) {
+		args = append(args, "--name", c.name)
+		args = append(args, "--pod-name", c.podName)
+		args = append(args, "--pod-namespace", c.podNamespace)
+	}
+	if c.HasLogDriver(LogDriverSyslog) {
+		args = append(args, "--log-syslog-address", c.logSyslogAddress)
+	}
+	if r.noPivot {
+		args = append(args, "--no-pivot")
+	}
+	if restorePath != "" {
+		args = append(args, "--restore", restorePath)
+	}
+	if c.terminal {
+		args = append(args, "-t")
+	} else if c.stdin {
+		if !c.stdinOnce {
+			args = append(args, "--leave-stdin-open")
+		}
+		args = append(args, "-i")
+	}
+	logrus.WithFields(logrus.Fields{
+		"args": args,
+	}).Debugf("running conmon: %s", r.conmonPath)
+
+	cmd := exec.Command(r.conmonPath, args...)
+	cmd.Dir = c.bundlePath
+	cmd.SysProcAttr = sysProcAttrPlatform()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if c.terminal {
+		cmd.Stderr = &stderrBuf
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, childPipe, childStartPipe)
+	// 0, 1 and 2 are stdin, stdout and stderr
+	cmd.Env = append(r.conmonEnv, fmt.Sprintf("_OCI_SYNCPIPE=%d", 3))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("_OCI_STARTPIPE=%d", 4))
+
+	err = cmd.Start()
+	if err != nil {
+		childPipe.Close()
+		return err
+	}
+
+	// We don't need childPipe on the parent side
+	childPipe.Close()
+	childStartPipe.Close()
+
+	// Platform specific container setup
+	if err := r.createContainerPlatform(c, cgroupParent, cmd.Process.Pid); err != nil {
+		logrus.Warnf("%s", err)
+	}
+
+	/* We set the cgroup, now the child can start creating children */
+	someData := []byte{0}
+	_, err = parentStartPipe.Write(someData)
+	if err != nil {
+		return err
+	}
+
+	/* Wait for initial setup and fork, and reap child */
+	err = cmd.Wait()
+	if err != nil {
+		return err
+	}
+
+	// We will delete all container resources if creation fails
+	defer func() {
+		if err != nil {
+			r.DeleteContainer(c)
+		}
+	}()
+
+	// Wait to get container pid from conmon
+	type syncStruct struct {
+		si  *syncInfo
+		err error
+	}
+	ch := make(chan syncStruct)
+	go func() {
+		var si *syncInfo
+		if err = json.NewDecoder(parentPipe).Decode(&si); err != nil {
+			ch <- syncStruct{err: err}
+			return
+		}
+		ch <- syncStruct{si: si}
+	}()
+
+	select {
+	case ss := <-ch:
+		if ss.err != nil {
+			return fmt.Errorf("error reading container (probably exited) json message: %v", ss.err)
+		}
+		logrus.Debugf("Received container pid: %d", ss.si.Pid)
+		if ss.si.Pid == -1 {
+			if ss.si.Message != "" {
+				logrus.Errorf("Container creation error: %s", ss.si.Message)
+				return fmt.Errorf("container create failed: %s", ss.si.Message)
+			}
+			logrus.Errorf("Container creation failed")
+			return fmt.Errorf("container create failed")
+		}
+	case <-time.After(ContainerCreateTimeout):
+		logrus.Errorf("Container creation timeout (%v)", ContainerCreateTimeout)
+		return fmt.Errorf("create container timeout")
+	}
+	return nil
+}
+
+// conmonRuntimeArgs returns the conmon arguments passing the runtime global
+// options of the container down to the runtime.
+func (r *runtimeOCI) conmonRuntimeArgs(c *Container) []string {
+	var args []string
+	for _, arg := range r.runtimeArgs(c) {
+		args = append(args, "--runtime-arg", arg)
+	}
+	return args
+}
+
+func createUnitName(prefix string, name string) string {
+	return fmt.Sprintf("%s-%s.scope", prefix, name)
+}
+
+// StartContainer starts a container.
+func (r *runtimeOCI) StartContainer(c *Container) error {
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	if err := utils.ExecCmdWithStdStreams(os.Stdin, os.Stdout, os.Stderr, runtimePath, r.runtimeArgs(c, "start", c.id)...); err != nil {
+		return err
+	}
+	c.state.Started = time.Now()
+	return nil
+}
+
+// ExecContainer runs a command in a container through the runtime exec
+// command, streaming its standard streams.
+func (r *runtimeOCI) ExecContainer(c *Container, cmd []string, stdin io.Reader, stdout, stderr io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	processFile, err := PrepareProcessExec(c, cmd, tty)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(processFile.Name())
+
+	args := r.runtimeArgs(c, "exec", "--process", processFile.Name(), c.id)
+	execCmd := exec.Command(runtimePath, args...)
+	var session *ExecSession
+	defer func() {
+		c.untrackExecSession(session)
+	}()
+	var cmdErr error
+	if tty {
+		cmdErr = ttyCmd(execCmd, stdin, stdout, resize, func() {
+			session = c.trackExecSession(execCmd.Process.Pid, cmd, tty, false)
+		})
+	} else {
+		if stdin != nil {
+			// Use an os.Pipe here as it returns true *os.File objects.
+			// This way, if you run 'kubectl exec <pod> -i bash' (no tty) and type 'exit',
+			// the call below to execCmd.Run() can unblock because its Stdin is the read half
+			// of the pipe.
+			r, w, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+			go pools.Copy(w, stdin)
+
+			execCmd.Stdin = r
+		}
+		if stdout != nil {
+			execCmd.Stdout = stdout
+		}
+		if stderr != nil {
+			execCmd.Stderr = stderr
+		}
+
+		cmdErr = execCmd.Start()
+		if cmdErr == nil {
+			session = c.trackExecSession(execCmd.Process.Pid, cmd, tty, false)
+			cmdErr = execCmd.Wait()
+		}
+	}
+
+	if exitErr, ok := cmdErr.(*exec.ExitError); ok {
+		return &utilexec.ExitErrorWrapper{ExitError: exitErr}
+	}
+	return cmdErr
+}
+
+// ExecSyncContainer execs a command in a container and returns it's stdout, stderr and return code.
+// The runtime reads the process spec from a pipe and waits for the command,
+// whose output comes back through pipes. Its exit status, or it timing out,
+// comes back on the sync pipe, without a conmon process or temp files.
+func (r *runtimeOCI) ExecSyncContainer(c *Container, command []string, timeout int64) (resp *ExecSyncResponse, err error) {
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+	processJSON, err := execProcessSpec(c, command, false)
+	if err != nil {
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+	parentPipe, childPipe, err := os.Pipe()
+	if err != nil {
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+	defer parentPipe.Close()
+	specReader, specWriter, err := os.Pipe()
+	if err != nil {
+		childPipe.Close()
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+	defer specWriter.Close()
+
+	cmd := exec.Command(runtimePath, r.runtimeArgs(c, "exec", "--process", "/proc/self/fd/3", c.id)...)
+	// 0, 1 and 2 are stdin, stdout and stderr
+	cmd.ExtraFiles = []*os.File{specReader}
+	output := &execSyncOutput{}
+	if err := output.attach(cmd); err != nil {
+		childPipe.Close()
+		specReader.Close()
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+
+	err = cmd.Start()
+	specReader.Close()
+	output.started()
+	if err != nil {
+		childPipe.Close()
+		output.wait(0)
+		return nil, ExecSyncError{
+			ExitCode: getExitCode(err),
+			Err:      err,
+		}
+	}
+	go func() {
+		// The runtime not reading the spec fails the exec anyway.
+		specWriter.Write(processJSON)
+		specWriter.Close()
+	}()
+	go superviseExecSync(cmd, timeout, childPipe)
+
+	// The pid of the runtime comes first, and then the exit status of the
+	// command.
+	decoder := json.NewDecoder(parentPipe)
+	var si syncInfo
+	if err := decoder.Decode(&si); err != nil {
+		output.wait(0)
+		return nil, ExecSyncError{
+			Stdout:   output.stdout.buf,
+			Stderr:   output.stderr.buf,
+			ExitCode: -1,
+			Err:      fmt.Errorf("failed to get the runtime pid: %v", err),
+		}
+	}
+	session := c.trackExecSession(si.Pid, command, false, true)
+	defer c.untrackExecSession(session)
+
+	var ec exitCodeInfo
+	if err := decoder.Decode(&ec); err != nil {
+		output.wait(0)
+		return nil, ExecSyncError{
+			Stdout:   output.stdout.buf,
+			Stderr:   output.stderr.buf,
+			ExitCode: -1,
+			Err:      fmt.Errorf("failed to get the exit status: %v", err),
+		}
+	}
+	// Processes the command left in the background may keep the output
+	// pipes open.
+	output.wait(execSyncDrainTimeout)
+	if ec.ExitCode == -1 {
+		return nil, ExecSyncError{
+			Stdout:   output.stdout.buf,
+			Stderr:   output.stderr.buf,
+			ExitCode: -1,
+			Err:      errors.New(ec.Message),
+		}
+	}
+	if output.stdout.truncated || output.stderr.truncated {
+		logrus.Warnf("output of exec sync in container %s got truncated to %d bytes", c.id, maxExecSyncOutputSize)
+	}
+	logrus.Debugf("Received container exit code: %v, message: %s", ec.ExitCode, ec.Message)
+
+	return &ExecSyncResponse{
+		Stdout:   output.stdout.buf.Bytes(),
+		Stderr:   output.stderr.buf.Bytes(),
+		ExitCode: ec.ExitCode,
+	}, nil
+}
+
+// UpdateContainer updates container resources
+func (r *runtimeOCI) UpdateContainer(c *Container, res *rspec.LinuxResources) error {
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(runtimePath, r.runtimeArgs(c, "update", "--resources", "-", c.id)...)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	jsonResources, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(jsonResources)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("updating resources for container %q failed: %v %v (%v)", c.id, stderr.String(), stdout.String(), err)
+	}
+	return nil
+}
+
+func waitContainerStop(ctx context.Context, c *Container, timeout time.Duration, ignoreKill bool) error {
+	done := make(chan struct{})
+	// we could potentially re-use "done" channel to exit the loop on timeout,
+	// but we use another channel "chControl" so that we never panic
+	// attempting to close an already-closed "done" channel.  The panic
+	// would occur in the "default" select case below if we'd closed the
+	// "done" channel (instead of the "chControl" channel) in the timeout
+	// select case.
+	chControl := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-chControl:
+				return
+			default:
+				process, err := findprocess.FindProcess(c.state.Pid)
+				if err != nil {
+					if err != findprocess.ErrNotFound {
+						logrus.Warnf("failed to find process %d for container %q: %v", c.state.Pid, c.id, err)
+					}
+					close(done)
+					return
+				}
+				err = process.Release()
+				if err != nil {
+					logrus.Warnf("failed to release process %d for container %q: %v", c.state.Pid, c.id, err)
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(chControl)
+		return ctx.Err()
+	case <-time.After(timeout):
+		close(chControl)
+		if ignoreKill {
+			return fmt.Errorf("failed to wait process, timeout reached after %.0f seconds",
+				timeout.Seconds())
+		}
+		err := kill(c.state.Pid)
+		if err != nil {
+			return fmt.Errorf("failed to kill process: %v", err)
+		}
+	}
+
+	c.state.Finished = time.Now()
+	return nil
+}
+
+// StopContainer stops a container. Timeout is given in seconds.
+func (r *runtimeOCI) StopContainer(ctx context.Context, c *Container, timeout int64) error {
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	// Check if the process is around before sending a signal
+	process, err := findprocess.FindProcess(c.state.Pid)
+	if err == findprocess.ErrNotFound {
+		c.state.Finished = time.Now()
+		return nil
+	}
+	if err != nil {
+		logrus.Warnf("failed to find process %d for container %q: %v", c.state.Pid, c.id, err)
+	} else {
+		err = process.Release()
+		if err != nil {
+			logrus.Warnf("failed to release process %d for container %q: %v", c.state.Pid, c.id, err)
+		}
+	}
+
+	if timeout > 0 {
+		if err := utils.ExecCmdWithStdStreams(os.Stdin, os.Stdout, os.Stderr, runtimePath, r.runtimeArgs(c, "kill", c.id, c.GetStopSignal())...); err != nil {
+			if err := checkProcessGone(c); err != nil {
+				return fmt.Errorf("failed to stop container %q: %v", c.id, err)
+			}
+		}
+		err = waitContainerStop(ctx, c, time.Duration(timeout)*time.Second, true)
+		if err == nil {
+			return nil
+		}
+		logrus.Warnf("Stop container %q timed out: %v", c.id, err)
+	}
+
+	if err := utils.ExecCmdWithStdStreams(os.Stdin, os.Stdout, os.Stderr, runtimePath, r.runtimeArgs(c, "kill", "--all", c.id, "KILL")...); err != nil {
+		if err := checkProcessGone(c); err != nil {
+			return fmt.Errorf("failed to stop container %q: %v", c.id, err)
+		}
+	}
+
+	return waitContainerStop(ctx, c, killContainerTimeout, false)
+}
+
+func checkProcessGone(c *Container) error {
+	process, perr := findprocess.FindProcess(c.state.Pid)
+	if perr == findprocess.ErrNotFound {
+		c.state.Finished = time.Now()
+		return nil
+	}
+	if perr == nil {
+		err := process.Release()
+		if err != nil {
+			logrus.Warnf("failed to release process %d for container %q: %v", c.state.Pid, c.id, err)
+		}
+	}
+	return fmt.Errorf("failed to find process: %v", perr)
+}
+
+// DeleteContainer deletes a container.
+func (r *runtimeOCI) DeleteContainer(c *Container) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	_, err = utils.ExecCmd(runtimePath, r.runtimeArgs(c, "delete", "--force", c.id)...)
+	return err
+}
+
+// UpdateContainerStatus refreshes the status of the container.
+func (r *runtimeOCI) UpdateContainerStatus(c *Container) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(runtimePath, r.runtimeArgs(c, "state", c.id)...).Output()
+	if err != nil {
+		// there are many code paths that could lead to have a bad state in the
+		// underlying runtime.
+		// On any error like a container went away or we rebooted and containers
+		// went away we do not error out stopping kubernetes to recover.
+		// We always populate the fields below so kube can restart/reschedule
+		// containers failing.
+		c.state.Status = ContainerStateStopped
+		c.state.Finished = time.Now()
+		c.state.ExitCode = 255
+		return nil
+	}
+	if err := json.NewDecoder(bytes.NewBuffer(out)).Decode(&c.state); err != nil {
+		return fmt.Errorf("failed to decode container status for %s: %s", c.id, err)
+	}
+
+	if c.state.Status == ContainerStateStopped {
+		exitFilePath := filepath.Join(r.containerExitsDir, c.id)
+		var fi os.FileInfo
+		err = kwait.ExponentialBackoff(
+			kwait.Backoff{
+				Duration: 500 * time.Millisecond,
+				Factor:   1.2,
+				Steps:    6,
+			},
+			func() (bool, error) {
+				var err error
+				fi, err = os.Stat(exitFilePath)
+				if err != nil {
+					// wait longer
+					return false, nil
+				}
+				return true, nil
+			})
+		if err != nil {
+			logrus.Warnf("failed to find container exit file for %v: %v", c.id, err)
+			c.state.ExitCode = -1
+		} else {
+			c.state.Finished = getFinishedTime(fi)
+			statusCodeStr, err := ioutil.ReadFile(exitFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to read exit file: %v", err)
+			}
+			statusCode, err := strconv.Atoi(string(statusCodeStr))
+			if err != nil {
+				return fmt.Errorf("status code conversion failed: %v", err)
+			}
+			c.state.ExitCode = int32(statusCode)
+		}
+
+		oomFilePath := filepath.Join(c.bundlePath, "oom")
+		if _, err = os.Stat(oomFilePath); err == nil {
+			c.state.OOMKilled = true
+		}
+	}
+
+	return nil
+}
+
+// PauseContainer pauses a container.
+func (r *runtimeOCI) PauseContainer(c *Container) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	_, err = utils.ExecCmd(runtimePath, r.runtimeArgs(c, "pause", c.id)...)
+	return err
+}
+
+// UnpauseContainer unpauses a container.
+func (r *runtimeOCI) UnpauseContainer(c *Container) error {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	_, err = utils.ExecCmd(runtimePath, r.runtimeArgs(c, "resume", c.id)...)
+	return err
+}
+
+// CheckpointContainer checkpoints the container with the runtime checkpoint
+// command.
+func (r *runtimeOCI) CheckpointContainer(c *Container, opts *CheckpointOptions) error {
+	args := []string{"checkpoint", "--image-path", opts.ImagePath, "--work-path", opts.WorkPath}
+	if opts.LeaveRunning {
+		args = append(args, "--leave-running")
+	}
+	if opts.TCPEstablished {
+		args = append(args, "--tcp-established")
+	}
+	args = append(args, c.id)
+
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		return err
+	}
+	if _, err := utils.ExecCmd(runtimePath, r.runtimeArgs(c, args...)...); err != nil {
+		return fmt.Errorf("failed to checkpoint container %s: %v", c.id, err)
+	}
+	return nil
+}
+
+// RestoreContainer restores the container through conmon and the runtime
+// restore command.
+func (r *runtimeOCI) RestoreContainer(c *Container, cgroupParent string, imagePath string) error {
+	if err := r.createContainer(c, cgroupParent, imagePath); err != nil {
+		return err
+	}
+
+	c.opLock.Lock()
+	c.state.Started = time.Now()
+	c.state.Finished = time.Time{}
+	c.state.ExitCode = 0
+	c.state.OOMKilled = false
+	c.state.Error = ""
+	c.opLock.Unlock()
+	return r.UpdateContainerStatus(c)
+}
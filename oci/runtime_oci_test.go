@@ -0,0 +1,477 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// fakeRuntimeEnv makes the test binary act as an OCI runtime which only
+// knows about exec, or as conmon running an exec the way ExecSync used to.
+const fakeRuntimeEnv = "CRIO_TEST_FAKE_RUNTIME"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeRuntimeEnv) != "" {
+		for _, arg := range os.Args[1:] {
+			if arg == "-r" {
+				os.Exit(fakeConmon(os.Args[1:]))
+			}
+		}
+		os.Exit(fakeRuntime(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeConmon runs an exec like conmon does: it writes the pid of the runtime
+// to the pid file and the output of the command to the log file, and sends
+// its exit status, or it timing out, on the sync pipe.
+func fakeConmon(args []string) int {
+	var (
+		runtimePath, processPath, cid string
+		logPath, pidFile              string
+		runtimeArgs                   []string
+		timeout                       time.Duration
+	)
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-l":
+			logPath = args[i+1]
+		case "-p":
+			pidFile = args[i+1]
+		case "-r":
+			runtimePath = args[i+1]
+		case "--runtime-arg":
+			runtimeArgs = append(runtimeArgs, args[i+1])
+		case "--exec-process-spec":
+			processPath = args[i+1]
+		case "-c":
+			cid = args[i+1]
+		case "-T":
+			seconds, _ := strconv.Atoi(args[i+1])
+			timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	syncPipe := os.NewFile(3, "sync")
+	cmd := exec.Command(runtimePath, append(runtimeArgs, "exec", "--process", processPath, cid)...)
+	var logOutput bytes.Buffer
+	cmd.Stdout = &logOutput
+	cmd.Stderr = &logOutput
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := ioutil.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timeoutCh = time.After(timeout)
+	}
+	ec := exitCodeInfo{}
+	select {
+	case err := <-done:
+		if err != nil {
+			ec.ExitCode = processExitCode(cmd.ProcessState)
+		}
+	case <-timeoutCh:
+		cmd.Process.Kill()
+		ec = exitCodeInfo{ExitCode: -1, Message: "command timed out"}
+	}
+	var log bytes.Buffer
+	for _, line := range bytes.SplitAfter(logOutput.Bytes(), []byte{'\n'}) {
+		if len(line) > 0 {
+			fmt.Fprintf(&log, "%s stdout F %s", time.Now().Format(time.RFC3339Nano), line)
+		}
+	}
+	if err := ioutil.WriteFile(logPath, log.Bytes(), 0600); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	json.NewEncoder(syncPipe).Encode(ec)
+	return 0
+}
+
+// fakeRuntime runs the process of the spec given to exec as a child it waits
+// for, like runc does, and exits with its status.
+func fakeRuntime(args []string) int {
+	var processPath string
+	for i, arg := range args {
+		if arg == "--process" && i+1 < len(args) {
+			processPath = args[i+1]
+		}
+	}
+	data, err := ioutil.ReadFile(processPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 255
+	}
+	var process specs.Process
+	if err := json.Unmarshal(data, &process); err != nil || len(process.Args) == 0 {
+		fmt.Fprintf(os.Stderr, "invalid process spec %q: %v\n", data, err)
+		return 255
+	}
+	cmd := exec.Command(process.Args[0], process.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if cmd.ProcessState != nil {
+			status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+			if status.Signaled() {
+				return 128 + int(status.Signal())
+			}
+			return status.ExitStatus()
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 255
+	}
+	return 0
+}
+
+func newTestOCIRuntimeOrFailNow(t testing.TB) (*Runtime, *Container) {
+	if err := os.Setenv(fakeRuntimeEnv, "1"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(os.Args[0], "", "trusted", nil, os.Args[0], []string{fakeRuntimeEnv + "=1"}, CgroupfsCgroupsManager, "", -1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundlePath, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewContainer("testid", "testname", bundlePath, "", "", nil, nil, nil, "", "", "", nil, "testsandboxid", false, false, false, false, true, "", "", time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetSpec(&specs.Spec{Process: &specs.Process{Args: []string{"/bin/sleep", "infinity"}}})
+	return r, c
+}
+
+func TestExecSyncContainer(t *testing.T) {
+	r, c := newTestOCIRuntimeOrFailNow(t)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+
+	resp, err := r.ExecSync(c, []string{"/bin/sh", "-c", "echo out; echo err >&2; exit 3"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Stdout) != "out\n" || string(resp.Stderr) != "err\n" || resp.ExitCode != 3 {
+		t.Fatalf("unexpected exec sync response: %q %q %d", resp.Stdout, resp.Stderr, resp.ExitCode)
+	}
+	if args := c.Spec().Process.Args; len(args) != 2 || args[0] != "/bin/sleep" {
+		t.Fatalf("expected the spec of the container to be left alone, got args %q", args)
+	}
+
+	// The runtime is the only process between the daemon and the command.
+	resp, err = r.ExecSync(c, []string{"/bin/sh", "-c", "cat /proc/$PPID/stat"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(resp.Stdout[bytes.LastIndexByte(resp.Stdout, ')')+1:]))
+	if len(fields) < 2 || fields[1] != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("expected the runtime to be a child of the daemon, got %q", resp.Stdout)
+	}
+
+	resp, err = r.ExecSync(c, []string{"/bin/sh", "-c", fmt.Sprintf("head -c %d /dev/zero", maxExecSyncOutputSize+1024)}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Stdout) != maxExecSyncOutputSize || resp.ExitCode != 0 {
+		t.Fatalf("expected the output to be capped to %d bytes, got %d bytes and exit code %d", maxExecSyncOutputSize, len(resp.Stdout), resp.ExitCode)
+	}
+
+	// The background process keeps stdout open after the command exited.
+	start := time.Now()
+	resp, err = r.ExecSync(c, []string{"/bin/sh", "-c", "sleep 5 & echo started"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Stdout) != "started\n" || time.Since(start) > 4*time.Second {
+		t.Fatalf("expected the output to be returned without waiting for the background process, got %q after %v", resp.Stdout, time.Since(start))
+	}
+
+	time.Sleep(execSessionsPersistDelay + 100*time.Millisecond)
+	if fileExists(filepath.Join(c.BundlePath(), execSessionsFile)) {
+		t.Fatal("expected the sessions of the execs which ended early to never be written")
+	}
+}
+
+func TestExecSyncContainerTimeout(t *testing.T) {
+	r, c := newTestOCIRuntimeOrFailNow(t)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+	dir, err := ioutil.TempDir("", "exec-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pid")
+
+	start := time.Now()
+	_, err = r.ExecSync(c, []string{"/bin/sh", "-c", fmt.Sprintf("echo $$ > %s; exec sleep 60", pidFile)}, 1)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected the exec to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the exec to be killed once timed out, took %v", elapsed)
+	}
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pid int
+	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
+		t.Fatal(err)
+	}
+	expectKilled(t, pid)
+}
+
+// expectKilled waits for the process to be killed. An orphaned process may
+// be left a zombie until its new parent reaps it.
+func expectKilled(t *testing.T, pid int) {
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		stat, err := readProcStat(pid)
+		if err != nil || stat.state == "Z" {
+			return
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("expected the exec process %d to be killed", pid)
+		}
+	}
+}
+
+func TestReapExecSessions(t *testing.T) {
+	testReapExecSessions(t, false)
+}
+
+func TestReapExecSessionsWithoutRuntime(t *testing.T) {
+	testReapExecSessions(t, true)
+}
+
+func testReapExecSessions(t *testing.T, withoutRuntime bool) {
+	r, c := newTestOCIRuntimeOrFailNow(t)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+	pidFile := filepath.Join(c.BundlePath(), "pid")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ExecSync(c, []string{"/bin/sh", "-c", fmt.Sprintf("echo $$ > %s; exec sleep 60", pidFile)}, 0)
+	}()
+	var sessions []ExecSession
+	for start := time.Now(); len(sessions) == 0 || sessions[0].ProcessPid == 0 || !fileExists(pidFile); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("expected the exec session to get tracked")
+		}
+		var err error
+		if sessions, err = c.readExecSessions(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pid int
+	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
+		t.Fatal(err)
+	}
+	if session := sessions[0]; len(sessions) != 1 || !session.Sync || session.Pid == 0 || session.StartTime == 0 || session.ProcessPid != pid || session.ProcessStartTime == 0 || session.Command[0] != "/bin/sh" {
+		t.Fatalf("unexpected exec sessions %+v", sessions)
+	}
+
+	if withoutRuntime {
+		// The runtime got killed along with the daemon, leaving the
+		// exec process behind.
+		if err := syscall.Kill(sessions[0].Pid, syscall.SIGKILL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// What a restarted daemon does with the sessions of the previous one,
+	// it knows nothing about but the state file.
+	restored, err := NewContainer(c.ID(), c.Name(), c.BundlePath(), "", "", nil, nil, nil, "", "", "", nil, "testsandboxid", false, false, false, false, true, "", "", time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ReapExecSessions(restored); err != nil {
+		t.Fatal(err)
+	}
+	expectKilled(t, pid)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the exec to end once reaped")
+	}
+	if fileExists(filepath.Join(c.BundlePath(), execSessionsFile)) {
+		t.Fatal("expected the exec sessions to be forgotten")
+	}
+}
+
+func TestPrepareProcessExecCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	if err := os.Setenv("TMPDIR", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewContainer("testid", "testname", dir, "", "", nil, nil, nil, "", "", "", nil, "testsandboxid", false, false, false, false, true, "", "", time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PrepareProcessExec(c, []string{"/bin/true"}, false); err == nil {
+		t.Fatal("expected a container without a process spec to fail")
+	}
+	if files, err := ioutil.ReadDir(dir); err != nil || len(files) != 0 {
+		t.Fatalf("expected the process file to be removed, got %v: %v", files, err)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// BenchmarkExecSyncContainer measures the latency of a liveness probe like
+// exec. Each probe runs the runtime and the command, and nothing else.
+func BenchmarkExecSyncContainer(b *testing.B) {
+	r, c := newTestOCIRuntimeOrFailNow(b)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := r.ExecSync(c, []string{"/bin/sh", "-c", "echo ok"}, 10)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if string(resp.Stdout) != "ok\n" {
+			b.Fatalf("unexpected output %q", resp.Stdout)
+		}
+	}
+}
+
+// BenchmarkExecSyncContainerLogFile is the baseline of
+// BenchmarkExecSyncContainer: each probe goes through conmon as it used to,
+// one more process, with a process spec, a pid file and a log file written
+// to disk, the log getting parsed back for the output. The test binary
+// stands in for conmon, which only makes the baseline faster than the real
+// one, as it doesn't have to set up glib and its main loop.
+func BenchmarkExecSyncContainerLogFile(b *testing.B) {
+	r, c := newTestOCIRuntimeOrFailNow(b)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stdout, err := execSyncLogFile(r, c, []string{"/bin/sh", "-c", "echo ok"})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if string(stdout) != "ok\n" {
+			b.Fatalf("unexpected output %q", stdout)
+		}
+	}
+}
+
+// execSyncLogFile is how ExecSync used to run the command through conmon.
+func execSyncLogFile(r *Runtime, c *Container, command []string) ([]byte, error) {
+	parentPipe, childPipe, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer parentPipe.Close()
+	pidFile, err := ioutil.TempFile("", "pidfile")
+	if err != nil {
+		childPipe.Close()
+		return nil, err
+	}
+	pidFile.Close()
+	defer os.Remove(pidFile.Name())
+	logFile, err := ioutil.TempFile("", "crio-log-"+c.ID())
+	if err != nil {
+		childPipe.Close()
+		return nil, err
+	}
+	logFile.Close()
+	defer os.Remove(logFile.Name())
+	processFile, err := PrepareProcessExec(c, command, false)
+	if err != nil {
+		childPipe.Close()
+		return nil, err
+	}
+	processFile.Close()
+	defer os.Remove(processFile.Name())
+
+	runtimePath, err := r.Path(c)
+	if err != nil {
+		childPipe.Close()
+		return nil, err
+	}
+	cmd := exec.Command(r.conmonPath, "-c", c.ID(), "-r", runtimePath, "-p", pidFile.Name(), "-e", "-T", "10", "-l", logFile.Name(), "--exec-process-spec", processFile.Name())
+	cmd.ExtraFiles = []*os.File{childPipe}
+	cmd.Env = append(r.conmonEnv, "_OCI_SYNCPIPE=3")
+	err = cmd.Start()
+	childPipe.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer cmd.Wait()
+	var ec exitCodeInfo
+	if err := json.NewDecoder(parentPipe).Decode(&ec); err != nil {
+		return nil, err
+	}
+
+	log, err := ioutil.ReadFile(logFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	var stdout []byte
+	for _, line := range bytes.SplitAfter(log, []byte{'\n'}) {
+		// The format of log lines is "DATE pipe LogTag REST".
+		if parts := bytes.SplitN(line, []byte{' '}, 4); len(parts) == 4 && string(parts[1]) == "stdout" {
+			stdout = append(stdout, parts[3]...)
+		}
+	}
+	return stdout, nil
+}
+
+// BenchmarkExecSyncContainerParallel measures the latency of concurrent
+// liveness probe like execs in the same container.
+func BenchmarkExecSyncContainerParallel(b *testing.B) {
+	r, c := newTestOCIRuntimeOrFailNow(b)
+	defer os.Unsetenv(fakeRuntimeEnv)
+	defer os.RemoveAll(c.BundlePath())
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := r.ExecSync(c, []string{"/bin/sh", "-c", "echo ok"}, 10)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if string(resp.Stdout) != "ok\n" {
+				b.Fatalf("unexpected output %q", resp.Stdout)
+			}
+		}
+	})
+}
@@ -0,0 +1,481 @@
+package oci
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/pools"
+	"github.com/docker/docker/pkg/signal"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/utils/exec"
+)
+
+// runtimeProcess is the RuntimeImpl running containers as plain processes
+// on the host, from the process section of their spec. It provides no
+// isolation at all, and is only meant for testing the container lifecycle
+// without root privileges nor an OCI runtime.
+type runtimeProcess struct {
+	containerExitsDir string
+
+	lock      sync.Mutex
+	processes map[string]*process
+}
+
+// process is a container process tracked by runtimeProcess.
+type process struct {
+	// lock protects cmd from being started while its process gets read.
+	lock    sync.Mutex
+	cmd     *exec.Cmd
+	logFile *os.File
+	stdout  *logWriter
+	stderr  *logWriter
+	// done is closed once the process exited and the container state
+	// got updated.
+	done chan struct{}
+}
+
+func newRuntimeProcess(r *Runtime) RuntimeImpl {
+	return &runtimeProcess{
+		containerExitsDir: r.containerExitsDir,
+		processes:         make(map[string]*process),
+	}
+}
+
+// osProcess returns the process of the container, or nil if it wasn't
+// started.
+func (p *process) osProcess() *os.Process {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.cmd.Process
+}
+
+// setState updates the state of the container. The state gets replaced
+// rather than modified in place, as ContainerStatus hands the current one
+// out to be read without the lock of the container, while the process may
+// exit at any time.
+func setState(c *Container, update func(*ContainerState)) {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	state := *c.state
+	update(&state)
+	c.state = &state
+}
+
+func (r *runtimeProcess) getProcess(c *Container) (*process, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p, ok := r.processes[c.id]
+	if !ok {
+		return nil, fmt.Errorf("no process found for container %s", c.id)
+	}
+	return p, nil
+}
+
+// processCommand returns the command running args in the context of the
+// container.
+func processCommand(c *Container, args []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command specified for container %s", c.id)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if c.spec != nil && c.spec.Process != nil {
+		cmd.Env = c.spec.Process.Env
+	}
+	cmd.Dir = c.bundlePath
+	return cmd, nil
+}
+
+// CreateContainer prepares the container process, without starting it.
+func (r *runtimeProcess) CreateContainer(c *Container, cgroupParent string) error {
+	if c.spec == nil || c.spec.Process == nil {
+		return fmt.Errorf("missing process in the spec of container %s", c.id)
+	}
+	cmd, err := processCommand(c, c.spec.Process.Args)
+	if err != nil {
+		return err
+	}
+
+	p := &process{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	for _, driver := range []string{LogDriverJournald, LogDriverSyslog} {
+		if c.HasLogDriver(driver) {
+			logrus.Debugf("ignoring %s log driver of container %s run as a plain process", driver, c.id)
+		}
+	}
+	if c.logPath != "" {
+		p.logFile, err = os.OpenFile(c.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file of container %s: %v", c.id, err)
+		}
+		lock := new(sync.Mutex)
+		p.stdout = &logWriter{lock: lock, w: p.logFile, stream: "stdout"}
+		p.stderr = &logWriter{lock: lock, w: p.logFile, stream: "stderr"}
+		cmd.Stdout = p.stdout
+		cmd.Stderr = p.stderr
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.processes[c.id]; ok {
+		if p.logFile != nil {
+			p.logFile.Close()
+		}
+		return fmt.Errorf("container %s already exists", c.id)
+	}
+	r.processes[c.id] = p
+
+	setState(c, func(state *ContainerState) {
+		state.ID = c.id
+		state.Bundle = c.bundlePath
+		state.Status = ContainerStateCreated
+	})
+	return nil
+}
+
+// StartContainer starts the container process.
+func (r *runtimeProcess) StartContainer(c *Container) error {
+	p, err := r.getProcess(c)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err := p.cmd.Start(); err != nil {
+		return err
+	}
+	pid := p.cmd.Process.Pid
+	setState(c, func(state *ContainerState) {
+		state.Pid = pid
+		state.Status = ContainerStateRunning
+		state.Started = time.Now()
+	})
+
+	go r.wait(c, p)
+	return nil
+}
+
+// wait reaps the container process and updates the container state once it
+// exited.
+func (r *runtimeProcess) wait(c *Container, p *process) {
+	var exitCode int32
+	if err := p.cmd.Wait(); err != nil {
+		exitCode = processExitCode(p.cmd.ProcessState)
+	}
+	if p.logFile != nil {
+		p.stdout.flush()
+		p.stderr.flush()
+		p.logFile.Close()
+	}
+
+	// Write the exit file like conmon does, so that the exit monitor picks
+	// the container exit up.
+	if r.containerExitsDir != "" {
+		exitFile := filepath.Join(r.containerExitsDir, c.id)
+		if err := ioutil.WriteFile(exitFile, []byte(fmt.Sprintf("%d", exitCode)), 0644); err != nil {
+			logrus.Warnf("failed to write exit file for container %s: %v", c.id, err)
+		}
+	}
+
+	setState(c, func(state *ContainerState) {
+		state.Status = ContainerStateStopped
+		state.Finished = time.Now()
+		state.ExitCode = exitCode
+	})
+	close(p.done)
+}
+
+// ExecContainer runs a command next to the container process, streaming its
+// standard streams.
+func (r *runtimeProcess) ExecContainer(c *Container, cmd []string, stdin io.Reader, stdout, stderr io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	execCmd, err := processCommand(c, cmd)
+	if err != nil {
+		return err
+	}
+
+	var cmdErr error
+	if tty {
+		cmdErr = ttyCmd(execCmd, stdin, stdout, resize, nil)
+	} else {
+		if stdin != nil {
+			r, w, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+			go pools.Copy(w, stdin)
+
+			execCmd.Stdin = r
+		}
+		if stdout != nil {
+			execCmd.Stdout = stdout
+		}
+		if stderr != nil {
+			execCmd.Stderr = stderr
+		}
+
+		cmdErr = execCmd.Run()
+	}
+
+	if exitErr, ok := cmdErr.(*exec.ExitError); ok {
+		return &utilexec.ExitErrorWrapper{ExitError: exitErr}
+	}
+	return cmdErr
+}
+
+// ExecSyncContainer runs a command next to the container process and
+// returns its output once it completed.
+func (r *runtimeProcess) ExecSyncContainer(c *Container, command []string, timeout int64) (*ExecSyncResponse, error) {
+	cmd, err := processCommand(c, command)
+	if err != nil {
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+	if err := cmd.Start(); err != nil {
+		return nil, ExecSyncError{
+			ExitCode: -1,
+			Err:      err,
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timeoutCh = time.After(time.Duration(timeout) * time.Second)
+	}
+	select {
+	case err = <-done:
+	case <-timeoutCh:
+		cmd.Process.Kill()
+		<-done
+		return nil, ExecSyncError{
+			Stdout:   stdoutBuf,
+			Stderr:   stderrBuf,
+			ExitCode: -1,
+			Err:      fmt.Errorf("command timed out"),
+		}
+	}
+
+	var exitCode int32
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, ExecSyncError{
+				Stdout:   stdoutBuf,
+				Stderr:   stderrBuf,
+				ExitCode: -1,
+				Err:      err,
+			}
+		}
+		exitCode = processExitCode(cmd.ProcessState)
+	}
+
+	return &ExecSyncResponse{
+		Stdout:   stdoutBuf.Bytes(),
+		Stderr:   stderrBuf.Bytes(),
+		ExitCode: exitCode,
+	}, nil
+}
+
+// UpdateContainer is a no-op, as plain processes have no resources to
+// update.
+func (r *runtimeProcess) UpdateContainer(c *Container, res *rspec.LinuxResources) error {
+	logrus.Debugf("ignoring resources update of container %s run as a plain process", c.id)
+	return nil
+}
+
+// StopContainer stops the container process. Timeout is given in seconds.
+func (r *runtimeProcess) StopContainer(ctx context.Context, c *Container, timeout int64) error {
+	p, err := r.getProcess(c)
+	if err != nil {
+		return err
+	}
+
+	proc := p.osProcess()
+	if proc == nil {
+		// The container was never started.
+		setState(c, func(state *ContainerState) {
+			state.Status = ContainerStateStopped
+			state.Finished = time.Now()
+		})
+		return nil
+	}
+
+	if timeout > 0 {
+		if err := proc.Signal(signal.SignalMap[c.GetStopSignal()]); err == nil {
+			select {
+			case <-p.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(timeout) * time.Second):
+				logrus.Warnf("Stop container %q timed out", c.id)
+			}
+		}
+	}
+
+	proc.Kill()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(killContainerTimeout):
+		return fmt.Errorf("failed to kill container %s, timeout reached after %.0f seconds",
+			c.id, killContainerTimeout.Seconds())
+	}
+}
+
+// DeleteContainer kills the container process if needed, and forgets about
+// it.
+func (r *runtimeProcess) DeleteContainer(c *Container) error {
+	p, err := r.getProcess(c)
+	if err != nil {
+		return err
+	}
+
+	if proc := p.osProcess(); proc != nil {
+		proc.Kill()
+		<-p.done
+	} else if p.logFile != nil {
+		p.logFile.Close()
+	}
+
+	r.lock.Lock()
+	delete(r.processes, c.id)
+	r.lock.Unlock()
+	return nil
+}
+
+// UpdateContainerStatus refreshes the status of the container. The state of
+// tracked containers is kept up to date as their process exits, so this only
+// marks containers this runtime doesn't know about as stopped.
+func (r *runtimeProcess) UpdateContainerStatus(c *Container) error {
+	if _, err := r.getProcess(c); err == nil {
+		return nil
+	}
+
+	setState(c, func(state *ContainerState) {
+		if state.Status != ContainerStateStopped {
+			state.Status = ContainerStateStopped
+			state.Finished = time.Now()
+			state.ExitCode = 255
+		}
+	})
+	return nil
+}
+
+// PauseContainer stops the container process with SIGSTOP.
+func (r *runtimeProcess) PauseContainer(c *Container) error {
+	return r.signalState(c, "STOP", ContainerStatePaused)
+}
+
+// UnpauseContainer resumes the container process with SIGCONT.
+func (r *runtimeProcess) UnpauseContainer(c *Container) error {
+	return r.signalState(c, "CONT", ContainerStateRunning)
+}
+
+func (r *runtimeProcess) signalState(c *Container, sig string, status string) error {
+	p, err := r.getProcess(c)
+	if err != nil {
+		return err
+	}
+	proc := p.osProcess()
+	if proc == nil {
+		return fmt.Errorf("container %s is not running", c.id)
+	}
+
+	// The process exiting meanwhile doesn't get its state overwritten.
+	setState(c, func(state *ContainerState) {
+		if err = proc.Signal(signal.SignalMap[sig]); err == nil {
+			state.Status = status
+		}
+	})
+	return err
+}
+
+// CheckpointContainer is not supported for plain processes.
+func (r *runtimeProcess) CheckpointContainer(c *Container, opts *CheckpointOptions) error {
+	return fmt.Errorf("checkpoint is not supported by the process runtime")
+}
+
+// RestoreContainer is not supported for plain processes.
+func (r *runtimeProcess) RestoreContainer(c *Container, cgroupParent string, imagePath string) error {
+	return fmt.Errorf("restore is not supported by the process runtime")
+}
+
+// processExitCode returns the exit code of a process which exited, or -1 if
+// it didn't.
+func processExitCode(state *os.ProcessState) int32 {
+	if state != nil {
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Exited() {
+			return int32(status.ExitStatus())
+		}
+	}
+	return -1
+}
+
+// logWriter writes the output of a container process to its log file, one
+// CRI formatted line at a time.
+type logWriter struct {
+	// lock serializes the writes of the stdout and stderr writers sharing
+	// the same log file.
+	lock   *sync.Mutex
+	w      io.Writer
+	stream string
+	buf    []byte
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := l.writeLine(l.buf[:i]); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush writes out the last line of output, if it wasn't terminated by a
+// newline.
+func (l *logWriter) flush() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.buf) > 0 {
+		l.writeLine(l.buf)
+		l.buf = nil
+	}
+}
+
+func (l *logWriter) writeLine(line []byte) error {
+	_, err := fmt.Fprintf(l.w, "%s %s F %s\n", time.Now().Format(time.RFC3339Nano), l.stream, line)
+	return err
+}
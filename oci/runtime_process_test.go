@@ -0,0 +1,148 @@
+package oci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/net/context"
+)
+
+func newTestProcessRuntimeOrFailNow(t *testing.T, exitsDir string) *Runtime {
+	runtimes := map[string]RuntimeHandler{
+		"process": {RuntimeType: RuntimeTypeProcess},
+	}
+	r, err := New("/bin/false", "", "trusted", runtimes, "/bin/false", nil, CgroupfsCgroupsManager, exitsDir, -1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func newTestProcessContainerOrFailNow(t *testing.T, dir string, args ...string) *Container {
+	c, err := NewContainer("testid", "testname", dir, filepath.Join(dir, "ctr.log"), "", nil, nil, nil, "", "", "", nil, "testsandboxid", false, false, false, false, false, "process", dir, time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetSpec(&specs.Spec{Process: &specs.Process{Args: args}})
+	return c
+}
+
+func TestProcessRuntimeLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtime-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := newTestProcessRuntimeOrFailNow(t, dir)
+	c := newTestProcessContainerOrFailNow(t, dir, "/bin/sh", "-c", "echo hello; exec sleep 60")
+
+	if err := r.CreateContainer(c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if status := r.ContainerStatus(c).Status; status != ContainerStateCreated {
+		t.Fatalf("expected container to be %s, got %s", ContainerStateCreated, status)
+	}
+
+	if err := r.StartContainer(c); err != nil {
+		t.Fatal(err)
+	}
+	if status := r.ContainerStatus(c).Status; status != ContainerStateRunning {
+		t.Fatalf("expected container to be %s, got %s", ContainerStateRunning, status)
+	}
+
+	resp, err := r.ExecSync(c, []string{"/bin/sh", "-c", "echo out; echo err >&2; exit 3"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Stdout) != "out\n" || string(resp.Stderr) != "err\n" || resp.ExitCode != 3 {
+		t.Fatalf("unexpected exec sync response: %q %q %d", resp.Stdout, resp.Stderr, resp.ExitCode)
+	}
+
+	// Make sure the container wrote its output before stopping it.
+	for i := 0; ; i++ {
+		if logs, _ := ioutil.ReadFile(c.LogPath()); strings.Contains(string(logs), "hello") {
+			break
+		}
+		if i == 100 {
+			t.Fatal("expected the container to write its output")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := r.StopContainer(context.Background(), c, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitContainerStateStopped(context.Background(), c, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := ioutil.ReadFile(c.LogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(logs), " stdout F hello\n") {
+		t.Fatalf("unexpected container logs: %q", logs)
+	}
+
+	if err := r.DeleteContainer(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessRuntimeExitCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtime-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := newTestProcessRuntimeOrFailNow(t, dir)
+	c := newTestProcessContainerOrFailNow(t, dir, "/bin/sh", "-c", "exit 42")
+
+	if err := r.CreateContainer(c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StartContainer(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitContainerStateStopped(context.Background(), c, 10); err != nil {
+		t.Fatal(err)
+	}
+	if exitCode := r.ContainerStatus(c).ExitCode; exitCode != 42 {
+		t.Fatalf("expected exit code 42, got %d", exitCode)
+	}
+
+	exitFile, err := ioutil.ReadFile(filepath.Join(dir, c.ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(exitFile) != "42" {
+		t.Fatalf("unexpected exit file content: %q", exitFile)
+	}
+}
+
+func TestUnknownRuntimeHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtime-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := newTestProcessRuntimeOrFailNow(t, dir)
+	c, err := NewContainer("testid", "testname", dir, filepath.Join(dir, "ctr.log"), "", nil, nil, nil, "", "", "", nil, "testsandboxid", false, false, false, false, false, "removed", dir, time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err := r.Path(c); err == nil {
+		t.Errorf("expected the container of an unknown runtime handler to have no runtime path, got %s", path)
+	}
+	if err := r.CreateContainer(c, ""); err == nil {
+		t.Error("expected the container of an unknown runtime handler to fail to be created")
+	}
+}
@@ -31,6 +31,46 @@ const (
 	// SeccompProfilePath is the node seccomp profile path
 	SeccompProfilePath = "io.kubernetes.cri-o.SeccompProfilePath"
 
+	// LogRotateFiles is the number of compressed generations of the log
+	// file of a container conmon keeps when rotating it, 0 not to rotate it
+	LogRotateFiles = "io.kubernetes.cri-o.LogRotateFiles"
+
+	// LogRotateSize is the size in bytes the log file of a container gets
+	// rotated at, 0 not to rotate it by size
+	LogRotateSize = "io.kubernetes.cri-o.LogRotateSize"
+
+	// LogRotateAge is how long the log file of a container gets written to
+	// before being rotated, as a duration, 0 not to rotate it by age
+	LogRotateAge = "io.kubernetes.cri-o.LogRotateAge"
+
+	// LogRateLimitBytes is the number of bytes of output per second of a
+	// container written to its log, which may only lower the default one
+	LogRateLimitBytes = "io.kubernetes.cri-o.LogRateLimitBytes"
+
+	// LogRateLimitLines is the number of lines of output per second of a
+	// container written to its log, which may only lower the default one
+	LogRateLimitLines = "io.kubernetes.cri-o.LogRateLimitLines"
+
+	// LogRateLimitBurst is the number of seconds of output at the log rate
+	// limits a container may write at once, which may only lower the
+	// default one
+	LogRateLimitBurst = "io.kubernetes.cri-o.LogRateLimitBurst"
+
+	// LogDrivers is the comma separated list of the log drivers conmon
+	// writes the output of a container with, k8s-file, journald or syslog
+	LogDrivers = "io.kubernetes.cri-o.LogDrivers"
+
+	// LogSyslogAddress is the address of the remote syslog server conmon
+	// sends the output of a container to with the syslog log driver
+	LogSyslogAddress = "io.kubernetes.cri-o.LogSyslogAddress"
+
+	// SeccompAudit is the seccomp audit mode requested for a container or a pod
+	SeccompAudit = "io.kubernetes.cri-o.SeccompAudit"
+
+	// SeccompAuditProfilePath is where the profile generated by the seccomp
+	// audit of a container gets written
+	SeccompAuditProfilePath = "io.kubernetes.cri-o.SeccompAuditProfilePath"
+
 	// Image is the container image ID annotation
 	Image = "io.kubernetes.cri-o.Image"
 
@@ -64,6 +104,10 @@ const (
 	// PrivilegedRuntime is the annotation for the privileged runtime path
 	PrivilegedRuntime = "io.kubernetes.cri-o.PrivilegedRuntime"
 
+	// RuntimeHandler is the annotation selecting the runtime handler of a
+	// sandbox, among the ones configured in crio.runtime.runtimes
+	RuntimeHandler = "io.kubernetes.cri-o.RuntimeHandler"
+
 	// ResolvPath is the resolver configuration path annotation
 	ResolvPath = "io.kubernetes.cri-o.ResolvPath"
 
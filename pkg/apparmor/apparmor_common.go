@@ -1,5 +1,10 @@
 package apparmor
 
+import (
+	"os"
+	"strings"
+)
+
 const (
 	// DefaultApparmorProfile is the name of default apparmor profile name.
 	DefaultApparmorProfile = "crio-default"
@@ -7,4 +12,12 @@ const (
 	ProfileRuntimeDefault = "runtime/default"
 	// ProfileNamePrefix is the prefix for specifying profiles loaded on the node.
 	ProfileNamePrefix = "localhost/"
+	// ProfileUnconfined is the profile specifying to run without apparmor.
+	ProfileUnconfined = "unconfined"
 )
+
+// IsProfileFile returns whether the file of a profile directory holds a
+// profile to load: it's a regular file, and it's not hidden.
+func IsProfileFile(info os.FileInfo) bool {
+	return info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), ".")
+}
@@ -106,6 +106,28 @@ func IsLoaded(name string) (bool, error) {
 	return false, nil
 }
 
+// LoadProfileDir loads the profiles of all the files in dir into the kernel
+// using 'apparmor_parser'. Hidden files are skipped.
+func LoadProfileDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	failed := []string{}
+	for _, f := range files {
+		if !IsProfileFile(f) {
+			continue
+		}
+		if err := LoadProfile(path.Join(dir, f.Name())); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("loading apparmor profiles from %s failed: %s", dir, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
 // generateDefault creates an apparmor profile from ProfileData.
 func (p *profileData) generateDefault(out io.Writer) error {
 	compiled, err := templates.NewParse("apparmor_profile", baseTemplate)
@@ -2,6 +2,10 @@
 
 package apparmor
 
+import "errors"
+
+var errUnsupported = errors.New("apparmor support is not compiled in")
+
 // IsEnabled returns false, when build without apparmor build tag.
 func IsEnabled() bool {
 	return false
@@ -11,3 +15,18 @@ func IsEnabled() bool {
 func EnsureDefaultApparmorProfile() error {
 	return nil
 }
+
+// IsLoaded returns false, when build without apparmor build tag.
+func IsLoaded(name string) (bool, error) {
+	return false, nil
+}
+
+// LoadProfile returns an error, when build without apparmor build tag.
+func LoadProfile(profilePath string) error {
+	return errUnsupported
+}
+
+// LoadProfileDir returns an error, when build without apparmor build tag.
+func LoadProfileDir(dir string) error {
+	return errUnsupported
+}
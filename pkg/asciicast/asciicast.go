@@ -0,0 +1,125 @@
+// Package asciicast writes terminal sessions in the asciicast v2 format, a
+// JSON header line followed by a JSON line per timed event.
+package asciicast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the version of the asciicast format written.
+const Version = 2
+
+// EventType is the type of an event of a recording.
+type EventType string
+
+const (
+	// EventOutput is the data the session printed.
+	EventOutput EventType = "o"
+	// EventInput is the data the session got typed in.
+	EventInput EventType = "i"
+	// EventResize is a resize of the terminal, as "<width>x<height>".
+	EventResize EventType = "r"
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Writer writes the events of a session, timed from the moment the header
+// got written.
+type Writer struct {
+	lock  sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewWriter writes the header of a recording to w, and returns the writer of
+// its events. The version of the header is set, and its timestamp too if it
+// isn't yet.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	start := time.Now()
+	header.Version = Version
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start}, nil
+}
+
+// WriteEvent writes an event with the data to the recording.
+func (w *Writer) WriteEvent(eventType EventType, data string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	elapsed := time.Since(w.start).Seconds()
+	line, err := json.Marshal([]interface{}{elapsed, eventType, data})
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(line, '\n'))
+	return err
+}
+
+// WriteResize writes a resize event of the terminal to the recording.
+func (w *Writer) WriteResize(width, height uint16) error {
+	return w.WriteEvent(EventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// Stream returns an io.Writer writing what it gets as events of the type.
+// Characters split across writes are held back until they are complete, as
+// the data of the events must be valid UTF-8.
+func (w *Writer) Stream(eventType EventType) io.Writer {
+	return &stream{w: w, eventType: eventType}
+}
+
+type stream struct {
+	w         *Writer
+	eventType EventType
+	pending   []byte
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	data := append(s.pending, p...)
+	n := len(data) - incompleteRuneLen(data)
+	s.pending = append([]byte(nil), data[n:]...)
+	if n == 0 {
+		return len(p), nil
+	}
+	if err := s.w.WriteEvent(s.eventType, string(data[:n])); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// incompleteRuneLen returns the length of the start of a character data ends
+// with, if any.
+func incompleteRuneLen(data []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		start := len(data) - i
+		if !utf8.RuneStart(data[start]) {
+			continue
+		}
+		if utf8.FullRune(data[start:]) {
+			return 0
+		}
+		return i
+	}
+	return 0
+}
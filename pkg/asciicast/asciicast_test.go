@@ -0,0 +1,72 @@
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{Width: 80, Height: 24, Command: "sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := w.Stream(EventOutput)
+	// "é" gets split across the writes.
+	for _, data := range [][]byte{[]byte("caf\xc3"), []byte("\xa9\r\n"), []byte("$ ")} {
+		if _, err := out.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.Stream(EventInput).Write([]byte("exit\r")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteResize(120, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	if !scanner.Scan() {
+		t.Fatal("expected a header")
+	}
+	var header Header
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != Version || header.Width != 80 || header.Height != 24 || header.Command != "sh" || header.Timestamp == 0 {
+		t.Errorf("unexpected header %+v", header)
+	}
+
+	for _, expected := range []struct {
+		eventType EventType
+		data      string
+	}{
+		{EventOutput, "caf"},
+		{EventOutput, "é\r\n"},
+		{EventOutput, "$ "},
+		{EventInput, "exit\r"},
+		{EventResize, "120x40"},
+	} {
+		if !scanner.Scan() {
+			t.Fatalf("expected event %q %q", expected.eventType, expected.data)
+		}
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		if len(event) != 3 {
+			t.Fatalf("expected an event of 3 fields, got %v", event)
+		}
+		if _, ok := event[0].(float64); !ok {
+			t.Errorf("expected the time of the event to be a number, got %v", event[0])
+		}
+		if event[1] != string(expected.eventType) || event[2] != expected.data {
+			t.Errorf("expected event %q %q, got %v", expected.eventType, expected.data, event)
+		}
+	}
+	if scanner.Scan() {
+		t.Errorf("expected no other event, got %s", scanner.Text())
+	}
+}
@@ -0,0 +1,144 @@
+// Package cgroupv2 reads the statistics of the cgroups of the unified (v2)
+// cgroup hierarchy.
+package cgroupv2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// max is the value of the limits of a cgroup which are unset.
+const max = "max"
+
+// Stats are the statistics of a cgroup.
+type Stats struct {
+	CPU    CPUStats
+	Memory MemoryStats
+	IO     IOStats
+	Pids   PidsStats
+}
+
+// CPUStats are the statistics of the cpu controller, read from cpu.stat.
+type CPUStats struct {
+	// UsageUsec, UserUsec and SystemUsec are the CPU time used by the
+	// processes of the cgroup, in microseconds.
+	UsageUsec  uint64
+	UserUsec   uint64
+	SystemUsec uint64
+}
+
+// MemoryStats are the statistics of the memory controller.
+type MemoryStats struct {
+	// Usage is the memory used by the cgroup, read from memory.current.
+	Usage uint64
+	// Limit is the hard memory limit of the cgroup, read from memory.max,
+	// or math.MaxUint64 if it's unset.
+	Limit uint64
+	// Events are the counts of memory events of the cgroup, read from
+	// memory.events, such as "oom" and "oom_kill".
+	Events map[string]uint64
+}
+
+// IOStats are the statistics of the io controller, read from io.stat.
+type IOStats struct {
+	// ReadBytes and WriteBytes sum the bytes read from and written to all
+	// the devices.
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// PidsStats are the statistics of the pids controller.
+type PidsStats struct {
+	// Current is the number of processes of the cgroup, read from
+	// pids.current.
+	Current uint64
+}
+
+// parseValue parses the value of a cgroup file holding a single one, where
+// "max" stands for no limit.
+func parseValue(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == max {
+		return math.MaxUint64, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// parseFlatKeyed parses a flat keyed cgroup file, such as cpu.stat or
+// memory.events, which lines are "<key> <value>".
+func parseFlatKeyed(r io.Reader) (map[string]uint64, error) {
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %q", scanner.Text())
+		}
+		value, err := parseValue(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %v", fields[0], err)
+		}
+		values[fields[0]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseIOStat parses an io.stat file, which lines are the device numbers
+// followed by "<key>=<value>" pairs, and sums the bytes read and written.
+func parseIOStat(r io.Reader) (IOStats, error) {
+	var stats IOStats
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				return stats, fmt.Errorf("invalid line %q", scanner.Text())
+			}
+			var sum *uint64
+			switch kv[0] {
+			case "rbytes":
+				sum = &stats.ReadBytes
+			case "wbytes":
+				sum = &stats.WriteBytes
+			default:
+				continue
+			}
+			value, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return stats, fmt.Errorf("invalid value of %s: %v", kv[0], err)
+			}
+			*sum += value
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// parseProcCgroup returns the path of the cgroup of the unified hierarchy,
+// relative to its root, from the content of a /proc/<pid>/cgroup file.
+func parseProcCgroup(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimPrefix(scanner.Text(), "0::"); path != scanner.Text() {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("unified hierarchy cgroup not found")
+}
@@ -0,0 +1,158 @@
+// +build linux
+
+package cgroupv2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const superMagic = 0x63677270
+
+// Root is where the cgroup hierarchies are mounted.
+var Root = "/sys/fs/cgroup"
+
+var unified struct {
+	once sync.Once
+	mode bool
+	err  error
+}
+
+// IsUnifiedMode returns whether the host uses the unified cgroup hierarchy
+// only.
+func IsUnifiedMode() (bool, error) {
+	unified.once.Do(func() {
+		var st unix.Statfs_t
+		if unified.err = unix.Statfs(Root, &st); unified.err == nil {
+			unified.mode = st.Type == superMagic
+		}
+	})
+	return unified.mode, unified.err
+}
+
+// ProcessPath returns the absolute path of the cgroup of the process, which
+// is the same whether the cgroup got created by systemd or through the
+// cgroup filesystem.
+func ProcessPath(pid int) (string, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	path, err := parseProcCgroup(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to find cgroup of process %d: %v", pid, err)
+	}
+	return filepath.Join(Root, path), nil
+}
+
+// HasController returns whether the controller is available to the cgroups
+// of the unified hierarchy.
+func HasController(controller string) (bool, error) {
+	controllers, err := ioutil.ReadFile(filepath.Join(Root, "cgroup.controllers"))
+	if err != nil {
+		return false, err
+	}
+	for _, c := range strings.Fields(string(controllers)) {
+		if c == controller {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddProcess moves the process to the cgroup at path, relative to the root
+// of the hierarchy, creating the cgroup if needed.
+func AddProcess(path string, pid int) error {
+	path = filepath.Join(Root, path)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644)
+}
+
+// ReadStats reads the statistics of the cgroup at the absolute path. The
+// statistics of the controllers which aren't enabled for the cgroup are
+// left zero.
+func ReadStats(path string) (*Stats, error) {
+	stats := &Stats{}
+
+	cpu, err := readFlatKeyed(path, "cpu.stat")
+	if err != nil {
+		return nil, err
+	}
+	stats.CPU = CPUStats{
+		UsageUsec:  cpu["usage_usec"],
+		UserUsec:   cpu["user_usec"],
+		SystemUsec: cpu["system_usec"],
+	}
+
+	if stats.Memory.Usage, err = readValue(path, "memory.current"); err != nil {
+		return nil, err
+	}
+	if stats.Memory.Limit, err = readValue(path, "memory.max"); err != nil {
+		return nil, err
+	}
+	if stats.Memory.Events, err = readFlatKeyed(path, "memory.events"); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(path, "io.stat"))
+	if err == nil {
+		stats.IO, err = parseIOStat(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", f.Name(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if stats.Pids.Current, err = readValue(path, "pids.current"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// readValue reads a cgroup file holding a single value, or returns 0 if the
+// controller of the file isn't enabled.
+func readValue(path, name string) (uint64, error) {
+	content, err := ioutil.ReadFile(filepath.Join(path, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	value, err := parseValue(string(content))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", filepath.Join(path, name), err)
+	}
+	return value, nil
+}
+
+// readFlatKeyed reads a flat keyed cgroup file, or returns no values if the
+// controller of the file isn't enabled.
+func readFlatKeyed(path, name string) (map[string]uint64, error) {
+	f, err := os.Open(filepath.Join(path, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]uint64{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	values, err := parseFlatKeyed(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", f.Name(), err)
+	}
+	return values, nil
+}
@@ -0,0 +1,104 @@
+package cgroupv2
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlatKeyed(t *testing.T) {
+	values, err := parseFlatKeyed(strings.NewReader("usage_usec 1500\nuser_usec 1000\nsystem_usec 500\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]uint64{"usage_usec": 1500, "user_usec": 1000, "system_usec": 500}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+	for _, content := range []string{"usage_usec\n", "usage_usec 1 2\n", "usage_usec abc\n"} {
+		if _, err := parseFlatKeyed(strings.NewReader(content)); err == nil {
+			t.Errorf("expected %q to be invalid", content)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	if value, err := parseValue("4096\n"); err != nil || value != 4096 {
+		t.Errorf("expected 4096, got %d: %v", value, err)
+	}
+	if value, err := parseValue("max\n"); err != nil || value != math.MaxUint64 {
+		t.Errorf("expected no limit, got %d: %v", value, err)
+	}
+	if _, err := parseValue("-1"); err == nil {
+		t.Error("expected -1 to be invalid")
+	}
+}
+
+func TestParseIOStat(t *testing.T) {
+	stats, err := parseIOStat(strings.NewReader(
+		"8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0\n" +
+			"253:0 rbytes=4096 wbytes=0 rios=3 wios=0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ReadBytes != 5120 || stats.WriteBytes != 2048 {
+		t.Errorf("expected 5120 bytes read and 2048 written, got %+v", stats)
+	}
+	if _, err := parseIOStat(strings.NewReader("8:0 rbytes\n")); err == nil {
+		t.Error("expected a field without a value to be invalid")
+	}
+}
+
+func TestParseProcCgroup(t *testing.T) {
+	path, err := parseProcCgroup(strings.NewReader("0::/kubepods.slice/kubepods-pod1.slice/crio-abc.scope\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/kubepods.slice/kubepods-pod1.slice/crio-abc.scope" {
+		t.Errorf("unexpected cgroup path %q", path)
+	}
+	if _, err := parseProcCgroup(strings.NewReader("3:memory:/kubepods/pod1/crio-abc\n")); err == nil {
+		t.Error("expected no unified hierarchy cgroup to be found")
+	}
+}
+
+func TestReadStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroupv2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"cpu.stat":       "usage_usec 1500\nuser_usec 1000\nsystem_usec 500\n",
+		"memory.current": "8192\n",
+		"memory.max":     "max\n",
+		"memory.events":  "low 0\nhigh 0\nmax 4\noom 1\noom_kill 1\n",
+		"io.stat":        "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2\n",
+		// pids.current is missing as the pids controller isn't enabled.
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := ReadStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Stats{
+		CPU: CPUStats{UsageUsec: 1500, UserUsec: 1000, SystemUsec: 500},
+		Memory: MemoryStats{
+			Usage:  8192,
+			Limit:  math.MaxUint64,
+			Events: map[string]uint64{"low": 0, "high": 0, "max": 4, "oom": 1, "oom_kill": 1},
+		},
+		IO: IOStats{ReadBytes: 1024, WriteBytes: 2048},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected the stats %+v, got %+v", expected, stats)
+	}
+}
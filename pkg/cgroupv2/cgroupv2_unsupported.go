@@ -0,0 +1,37 @@
+// +build !linux
+
+package cgroupv2
+
+import (
+	"errors"
+)
+
+var errUnsupported = errors.New("cgroups are not supported on this platform")
+
+// IsUnifiedMode returns whether the host uses the unified cgroup hierarchy
+// only.
+func IsUnifiedMode() (bool, error) {
+	return false, errUnsupported
+}
+
+// ProcessPath returns the absolute path of the cgroup of the process.
+func ProcessPath(pid int) (string, error) {
+	return "", errUnsupported
+}
+
+// HasController returns whether the controller is available to the cgroups
+// of the unified hierarchy.
+func HasController(controller string) (bool, error) {
+	return false, errUnsupported
+}
+
+// AddProcess moves the process to the cgroup at path, relative to the root
+// of the hierarchy.
+func AddProcess(path string, pid int) error {
+	return errUnsupported
+}
+
+// ReadStats reads the statistics of the cgroup at the absolute path.
+func ReadStats(path string) (*Stats, error) {
+	return nil, errUnsupported
+}
@@ -0,0 +1,112 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// compressedExt is the extension of the generations of a log file conmon
+// compressed once it rotated them.
+const compressedExt = ".gz"
+
+// generations returns the paths of the generations of the log file at path
+// conmon rotated, "<path>.<n>" compressed or not, from the most recent one.
+func generations(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int]string)
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, path+".")
+		n, err := strconv.Atoi(strings.TrimSuffix(suffix, compressedExt))
+		if err != nil || n < 1 {
+			continue
+		}
+		// The generation being compressed is still there uncompressed.
+		if _, ok := byNumber[n]; !ok || !strings.HasSuffix(suffix, compressedExt) {
+			byNumber[n] = match
+		}
+	}
+	numbers := make([]int, 0, len(byNumber))
+	for n := range byNumber {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	paths := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		paths = append(paths, byNumber[n])
+	}
+	return paths, nil
+}
+
+// openGeneration opens the generation of a log file, uncompressing it to an
+// unlinked temporary file if it's compressed, for it to be read backwards.
+func openGeneration(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, compressedExt) {
+		return f, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tmp, err := ioutil.TempFile("", "crio-log")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, gz); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// readGeneration reads the lines of a generation of the log file.
+func (r *reader) readGeneration(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// It got rotated away meanwhile.
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	var content io.Reader = f
+	if strings.HasSuffix(path, compressedExt) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		content = gz
+	}
+	br := bufio.NewReaderSize(content, blockSize)
+	for !r.done {
+		data, err := br.ReadBytes('\n')
+		if len(data) > 0 {
+			if err := r.line(bytes.TrimSuffix(data, []byte{'\n'})); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
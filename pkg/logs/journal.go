@@ -0,0 +1,230 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// journalctl is the command the journal gets read with.
+var journalctl = "journalctl"
+
+const (
+	// journalContainerIDField is the field of the journal entries conmon
+	// writes holding the full ID of the container.
+	journalContainerIDField = "CONTAINER_ID_FULL"
+
+	// The priorities of the journal entries conmon writes tell their
+	// stream.
+	journalPriorityStdout = "6"
+	journalPriorityStderr = "3"
+)
+
+// journalEntry is an entry of the journal, as journalctl outputs it in JSON.
+type journalEntry struct {
+	Cursor    string `json:"__CURSOR"`
+	Timestamp string `json:"__REALTIME_TIMESTAMP"`
+	Priority  string `json:"PRIORITY"`
+	// Partial is "true" for the partial lines a long line gets split into.
+	Partial string `json:"CONTAINER_PARTIAL_MESSAGE"`
+	// Message is a string, or an array of bytes if it's not printable.
+	Message json.RawMessage `json:"MESSAGE"`
+}
+
+func (e *journalEntry) logLine() (*logLine, error) {
+	usec, err := strconv.ParseInt(e.Timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp of journal entry %s: %v", e.Cursor, err)
+	}
+	l := &logLine{
+		timestamp: time.Unix(0, usec*int64(time.Microsecond)),
+		partial:   e.Partial == "true",
+	}
+	switch e.Priority {
+	case journalPriorityStdout:
+		l.stream = Stdout
+	case journalPriorityStderr:
+		l.stream = Stderr
+	default:
+		return nil, fmt.Errorf("invalid priority of journal entry %s", e.Cursor)
+	}
+	var content string
+	if err := json.Unmarshal(e.Message, &content); err == nil {
+		l.content = []byte(content)
+		return l, nil
+	}
+	var data []int
+	if err := json.Unmarshal(e.Message, &data); err != nil {
+		return nil, fmt.Errorf("invalid message of journal entry %s: %v", e.Cursor, err)
+	}
+	l.content = make([]byte, len(data))
+	for i, b := range data {
+		l.content[i] = byte(b)
+	}
+	return l, nil
+}
+
+// ReadJournal calls fn with the messages conmon wrote to the journal for the
+// container selected by opts, in the order they got logged, until fn fails.
+func ReadJournal(ctx context.Context, containerID string, opts *Options, fn func(*Message) error) error {
+	r := &reader{
+		opts:    opts,
+		fn:      fn,
+		pending: make(map[Stream]*Message),
+		skip:    make(map[Stream]bool),
+	}
+
+	args := []string{"--output=json", "--no-pager", "--all", journalContainerIDField + "=" + containerID}
+	switch opts.Stream {
+	case Stdout:
+		args = append(args, "PRIORITY="+journalPriorityStdout)
+	case Stderr:
+		args = append(args, "PRIORITY="+journalPriorityStderr)
+	}
+	if !opts.Since.IsZero() {
+		// The entries are selected to the second, and then precisely.
+		args = append(args, "--since", fmt.Sprintf("@%d", opts.Since.Unix()))
+	}
+	var cursor string
+	if opts.Tail > 0 || !opts.Follow {
+		readArgs := args
+		if !opts.Until.IsZero() {
+			readArgs = append(readArgs, "--until", fmt.Sprintf("@%d", opts.Until.Unix()+1))
+		}
+		var err error
+		if opts.Tail > 0 {
+			cursor, err = r.readJournalTail(ctx, readArgs)
+		} else {
+			cursor, _, err = r.readJournal(ctx, readArgs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !opts.Follow || r.done {
+		return r.flush()
+	}
+	args = append(args, "--follow", "--no-tail")
+	if cursor != "" {
+		args = append(args, "--after-cursor", cursor)
+	}
+	_, _, err := r.readJournal(ctx, args)
+	return err
+}
+
+// readJournal reads the journal entries journalctl outputs with the args,
+// and returns the cursor of the last one and the number of entries read.
+func (r *reader) readJournal(ctx context.Context, args []string) (string, uint64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, journalctl, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", 0, err
+	}
+
+	var (
+		cursor  string
+		entries uint64
+		readErr error
+	)
+	dec := json.NewDecoder(stdout)
+	for !r.done {
+		var e journalEntry
+		if err := dec.Decode(&e); err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				readErr = fmt.Errorf("failed to decode journal entry: %v", err)
+			}
+			break
+		}
+		cursor = e.Cursor
+		entries++
+		l, err := e.logLine()
+		if err != nil {
+			logrus.Debugf("skipping journal entry: %v", err)
+			continue
+		}
+		if err := r.logLine(l); err != nil {
+			readErr = err
+			break
+		}
+	}
+	if readErr != nil || r.done {
+		cancel()
+	}
+	if err := cmd.Wait(); err != nil && readErr == nil && ctx.Err() == nil {
+		readErr = fmt.Errorf("failed to read the journal: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return cursor, entries, readErr
+}
+
+// readJournalTail emits the last messages selected by the options, and
+// returns the cursor of the last journal entry read. As journalctl selects
+// the last entries, and a message may be split across several of them,
+// more entries get read until they hold enough messages.
+func (r *reader) readJournalTail(ctx context.Context, args []string) (string, error) {
+	opts := *r.opts
+	opts.Tail = 0
+	for n := r.opts.Tail; ; n *= 2 {
+		var messages []*Message
+		// Unless all the entries got read, the first message read of each
+		// stream may be missing its first lines.
+		first := make(map[Stream]*Message)
+		tail := &reader{
+			opts: &opts,
+			fn: func(m *Message) error {
+				if _, ok := first[m.Stream]; !ok {
+					first[m.Stream] = m
+				}
+				messages = append(messages, m)
+				return nil
+			},
+			pending: make(map[Stream]*Message),
+			skip:    make(map[Stream]bool),
+		}
+		cursor, entries, err := tail.readJournal(ctx, append(args, "--lines", strconv.FormatUint(n, 10)))
+		if err != nil {
+			return "", err
+		}
+		if !r.opts.Follow {
+			if err := tail.flush(); err != nil {
+				return "", err
+			}
+		}
+		if uint64(len(messages)) > r.opts.Tail {
+			messages = messages[uint64(len(messages))-r.opts.Tail:]
+		}
+		complete := entries < n
+		if !complete {
+			complete = uint64(len(messages)) == r.opts.Tail
+			for _, m := range messages {
+				if first[m.Stream] == m {
+					complete = false
+				}
+			}
+		}
+		if complete {
+			for _, m := range messages {
+				if err := r.fn(m); err != nil {
+					return "", err
+				}
+			}
+			// The rest of the messages is still to be logged.
+			r.pending = tail.pending
+			return cursor, nil
+		}
+	}
+}
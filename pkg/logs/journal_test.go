@@ -0,0 +1,98 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeJournalctl outputs the last entries of the journal, ignoring the
+// other arguments.
+const fakeJournalctl = `#!/bin/sh
+lines=
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--lines) lines="$2"; shift ;;
+	esac
+	shift
+done
+if [ -n "$lines" ]; then
+	tail -n "$lines" "$(dirname "$0")/journal.json"
+else
+	cat "$(dirname "$0")/journal.json"
+fi
+`
+
+// journalEntries returns journal entries in JSON, each logged a second after
+// the previous one, from entries of stream, partial tag and message.
+func journalEntries(entries ...string) string {
+	var journal bytes.Buffer
+	for i := 0; i+2 < len(entries); i += 3 {
+		priority := journalPriorityStdout
+		if Stream(entries[i]) == Stderr {
+			priority = journalPriorityStderr
+		}
+		partial := ""
+		if entries[i+1] == tagPartial {
+			partial = `, "CONTAINER_PARTIAL_MESSAGE": "true"`
+		}
+		fmt.Fprintf(&journal, `{"__CURSOR": "c%d", "__REALTIME_TIMESTAMP": "%d", "PRIORITY": "%s"%s, "MESSAGE": %s}`+"\n",
+			i/3, logStart.Add(time.Duration(i/3)*time.Second).UnixNano()/int64(time.Microsecond), priority, partial, entries[i+2])
+	}
+	return journal.String()
+}
+
+func TestReadJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "journalctl"), []byte(fakeJournalctl), 0700); err != nil {
+		t.Fatal(err)
+	}
+	journal := journalEntries(
+		"stdout", "F", `"one"`,
+		"stdout", "P", `"t"`,
+		"stderr", "F", `"err"`,
+		"stdout", "P", `[119]`,
+		"stdout", "F", `"o"`,
+		"stdout", "F", `"three"`,
+	)
+	if err := ioutil.WriteFile(filepath.Join(dir, "journal.json"), []byte(journal), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(path string) {
+		journalctl = path
+	}(journalctl)
+	journalctl = filepath.Join(dir, "journalctl")
+
+	for _, tc := range []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{"0 stdout one", "2 stderr err", "1 stdout two", "5 stdout three"}},
+		{Options{Until: logStart.Add(2 * time.Second)}, []string{"0 stdout one", "2 stderr err", "1 stdout two"}},
+		{Options{Tail: 1}, []string{"5 stdout three"}},
+		{Options{Tail: 2}, []string{"1 stdout two", "5 stdout three"}},
+		{Options{Tail: 10}, []string{"0 stdout one", "2 stderr err", "1 stdout two", "5 stdout three"}},
+	} {
+		opts := tc.opts
+		var messages []string
+		if err := ReadJournal(context.Background(), "ctr", &opts, func(m *Message) error {
+			messages = append(messages, fmt.Sprintf("%d %s %s", m.Timestamp.Sub(logStart)/time.Second, m.Stream, m.Content))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(messages, tc.expected) {
+			t.Errorf("expected the messages %q with %+v, got %q", tc.expected, tc.opts, messages)
+		}
+	}
+}
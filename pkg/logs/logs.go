@@ -0,0 +1,99 @@
+// Package logs reads the log files of containers in the CRI log format,
+// where each line is "<timestamp> <stream> <tag> <content>": the tag is P
+// for the partial lines a long line gets split into, and F for the full
+// line or the last part of a split one.
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Stream is the output stream of the container a line got written to.
+type Stream string
+
+const (
+	// Stdout is the standard output of the container.
+	Stdout Stream = "stdout"
+	// Stderr is the standard error of the container.
+	Stderr Stream = "stderr"
+)
+
+const (
+	tagPartial = "P"
+	tagFull    = "F"
+)
+
+// Message is a line the container wrote, rejoined from its partial lines.
+type Message struct {
+	// Timestamp is the time the first part of the line got logged.
+	Timestamp time.Time
+	Stream    Stream
+	// Content is the line, without its newline.
+	Content string
+}
+
+// logLine is a line of a log file.
+type logLine struct {
+	timestamp time.Time
+	stream    Stream
+	partial   bool
+	content   []byte
+}
+
+// parseLine parses a line of a log file, without its newline. Lines without
+// a tag, written before the tags got introduced, are full lines.
+func parseLine(line []byte) (*logLine, error) {
+	fields := bytes.SplitN(line, []byte{' '}, 3)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid log line %q", line)
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, string(fields[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp of log line %q: %v", line, err)
+	}
+	l := &logLine{
+		timestamp: timestamp,
+		stream:    Stream(fields[1]),
+		content:   fields[2],
+	}
+	if l.stream != Stdout && l.stream != Stderr {
+		return nil, fmt.Errorf("invalid stream of log line %q", line)
+	}
+	if tag := bytes.SplitN(fields[2], []byte{' '}, 2); len(tag) == 2 {
+		switch string(tag[0]) {
+		case tagPartial:
+			l.partial = true
+			l.content = tag[1]
+		case tagFull:
+			l.content = tag[1]
+		}
+	}
+	return l, nil
+}
+
+// Options selects the messages of a log file to read.
+type Options struct {
+	// Stream only selects the messages of the stream, if set.
+	Stream Stream
+	// Since only selects the messages logged at or after the time, if set.
+	Since time.Time
+	// Until only selects the messages logged at or before the time, if set.
+	Until time.Time
+	// Tail only selects the last messages, if not 0.
+	Tail uint64
+	// Follow keeps on reading the messages getting logged, across the
+	// reopenings of the log file, until the context is done.
+	Follow bool
+}
+
+func (o *Options) matchesStream(stream Stream) bool {
+	return o.Stream == "" || o.Stream == stream
+}
+
+func (o *Options) matches(m *Message) bool {
+	return o.matchesStream(m.Stream) &&
+		(o.Since.IsZero() || !m.Timestamp.Before(o.Since)) &&
+		(o.Until.IsZero() || !m.Timestamp.After(o.Until))
+}
@@ -0,0 +1,283 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var logStart = time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+
+// logLines returns log lines, each logged a second after the previous one.
+func logLines(start int, lines ...string) string {
+	var log bytes.Buffer
+	for i, line := range lines {
+		fmt.Fprintf(&log, "%s %s\n", logStart.Add(time.Duration(start+i)*time.Second).Format(time.RFC3339Nano), line)
+	}
+	return log.String()
+}
+
+func writeLog(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ctr.log")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readLogs(t *testing.T, path string, opts *Options) []string {
+	var messages []string
+	if err := ReadLogs(context.Background(), path, opts, func(m *Message) error {
+		messages = append(messages, fmt.Sprintf("%d %s %s", m.Timestamp.Sub(logStart)/time.Second, m.Stream, m.Content))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func TestParseLine(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		partial bool
+		content string
+	}{
+		{"2018-06-01T10:00:00.123456789+02:00 stdout F hello world", false, "hello world"},
+		{"2018-06-01T10:00:00Z stderr P hello ", true, "hello "},
+		{"2018-06-01T10:00:00Z stdout F ", false, ""},
+		{"2018-06-01T10:00:00Z stdout hello world", false, "hello world"},
+	} {
+		l, err := parseLine([]byte(tc.line))
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", tc.line, err)
+			continue
+		}
+		if l.partial != tc.partial || string(l.content) != tc.content {
+			t.Errorf("expected %q to be parsed as %v %q, got %v %q", tc.line, tc.partial, tc.content, l.partial, l.content)
+		}
+	}
+	for _, line := range []string{"", "2018-06-01T10:00:00Z", "yesterday stdout F hello", "2018-06-01T10:00:00Z stdin F hello"} {
+		if _, err := parseLine([]byte(line)); err == nil {
+			t.Errorf("expected %q to be invalid", line)
+		}
+	}
+}
+
+func TestReadLogs(t *testing.T) {
+	path := writeLog(t, logLines(0,
+		"stdout F one",
+		"stdout P tw",
+		"stderr F err",
+		"stdout F o",
+		"invalid",
+		"stderr P last",
+		"stdout F three",
+	))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for _, tc := range []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{"0 stdout one", "2 stderr err", "1 stdout two", "6 stdout three", "5 stderr last"}},
+		{Options{Stream: Stdout}, []string{"0 stdout one", "1 stdout two", "6 stdout three"}},
+		{Options{Stream: Stderr}, []string{"2 stderr err", "5 stderr last"}},
+		{Options{Since: logStart.Add(time.Second)}, []string{"2 stderr err", "1 stdout two", "5 stderr last", "6 stdout three"}},
+		{Options{Since: logStart.Add(2 * time.Second)}, []string{"2 stderr err", "5 stderr last", "6 stdout three"}},
+		{Options{Until: logStart.Add(2 * time.Second)}, []string{"0 stdout one", "2 stderr err", "1 stdout two"}},
+		{Options{Since: logStart.Add(time.Second), Until: logStart.Add(2 * time.Second)}, []string{"2 stderr err", "1 stdout two"}},
+		{Options{Tail: 1}, []string{"6 stdout three"}},
+		{Options{Tail: 2}, []string{"5 stderr last", "6 stdout three"}},
+		{Options{Tail: 4}, []string{"2 stderr err", "1 stdout two", "5 stderr last", "6 stdout three"}},
+		{Options{Tail: 10}, []string{"0 stdout one", "2 stderr err", "1 stdout two", "5 stderr last", "6 stdout three"}},
+		{Options{Tail: 2, Stream: Stdout}, []string{"1 stdout two", "6 stdout three"}},
+		{Options{Tail: 1, Stream: Stderr, Until: logStart.Add(4 * time.Second)}, []string{"2 stderr err"}},
+		{Options{Tail: 5, Since: logStart.Add(3 * time.Second)}, []string{"5 stderr last", "6 stdout three"}},
+	} {
+		opts := tc.opts
+		if messages := readLogs(t, path, &opts); !reflect.DeepEqual(messages, tc.expected) {
+			t.Errorf("expected the messages %q with %+v, got %q", tc.expected, tc.opts, messages)
+		}
+	}
+}
+
+func TestReadLogsTailLongFile(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10000; i++ {
+		lines = append(lines, fmt.Sprintf("stdout P %d-", i), fmt.Sprintf("stdout F %d", i))
+	}
+	path := writeLog(t, logLines(0, lines...)+"2018-06-01T10:00:00Z stdout F incomplete")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	messages := readLogs(t, path, &Options{Tail: 3})
+	expected := []string{"19994 stdout 9997-9997", "19996 stdout 9998-9998", "19998 stdout 9999-9999"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected the messages %q, got %q", expected, messages)
+	}
+	if messages := readLogs(t, path, &Options{}); len(messages) != 10000 {
+		t.Errorf("expected 10000 messages, got %d", len(messages))
+	}
+}
+
+func TestReadLogsGenerations(t *testing.T) {
+	path := writeLog(t, logLines(4, "stdout F ee", "stdout F four"))
+	defer os.RemoveAll(filepath.Dir(path))
+	if err := ioutil.WriteFile(path+".1", []byte(logLines(2, "stdout F two", "stdout P thr")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path + ".2.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(logLines(0, "stdout F zero", "stdout F one"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	// Neither a generation conmon rotated nor one being compressed.
+	for _, name := range []string{path + ".20180601-100000", path + ".2.gz.tmp"} {
+		if err := ioutil.WriteFile(name, []byte(logLines(0, "stdout F other")), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{"0 stdout zero", "1 stdout one", "2 stdout two", "3 stdout three", "5 stdout four"}},
+		{Options{Tail: 2}, []string{"3 stdout three", "5 stdout four"}},
+		{Options{Tail: 4}, []string{"1 stdout one", "2 stdout two", "3 stdout three", "5 stdout four"}},
+		{Options{Since: logStart.Add(time.Second), Until: logStart.Add(3 * time.Second)}, []string{"1 stdout one", "2 stdout two", "3 stdout three"}},
+		{Options{Until: logStart.Add(time.Second)}, []string{"0 stdout zero", "1 stdout one"}},
+	} {
+		opts := tc.opts
+		if messages := readLogs(t, path, &opts); !reflect.DeepEqual(messages, tc.expected) {
+			t.Errorf("expected the messages %q with %+v, got %q", tc.expected, tc.opts, messages)
+		}
+	}
+}
+
+func TestReadLogsFollow(t *testing.T) {
+	path := writeLog(t, logLines(0, "stdout F one", "stdout F two", "stdout P thr"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- ReadLogs(ctx, path, &Options{Tail: 1, Follow: true}, func(m *Message) error {
+			messages <- m.Content
+			return nil
+		})
+	}()
+	expect := func(expected string) {
+		select {
+		case message := <-messages:
+			if message != expected {
+				t.Fatalf("expected the message %q, got %q", expected, message)
+			}
+		case err := <-done:
+			t.Fatalf("expected the message %q, got %v", expected, err)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected the message %q", expected)
+		}
+	}
+	appendLog := func(content string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect("two")
+	appendLog(logLines(3, "stdout F ee"))
+	expect("three")
+	appendLog(logLines(4, "stdout P fo"))
+
+	// The log file gets rotated as the kubelet does, and reopened.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(logLines(5, "stdout F ur", "stderr F five"))
+	expect("four")
+	expect("five")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected following the logs to stop")
+	}
+}
+
+func TestReadLogsFollowSplitLine(t *testing.T) {
+	path := writeLog(t, logLines(0, "stdout F one"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		done <- ReadLogs(ctx, path, &Options{Follow: true}, func(m *Message) error {
+			messages <- m.Content
+			return nil
+		})
+	}()
+	expect := func(expected string) {
+		select {
+		case message := <-messages:
+			if message != expected {
+				t.Fatalf("expected the message %q, got %q", expected, message)
+			}
+		case err := <-done:
+			t.Fatalf("expected the message %q, got %v", expected, err)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected the message %q", expected)
+		}
+	}
+	appendLog := func(content string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect("one")
+	// The line gets written in two parts, the first of which is read
+	// before the second one is written.
+	line := logLines(1, "stdout F hello")
+	appendLog(line[:len(line)-3])
+	time.Sleep(100 * time.Millisecond)
+	appendLog(line[len(line)-3:])
+	expect("hello")
+	appendLog(logLines(2, "stdout F world"))
+	expect("world")
+}
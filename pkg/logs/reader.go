@@ -0,0 +1,488 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// blockSize is the size of the blocks log files are read backwards by.
+	blockSize = 32 * 1024
+
+	// followPollInterval is how often a followed log file gets checked for
+	// new lines, in case its changes aren't notified.
+	followPollInterval = time.Second
+)
+
+// ReadLogs calls fn with the messages of the log file at path selected by
+// opts, in the order they got logged, until fn fails. The generations of
+// the log file conmon rotated get read first. Messages are read from the
+// end of the files when only the last or the recent ones are selected.
+func ReadLogs(ctx context.Context, path string, opts *Options, fn func(*Message) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	gens, err := generations(path)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r := &reader{
+		opts:    opts,
+		fn:      fn,
+		pending: make(map[Stream]*Message),
+		skip:    make(map[Stream]bool),
+		file:    f,
+	}
+	defer func() {
+		r.file.Close()
+	}()
+
+	var offset int64
+	if opts.Tail > 0 || !opts.Since.IsZero() {
+		if offset, err = r.readBackward(gens); err != nil {
+			return err
+		}
+		if !opts.Follow {
+			return nil
+		}
+	} else {
+		for i := len(gens) - 1; i >= 0 && !r.done; i-- {
+			if err := r.readGeneration(gens[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if offset, err = r.readForward(offset); err != nil {
+		return err
+	}
+	if !opts.Follow {
+		return r.flush()
+	}
+	return r.follow(ctx, path, offset)
+}
+
+// reader reads the lines of a log file, rejoining the messages they hold.
+type reader struct {
+	opts *Options
+	fn   func(*Message) error
+	// pending holds the messages of which only partial lines got read.
+	pending map[Stream]*Message
+	// skip holds the streams of which the next lines end messages already
+	// done with.
+	skip map[Stream]bool
+	// done is set once no message logged afterwards can be selected.
+	done bool
+	// file is the file being read, and partial the incomplete line read at
+	// its end.
+	file    *os.File
+	partial []byte
+}
+
+// line handles the next line of the log file.
+func (r *reader) line(data []byte) error {
+	l, err := parseLine(data)
+	if err != nil {
+		logrus.Debugf("skipping log line: %v", err)
+		return nil
+	}
+	return r.logLine(l)
+}
+
+// logLine handles the next parsed line of the log.
+func (r *reader) logLine(l *logLine) error {
+	if !r.opts.matchesStream(l.stream) {
+		return nil
+	}
+	if !r.opts.Until.IsZero() && l.timestamp.After(r.opts.Until) && len(r.pending) == 0 {
+		r.done = true
+		return nil
+	}
+	if r.skip[l.stream] {
+		if !l.partial {
+			delete(r.skip, l.stream)
+		}
+		return nil
+	}
+	m, ok := r.pending[l.stream]
+	if !ok {
+		m = &Message{Timestamp: l.timestamp, Stream: l.stream}
+	}
+	m.Content += string(l.content)
+	if l.partial {
+		r.pending[l.stream] = m
+		return nil
+	}
+	delete(r.pending, l.stream)
+	return r.emit(m)
+}
+
+func (r *reader) emit(m *Message) error {
+	if !r.opts.matches(m) {
+		return nil
+	}
+	return r.fn(m)
+}
+
+// flush emits the messages of which only partial lines got logged, once
+// there is nothing more to read.
+func (r *reader) flush() error {
+	messages := make([]*Message, 0, len(r.pending))
+	for _, m := range r.pending {
+		messages = append(messages, m)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+	r.pending = make(map[Stream]*Message)
+	for _, m := range messages {
+		if err := r.emit(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readForward reads the complete lines of the file from the offset, and
+// returns the offset it read up to, past the incomplete line it keeps in
+// partial.
+func (r *reader) readForward(offset int64) (int64, error) {
+	if _, err := r.file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	br := bufio.NewReaderSize(r.file, blockSize)
+	for !r.done {
+		data, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// The rest of the line isn't written yet.
+			r.partial = append(r.partial, data...)
+			return offset + int64(len(data)), nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(len(data))
+		if len(r.partial) > 0 {
+			data = append(r.partial, data...)
+			r.partial = nil
+		}
+		if err := r.line(data[:len(data)-1]); err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
+
+// tailEntry is a message read backwards, from its last line.
+type tailEntry struct {
+	// end is the offset of the last line of the message, which orders the
+	// messages as they are when reading forward. The offsets of the lines
+	// of the previous generations of the log file are negative.
+	end int64
+	// trailing is set if the message has no full line yet.
+	trailing bool
+	lines    []*logLine
+}
+
+func (e *tailEntry) message() *Message {
+	m := &Message{
+		Timestamp: e.lines[len(e.lines)-1].timestamp,
+		Stream:    e.lines[0].stream,
+	}
+	var content bytes.Buffer
+	for i := len(e.lines) - 1; i >= 0; i-- {
+		content.Write(e.lines[i].content)
+	}
+	m.Content = content.String()
+	return m
+}
+
+// readBackward emits the messages selected by the tail and since options,
+// reading the file from its end and then its previous generations, and
+// returns the offset the file has been read up to.
+func (r *reader) readBackward(gens []string) (int64, error) {
+	s, err := newBackwardScanner(r.file)
+	if err != nil {
+		return 0, err
+	}
+	end := s.end
+	// base is the offset of the start of the file being read, relative to
+	// the start of the log file.
+	var base int64
+	var (
+		selected []*tailEntry
+		open     = make(map[Stream]*tailEntry)
+	)
+	complete := func(e *tailEntry) {
+		m := e.message()
+		if e.trailing && r.opts.Follow {
+			// The rest of the message is still to be logged.
+			r.pending[m.Stream] = m
+			return
+		}
+		if r.opts.matches(m) {
+			selected = append(selected, e)
+		}
+	}
+	// enough returns whether the last messages are all selected: none of
+	// the messages still being read back ended after them.
+	enough := func() bool {
+		if r.opts.Tail == 0 || uint64(len(selected)) < r.opts.Tail {
+			return false
+		}
+		sort.Slice(selected, func(i, j int) bool {
+			return selected[i].end > selected[j].end
+		})
+		selected = selected[:r.opts.Tail]
+		for _, e := range open {
+			if e.end > selected[len(selected)-1].end {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		data, offset, err := s.prev()
+		if err == io.EOF {
+			if len(gens) == 0 {
+				break
+			}
+			f, err := openGeneration(gens[0])
+			gens = gens[1:]
+			if os.IsNotExist(err) {
+				// It got rotated away meanwhile.
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			defer f.Close()
+			if s, err = newBackwardScanner(f); err != nil {
+				return 0, err
+			}
+			base -= s.end
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += base
+		l, err := parseLine(data)
+		if err != nil {
+			logrus.Debugf("skipping log line: %v", err)
+			continue
+		}
+		if !r.opts.matchesStream(l.stream) {
+			continue
+		}
+		e, ok := open[l.stream]
+		if !r.opts.Since.IsZero() && l.timestamp.Before(r.opts.Since) {
+			// So are the lines before: they only tell whether the
+			// messages still being read back started before too.
+			if ok {
+				if !l.partial {
+					complete(e)
+				} else if e.trailing && r.opts.Follow {
+					r.skip[l.stream] = true
+				}
+				delete(open, l.stream)
+			}
+			if len(open) == 0 {
+				break
+			}
+			continue
+		}
+		if !l.partial {
+			if ok {
+				complete(e)
+				delete(open, l.stream)
+				if enough() {
+					open = nil
+					break
+				}
+			}
+			e = &tailEntry{end: offset}
+			open[l.stream] = e
+		} else if !ok {
+			e = &tailEntry{end: offset, trailing: true}
+			open[l.stream] = e
+		}
+		e.lines = append(e.lines, l)
+	}
+	// The first lines of the file start the messages still being read.
+	for _, e := range open {
+		complete(e)
+	}
+	enough()
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].end < selected[j].end
+	})
+	for _, e := range selected {
+		if err := r.fn(e.message()); err != nil {
+			return 0, err
+		}
+	}
+	return end, nil
+}
+
+// backwardScanner reads the complete lines of a file from its end.
+type backwardScanner struct {
+	f *os.File
+	// end is the offset the last complete line of the file ends at.
+	end int64
+	// buf holds the part of the file from start which lines are still to
+	// be read, without the newline of the last one.
+	start int64
+	buf   []byte
+	bof   bool
+}
+
+func newBackwardScanner(f *os.File) (*backwardScanner, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	s := &backwardScanner{f: f, start: fi.Size()}
+	for {
+		if i := bytes.LastIndexByte(s.buf, '\n'); i >= 0 {
+			s.buf = s.buf[:i]
+			s.end = s.start + int64(i) + 1
+			return s, nil
+		}
+		if s.start == 0 {
+			// There is no complete line.
+			s.buf = nil
+			s.bof = true
+			return s, nil
+		}
+		if err := s.readBlock(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *backwardScanner) readBlock() error {
+	n := int64(blockSize)
+	if n > s.start {
+		n = s.start
+	}
+	block := make([]byte, n+int64(len(s.buf)))
+	if _, err := s.f.ReadAt(block[:n], s.start-n); err != nil {
+		return err
+	}
+	copy(block[n:], s.buf)
+	s.buf = block
+	s.start -= n
+	return nil
+}
+
+// prev returns the line before the last one returned, without its newline,
+// and its offset, or io.EOF once the first line got returned.
+func (s *backwardScanner) prev() ([]byte, int64, error) {
+	for !s.bof {
+		if i := bytes.LastIndexByte(s.buf, '\n'); i >= 0 {
+			line := s.buf[i+1:]
+			s.buf = s.buf[:i]
+			return line, s.start + int64(i) + 1, nil
+		}
+		if s.start == 0 {
+			line := s.buf
+			s.buf = nil
+			s.bof = true
+			return line, 0, nil
+		}
+		if err := s.readBlock(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return nil, 0, io.EOF
+}
+
+// follow reads the lines getting logged to the file from the offset, until
+// the context is done. The file at path gets read from its start once
+// it's replaced, as when the log file got reopened, or truncated.
+func (r *reader) follow(ctx context.Context, path string, offset int64) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+
+	for !r.done {
+		var err error
+		if offset, err = r.readForward(offset); err != nil {
+			return err
+		}
+		if r.done {
+			break
+		}
+		f, err := r.reopened(path, offset)
+		if err != nil {
+			return err
+		}
+		if f != nil {
+			// The last lines may have been written to the previous
+			// file while it got replaced.
+			if _, err := r.readForward(offset); err != nil {
+				f.Close()
+				return err
+			}
+			r.file.Close()
+			r.file = f
+			r.partial = nil
+			offset = 0
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-watcher.Events:
+		case err := <-watcher.Errors:
+			logrus.Debugf("failed to watch log file %s: %v", path, err)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// reopened opens the file at path if it's another file than the one being
+// read, or if the latter got truncated before the offset.
+func (r *reader) reopened(path string, offset int64) (*os.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The log file is being reopened.
+			return nil, nil
+		}
+		return nil, err
+	}
+	current, err := r.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if os.SameFile(fi, current) && fi.Size() >= offset {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return f, err
+}
@@ -0,0 +1,65 @@
+// Package oom reads the OOM kill counters the kernel keeps for memory
+// cgroups, on both the legacy (v1) and the unified (v2) cgroup hierarchies.
+package oom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNoOOMKillCounter is returned when the kernel doesn't expose the
+// oom_kill counter of a memory cgroup (it was added in Linux 4.13).
+var ErrNoOOMKillCounter = errors.New("oom_kill counter not available")
+
+// parseOOMKillCount returns the value of the oom_kill key of a flat keyed
+// cgroup file, such as memory.oom_control (v1) or memory.events (v2).
+func parseOOMKillCount(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "oom_kill" {
+			continue
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid oom_kill counter %q: %v", fields[1], err)
+		}
+		return count, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, ErrNoOOMKillCounter
+}
+
+// parseMemoryCgroup returns the path of the memory cgroup, relative to the
+// root of its hierarchy, from the content of a /proc/<pid>/cgroup file. On
+// the unified hierarchy, that's the path of the only cgroup listed.
+func parseMemoryCgroup(r io.Reader, unified bool) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if unified {
+			if parts[0] == "0" && parts[1] == "" {
+				return parts[2], nil
+			}
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			if controller == "memory" {
+				return parts[2], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("memory cgroup not found")
+}
@@ -0,0 +1,49 @@
+// +build linux
+
+package oom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubernetes-incubator/cri-o/pkg/cgroupv2"
+)
+
+// MemoryCgroupPath returns the absolute path of the memory cgroup of the
+// process.
+func MemoryCgroupPath(pid int) (string, error) {
+	unified, err := cgroupv2.IsUnifiedMode()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	path, err := parseMemoryCgroup(f, unified)
+	if err != nil {
+		return "", fmt.Errorf("failed to find memory cgroup of process %d: %v", pid, err)
+	}
+	if unified {
+		return filepath.Join(cgroupv2.Root, path), nil
+	}
+	return filepath.Join(cgroupv2.Root, "memory", path), nil
+}
+
+// ReadOOMKillCount returns the number of processes of the memory cgroup
+// killed by the OOM killer since the cgroup got created.
+func ReadOOMKillCount(cgroupPath string) (uint64, error) {
+	// memory.events only exists on the unified hierarchy.
+	f, err := os.Open(filepath.Join(cgroupPath, "memory.events"))
+	if os.IsNotExist(err) {
+		f, err = os.Open(filepath.Join(cgroupPath, "memory.oom_control"))
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parseOOMKillCount(f)
+}
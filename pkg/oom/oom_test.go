@@ -0,0 +1,57 @@
+package oom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOOMKillCount(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		count   uint64
+		err     bool
+	}{
+		// cgroup v1 memory.oom_control
+		{"oom_kill_disable 0\nunder_oom 0\noom_kill 3\n", 3, false},
+		// cgroup v2 memory.events
+		{"low 0\nhigh 0\nmax 12\noom 2\noom_kill 2\n", 2, false},
+		// kernels older than 4.13
+		{"oom_kill_disable 0\nunder_oom 0\n", 0, true},
+		{"oom_kill abc\n", 0, true},
+	} {
+		count, err := parseOOMKillCount(strings.NewReader(tc.content))
+		if (err != nil) != tc.err {
+			t.Errorf("unexpected error for %q: %v", tc.content, err)
+		}
+		if count != tc.count {
+			t.Errorf("expected %d OOM kills for %q, got %d", tc.count, tc.content, count)
+		}
+	}
+}
+
+func TestParseMemoryCgroup(t *testing.T) {
+	v1 := "12:pids:/kubepods/pod1/crio-abc\n" +
+		"4:cpu,cpuacct:/kubepods/pod1/crio-abc\n" +
+		"3:memory:/kubepods/pod1/crio-abc\n" +
+		"0::/init.scope\n"
+	path, err := parseMemoryCgroup(strings.NewReader(v1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/kubepods/pod1/crio-abc" {
+		t.Fatalf("unexpected v1 memory cgroup %q", path)
+	}
+
+	v2 := "0::/kubepods.slice/crio-abc.scope\n"
+	path, err = parseMemoryCgroup(strings.NewReader(v2), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/kubepods.slice/crio-abc.scope" {
+		t.Fatalf("unexpected v2 memory cgroup %q", path)
+	}
+
+	if _, err := parseMemoryCgroup(strings.NewReader("0::/init.scope\n"), false); err == nil {
+		t.Fatal("expected an error without a memory controller")
+	}
+}
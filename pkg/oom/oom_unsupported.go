@@ -0,0 +1,21 @@
+// +build !linux
+
+package oom
+
+import (
+	"errors"
+)
+
+var errUnsupported = errors.New("OOM kill counters are not supported on this platform")
+
+// MemoryCgroupPath returns the absolute path of the memory cgroup of the
+// process.
+func MemoryCgroupPath(pid int) (string, error) {
+	return "", errUnsupported
+}
+
+// ReadOOMKillCount returns the number of processes of the memory cgroup
+// killed by the OOM killer since the cgroup got created.
+func ReadOOMKillCount(cgroupPath string) (uint64, error) {
+	return 0, errUnsupported
+}
@@ -0,0 +1,92 @@
+package seccomp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AuditModeLog is the seccomp audit mode logging the syscalls which the
+// profile of the container doesn't allow, instead of denying them.
+const AuditModeLog = "log"
+
+// AuditModeNotify is the seccomp audit mode handing the syscalls which the
+// profile of the container doesn't allow to a user space listener.
+const AuditModeNotify = "notify"
+
+// auditArches maps the AUDIT_ARCH_* values of the audit records to the
+// architecture names known to libseccomp.
+var auditArches = map[uint32]string{
+	0x40000003: "x86",
+	0xc000003e: "amd64",
+	0x40000028: "arm",
+	0xc00000b7: "arm64",
+	0x80000015: "ppc64",
+	0xc0000015: "ppc64le",
+	0x80000016: "s390x",
+}
+
+// AuditRecord is a syscall logged by the kernel because of a SCMP_ACT_LOG
+// seccomp action.
+type AuditRecord struct {
+	Pid     int
+	Arch    uint32
+	Syscall int
+}
+
+// ParseAuditRecord parses a seccomp record, as found in the kernel log or in
+// the log of the audit daemon. It returns false for any other line.
+func ParseAuditRecord(line string) (AuditRecord, bool) {
+	if !strings.Contains(line, "type=1326") && !strings.Contains(line, "type=SECCOMP") {
+		return AuditRecord{}, false
+	}
+	var (
+		record                 AuditRecord
+		hasPid, hasArch, hasNr bool
+	)
+	for _, field := range strings.Fields(line) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "pid":
+			pid, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return AuditRecord{}, false
+			}
+			record.Pid, hasPid = pid, true
+		case "arch":
+			arch, err := strconv.ParseUint(kv[1], 16, 32)
+			if err != nil {
+				return AuditRecord{}, false
+			}
+			record.Arch, hasArch = uint32(arch), true
+		case "syscall":
+			nr, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return AuditRecord{}, false
+			}
+			record.Syscall, hasNr = nr, true
+		}
+	}
+	return record, hasPid && hasArch && hasNr
+}
+
+// AuditProfile returns the profile allowing the syscalls logged during the
+// audit of a container on top of the ones its profile already allows.
+func AuditProfile(base *Seccomp, syscalls []string) *Seccomp {
+	profile := *base
+	profile.Syscalls = append([]*Syscall{}, base.Syscalls...)
+	if len(syscalls) > 0 {
+		names := append([]string{}, syscalls...)
+		sort.Strings(names)
+		profile.Syscalls = append(profile.Syscalls, &Syscall{
+			Names:   names,
+			Action:  ActAllow,
+			Args:    []*Arg{},
+			Comment: "syscalls logged by the seccomp audit",
+		})
+	}
+	return &profile
+}
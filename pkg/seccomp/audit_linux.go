@@ -0,0 +1,198 @@
+// +build linux
+
+package seccomp
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// auditLogPollInterval is how often the audit daemon log is read again once
+// all its records got handled. The kernel log device wakes up its reader as
+// soon as a record gets written.
+const auditLogPollInterval = 500 * time.Millisecond
+
+// AuditLog reads the seccomp records written to the kernel log device or the
+// audit daemon log.
+type AuditLog struct {
+	path string
+	fd   int
+	// wake is the pipe waking up Follow when a flush is requested.
+	wake    [2]int
+	flushes chan chan struct{}
+	// lock protects closed, set once Follow returned and closed the log.
+	lock   sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+// OpenAuditLog opens the kernel log device or the audit daemon log at path,
+// to read the records written from now on.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	fd, err := openAuditLog(path, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	l := &AuditLog{
+		path:    path,
+		fd:      fd,
+		flushes: make(chan chan struct{}, 16),
+		done:    make(chan struct{}),
+	}
+	if err := unix.Pipe2(l.wake[:], unix.O_NONBLOCK|unix.O_CLOEXEC); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return l, nil
+}
+
+// Follow calls handle with the seccomp records of the log until stop gets
+// closed, and closes the log. The audit daemon log is opened again when it
+// gets rotated.
+func (l *AuditLog) Follow(stop <-chan struct{}, handle func(AuditRecord)) error {
+	defer func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.closed = true
+		close(l.done)
+		unix.Close(l.fd)
+		unix.Close(l.wake[0])
+		unix.Close(l.wake[1])
+	}()
+
+	// Reads from /dev/kmsg return one record at a time, and fail if the
+	// buffer can't hold it.
+	buf := make([]byte, 8192)
+	var pending []byte
+	for {
+		// The flushes requested so far are done once all the records
+		// written until now got handled.
+		var flushes []chan struct{}
+	requests:
+		for {
+			select {
+			case flushed := <-l.flushes:
+				flushes = append(flushes, flushed)
+			default:
+				break requests
+			}
+		}
+
+		for {
+			n, err := unix.Read(l.fd, buf)
+			if n > 0 {
+				pending = append(pending, buf[:n]...)
+				for {
+					i := bytes.IndexByte(pending, '\n')
+					if i < 0 {
+						break
+					}
+					if record, ok := ParseAuditRecord(string(pending[:i])); ok {
+						handle(record)
+					}
+					pending = pending[i+1:]
+				}
+				continue
+			}
+			// EPIPE means records of /dev/kmsg got overwritten
+			// before being read, the next read returns the oldest
+			// one still around.
+			if err == unix.EINTR || err == unix.EPIPE {
+				continue
+			}
+			if err != nil && err != unix.EAGAIN {
+				return err
+			}
+			break
+		}
+		for _, flushed := range flushes {
+			close(flushed)
+		}
+
+		if rotated(l.fd, l.path) {
+			fd, err := openAuditLog(l.path, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			unix.Close(l.fd)
+			l.fd, pending = fd, nil
+			continue
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if err := l.wait(); err != nil {
+			return err
+		}
+	}
+}
+
+// wait waits for a record to be written to the log, for a flush to be
+// requested, or for the poll interval to pass.
+func (l *AuditLog) wait() error {
+	fds := []unix.PollFd{{Fd: int32(l.wake[0]), Events: unix.POLLIN}}
+	var st unix.Stat_t
+	if err := unix.Fstat(l.fd, &st); err == nil && st.Mode&unix.S_IFMT == unix.S_IFCHR {
+		// Regular files are always ready to be read.
+		fds = append(fds, unix.PollFd{Fd: int32(l.fd), Events: unix.POLLIN})
+	}
+	if _, err := unix.Poll(fds, int(auditLogPollInterval/time.Millisecond)); err != nil && err != unix.EINTR {
+		return err
+	}
+	buf := make([]byte, 64)
+	for {
+		if n, _ := unix.Read(l.wake[0], buf); n <= 0 {
+			return nil
+		}
+	}
+}
+
+// Flush waits for the records written to the log so far to be handled. It
+// returns right away if the log isn't being followed anymore.
+func (l *AuditLog) Flush() {
+	flushed := make(chan struct{})
+	select {
+	case l.flushes <- flushed:
+	case <-l.done:
+		return
+	}
+	l.lock.Lock()
+	if !l.closed {
+		unix.Write(l.wake[1], []byte{0})
+	}
+	l.lock.Unlock()
+	select {
+	case <-flushed:
+	case <-l.done:
+	}
+}
+
+func openAuditLog(path string, whence int) (int, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, err
+	}
+	if _, err := unix.Seek(fd, 0, whence); err != nil {
+		unix.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+// rotated returns whether path is no longer the file open as fd.
+func rotated(fd int, path string) bool {
+	var opened, current unix.Stat_t
+	if err := unix.Fstat(fd, &opened); err != nil {
+		return false
+	}
+	if err := unix.Stat(path, &current); err != nil {
+		return false
+	}
+	return opened.Dev != current.Dev || opened.Ino != current.Ino
+}
@@ -0,0 +1,151 @@
+package seccomp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseAuditRecord(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected *AuditRecord
+	}{
+		{
+			`6,1839,2461843417,-;audit: type=1326 audit(1539851711.103:92): auid=4294967295 uid=0 gid=0 ses=4294967295 subj=system_u:system_r:container_t:s0 pid=12174 comm="chmod" exe="/bin/busybox" sig=0 arch=c000003e syscall=90 compat=0 ip=0x4a8b3c code=0x7ffc0000`,
+			&AuditRecord{Pid: 12174, Arch: 0xc000003e, Syscall: 90},
+		},
+		{
+			`type=SECCOMP msg=audit(1539851711.103:92): auid=4294967295 uid=0 gid=0 ses=4294967295 pid=12174 comm="chmod" exe="/bin/busybox" sig=0 arch=c00000b7 syscall=53 compat=0 ip=0x4a8b3c code=0x7ffc0000`,
+			&AuditRecord{Pid: 12174, Arch: 0xc00000b7, Syscall: 53},
+		},
+		{
+			`type=SYSCALL msg=audit(1539851711.103:93): arch=c000003e syscall=59 success=yes exit=0 pid=12175`,
+			nil,
+		},
+		{
+			`6,1840,2461843417,-;audit: type=1326 audit(1539851711.103:92): pid=12174 arch=c000003e`,
+			nil,
+		},
+		{
+			`6,1841,2461843418,-;IPv6: ADDRCONF(NETDEV_UP): eth0: link is not ready`,
+			nil,
+		},
+	} {
+		record, ok := ParseAuditRecord(tc.line)
+		if tc.expected == nil {
+			if ok {
+				t.Errorf("expected %q not to be a seccomp record, got %+v", tc.line, record)
+			}
+			continue
+		}
+		if !ok || record != *tc.expected {
+			t.Errorf("expected %q to be parsed as %+v, got %+v", tc.line, *tc.expected, record)
+		}
+	}
+}
+
+func TestAuditProfile(t *testing.T) {
+	base := &Seccomp{
+		DefaultAction: ActErrno,
+		Syscalls: []*Syscall{
+			{Names: []string{"read", "write"}, Action: ActAllow},
+		},
+	}
+	profile := AuditProfile(base, []string{"fchmodat", "chmod"})
+	if len(base.Syscalls) != 1 {
+		t.Fatal("expected the base profile to be left alone")
+	}
+	if profile.DefaultAction != ActErrno || len(profile.Syscalls) != 2 {
+		t.Fatalf("expected the logged syscalls to be added to the base profile, got %+v", profile)
+	}
+	added := profile.Syscalls[1]
+	if added.Action != ActAllow || !reflect.DeepEqual(added.Names, []string{"chmod", "fchmodat"}) {
+		t.Errorf("expected the logged syscalls to be allowed, got %+v", added)
+	}
+	if err := Validate(profile); err != nil {
+		t.Errorf("expected the audit profile to be valid, got %v", err)
+	}
+
+	if profile := AuditProfile(base, nil); len(profile.Syscalls) != 1 {
+		t.Errorf("expected no rule to be added without logged syscalls, got %+v", profile.Syscalls)
+	}
+}
+
+func TestFollowAuditLog(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("seccomp audit is only supported on linux")
+	}
+	dir, err := ioutil.TempDir("", "seccomp-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	appendLine := func(line string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendLine("type=SECCOMP msg=audit(1539851711.103:91): pid=1 arch=c000003e syscall=1")
+
+	log, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	records := make(chan AuditRecord, 10)
+	done := make(chan error)
+	go func() {
+		done <- log.Follow(stop, func(record AuditRecord) {
+			records <- record
+		})
+	}()
+
+	appendLine("type=SYSCALL msg=audit(1539851711.103:92): arch=c000003e syscall=59 pid=2")
+	appendLine("type=SECCOMP msg=audit(1539851711.103:93): pid=2 arch=c000003e syscall=90")
+	expectRecord := func(expected AuditRecord) {
+		select {
+		case record := <-records:
+			if record != expected {
+				t.Errorf("expected record %+v, got %+v", expected, record)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected record %+v", expected)
+		}
+	}
+	expectRecord(AuditRecord{Pid: 2, Arch: 0xc000003e, Syscall: 90})
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLine("type=SECCOMP msg=audit(1539851711.103:94): pid=3 arch=c000003e syscall=91")
+	expectRecord(AuditRecord{Pid: 3, Arch: 0xc000003e, Syscall: 91})
+
+	// The records written before a flush are handled once it's done.
+	appendLine("type=SECCOMP msg=audit(1539851711.103:95): pid=4 arch=c000003e syscall=92")
+	log.Flush()
+	if len(records) != 1 {
+		t.Fatalf("expected the record to be handled by the flush, got %d records", len(records))
+	}
+	expectRecord(AuditRecord{Pid: 4, Arch: 0xc000003e, Syscall: 92})
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// Flushing the log which isn't followed anymore doesn't block.
+	log.Flush()
+	if len(records) != 0 {
+		t.Errorf("expected no other record, got %+v", <-records)
+	}
+}
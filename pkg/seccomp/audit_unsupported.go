@@ -0,0 +1,25 @@
+// +build !linux
+
+package seccomp
+
+import "errors"
+
+// AuditLog reads the seccomp records written to the kernel log device or the
+// audit daemon log.
+type AuditLog struct{}
+
+// OpenAuditLog opens the kernel log device or the audit daemon log at path,
+// to read the records written from now on.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	return nil, errors.New("seccomp audit is not supported on this platform")
+}
+
+// Follow calls handle with the seccomp records of the log until stop gets
+// closed, and closes the log.
+func (l *AuditLog) Follow(stop <-chan struct{}, handle func(AuditRecord)) error {
+	return nil
+}
+
+// Flush waits for the records written to the log so far to be handled.
+func (l *AuditLog) Flush() {
+}
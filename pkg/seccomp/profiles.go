@@ -0,0 +1,178 @@
+package seccomp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// maxSyscallArgs is the number of arguments a syscall takes at most.
+const maxSyscallArgs = 6
+
+var validActions = map[Action]bool{
+	ActKill:  true,
+	ActTrap:  true,
+	ActErrno: true,
+	ActTrace: true,
+	ActAllow: true,
+	ActLog:   true,
+}
+
+var validOperators = map[Operator]bool{
+	OpNotEqual:     true,
+	OpLessThan:     true,
+	OpLessEqual:    true,
+	OpEqualTo:      true,
+	OpGreaterEqual: true,
+	OpGreaterThan:  true,
+	OpMaskedEqual:  true,
+}
+
+var validArches = map[Arch]bool{
+	ArchX86:         true,
+	ArchX86_64:      true,
+	ArchX32:         true,
+	ArchARM:         true,
+	ArchAARCH64:     true,
+	ArchMIPS:        true,
+	ArchMIPS64:      true,
+	ArchMIPS64N32:   true,
+	ArchMIPSEL:      true,
+	ArchMIPSEL64:    true,
+	ArchMIPSEL64N32: true,
+	ArchPPC:         true,
+	ArchPPC64:       true,
+	ArchPPC64LE:     true,
+	ArchS390:        true,
+	ArchS390X:       true,
+}
+
+// Validate checks that the profile only uses known actions, architectures
+// and operators, and that its syscall rules are well formed.
+func Validate(config *Seccomp) error {
+	if !validActions[config.DefaultAction] {
+		return fmt.Errorf("invalid default action %q", config.DefaultAction)
+	}
+	if len(config.Architectures) != 0 && len(config.ArchMap) != 0 {
+		return fmt.Errorf("'architectures' and 'archMap' were specified in the seccomp profile, use either 'architectures' or 'archMap'")
+	}
+	for _, arch := range config.Architectures {
+		if !validArches[arch] {
+			return fmt.Errorf("invalid architecture %q", arch)
+		}
+	}
+	for _, a := range config.ArchMap {
+		if !validArches[a.Arch] {
+			return fmt.Errorf("invalid architecture %q", a.Arch)
+		}
+		for _, arch := range a.SubArches {
+			if !validArches[arch] {
+				return fmt.Errorf("invalid sub-architecture %q of %q", arch, a.Arch)
+			}
+		}
+	}
+	for i, call := range config.Syscalls {
+		if call == nil {
+			return fmt.Errorf("syscall rule %d is empty", i)
+		}
+		if call.Name != "" && len(call.Names) != 0 {
+			return fmt.Errorf("'name' and 'names' were specified in syscall rule %d, use either 'name' or 'names'", i)
+		}
+		if call.Name == "" && len(call.Names) == 0 {
+			return fmt.Errorf("syscall rule %d doesn't name any syscall", i)
+		}
+		if !validActions[call.Action] {
+			return fmt.Errorf("invalid action %q in syscall rule %d", call.Action, i)
+		}
+		for _, arg := range call.Args {
+			if arg == nil {
+				return fmt.Errorf("empty argument in syscall rule %d", i)
+			}
+			if arg.Index >= maxSyscallArgs {
+				return fmt.Errorf("invalid argument index %d in syscall rule %d", arg.Index, i)
+			}
+			if !validOperators[arg.Op] {
+				return fmt.Errorf("invalid operator %q in syscall rule %d", arg.Op, i)
+			}
+		}
+	}
+	return nil
+}
+
+// ProfileCache holds the profiles loaded from disk, indexed by the digest of
+// their content so that a profile used by many containers only gets decoded
+// and validated once.
+type ProfileCache struct {
+	lock     sync.Mutex
+	profiles map[digest.Digest]*Seccomp
+}
+
+// NewProfileCache returns an empty profile cache.
+func NewProfileCache() *ProfileCache {
+	return &ProfileCache{
+		profiles: make(map[digest.Digest]*Seccomp),
+	}
+}
+
+// Load reads the profile at path, and returns it decoded and validated.
+func (c *ProfileCache) Load(path string) (*Seccomp, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := c.LoadBytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seccomp profile %s: %v", path, err)
+	}
+	return profile, nil
+}
+
+// LoadBytes decodes and validates the profile, unless a profile with the
+// same content is cached already.
+func (c *ProfileCache) LoadBytes(body []byte) (*Seccomp, error) {
+	dgst := digest.FromBytes(body)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if profile, ok := c.profiles[dgst]; ok {
+		return profile, nil
+	}
+	profile := &Seccomp{}
+	if err := json.Unmarshal(body, profile); err != nil {
+		return nil, fmt.Errorf("decoding seccomp profile failed: %v", err)
+	}
+	if err := Validate(profile); err != nil {
+		return nil, err
+	}
+	c.profiles[dgst] = profile
+	return profile, nil
+}
+
+// LocalhostProfilePath returns the path of the file of a localhost/<path>
+// profile, given <path>. Relative paths are looked up in root. When root is
+// set, the profile must be inside it.
+func LocalhostProfilePath(root, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("no path given for the localhost seccomp profile")
+	}
+	path := filepath.FromSlash(name)
+	if root == "" {
+		if !filepath.IsAbs(path) {
+			return "", fmt.Errorf("localhost seccomp profile %q must be an absolute path when no seccomp profile root is set", name)
+		}
+		return filepath.Clean(path), nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("localhost seccomp profile %q is outside of the seccomp profile root %s", name, root)
+	}
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package seccomp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		profile string
+		err     string
+	}{
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["chmod"], "action": "SCMP_ACT_ALLOW"}]}`, ""},
+		{`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "personality", "action": "SCMP_ACT_ERRNO", "args": [{"index": 0, "value": 8, "op": "SCMP_CMP_EQ"}]}]}`, ""},
+		{`{"syscalls": []}`, "invalid default action"},
+		{`{"defaultAction": "SCMP_ACT_NOPE", "syscalls": []}`, "invalid default action"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["SCMP_ARCH_X86_64"], "archMap": [{"architecture": "SCMP_ARCH_X86_64"}], "syscalls": []}`, "use either 'architectures' or 'archMap'"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["x86_64"], "syscalls": []}`, "invalid architecture"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "chmod", "names": ["chown"], "action": "SCMP_ACT_ALLOW"}]}`, "use either 'name' or 'names'"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"action": "SCMP_ACT_ALLOW"}]}`, "doesn't name any syscall"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["chmod"], "action": "allow"}]}`, "invalid action"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["chmod"], "action": "SCMP_ACT_ALLOW", "args": [{"index": 6, "op": "SCMP_CMP_EQ"}]}]}`, "invalid argument index"},
+		{`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["chmod"], "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "op": "=="}]}]}`, "invalid operator"},
+	} {
+		_, err := NewProfileCache().LoadBytes([]byte(tc.profile))
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("expected %s to be valid, got %v", tc.profile, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected %s to fail with %q, got %v", tc.profile, tc.err, err)
+		}
+	}
+}
+
+func TestProfileCacheLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	body, err := ioutil.ReadFile(filepath.Join("..", "..", "seccomp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	for _, path := range []string{first, second} {
+		if err := ioutil.WriteFile(path, body, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := NewProfileCache()
+	p1, err := cache.Load(first)
+	if err != nil {
+		t.Fatalf("expected the default profile to be valid, got %v", err)
+	}
+	p2, err := cache.Load(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1 != p2 {
+		t.Error("expected profiles with the same content to be cached once")
+	}
+
+	if err := ioutil.WriteFile(second, []byte(`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p2, err = cache.Load(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2 == p1 || p2.DefaultAction != ActAllow {
+		t.Error("expected a modified profile to be loaded again")
+	}
+
+	if _, err := cache.Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected loading a missing profile to fail")
+	}
+}
+
+func TestLocalhostProfilePath(t *testing.T) {
+	for _, tc := range []struct {
+		root, name, path string
+		fails            bool
+	}{
+		{"", "/etc/crio/profile.json", "/etc/crio/profile.json", false},
+		{"", "profile.json", "", true},
+		{"", "", "", true},
+		{"/var/lib/kubelet/seccomp", "profile.json", "/var/lib/kubelet/seccomp/profile.json", false},
+		{"/var/lib/kubelet/seccomp", "team/profile.json", "/var/lib/kubelet/seccomp/team/profile.json", false},
+		{"/var/lib/kubelet/seccomp", "/var/lib/kubelet/seccomp/profile.json", "/var/lib/kubelet/seccomp/profile.json", false},
+		{"/var/lib/kubelet/seccomp", "/etc/crio/profile.json", "", true},
+		{"/var/lib/kubelet/seccomp", "../profile.json", "", true},
+		{"/var/lib/kubelet/seccomp", "team/../../seccomp-other/profile.json", "", true},
+	} {
+		path, err := LocalhostProfilePath(tc.root, tc.name)
+		if tc.fails {
+			if err == nil {
+				t.Errorf("expected %q in root %q to be refused, got %s", tc.name, tc.root, path)
+			}
+			continue
+		}
+		if err != nil || path != tc.path {
+			t.Errorf("expected %q in root %q to be %s, got %s (%v)", tc.name, tc.root, tc.path, path, err)
+		}
+	}
+}
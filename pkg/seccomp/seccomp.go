@@ -43,6 +43,19 @@ func LoadProfileFromBytes(body []byte, specgen *generate.Generator) error {
 	return setupSeccomp(&config, specgen)
 }
 
+// SyscallName returns the name of the syscall of an audit record.
+func SyscallName(record AuditRecord) (string, error) {
+	archName, ok := auditArches[record.Arch]
+	if !ok {
+		return "", fmt.Errorf("unknown audit architecture %x", record.Arch)
+	}
+	arch, err := libseccomp.GetArchFromString(archName)
+	if err != nil {
+		return "", err
+	}
+	return libseccomp.ScmpSyscall(record.Syscall).GetNameByArch(arch)
+}
+
 var nativeToSeccomp = map[string]Arch{
 	"amd64":       ArchX86_64,
 	"arm64":       ArchAARCH64,
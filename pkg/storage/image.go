@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"net"
 	"path"
@@ -14,10 +16,14 @@ import (
 	"github.com/containers/image/manifest"
 	"github.com/containers/image/signature"
 	istorage "github.com/containers/image/storage"
+	"github.com/containers/image/transports"
 	"github.com/containers/image/transports/alltransports"
 	"github.com/containers/image/types"
 	"github.com/containers/storage"
+	criotypes "github.com/kubernetes-incubator/cri-o/types"
 	digest "github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -44,6 +50,11 @@ type ImageResult struct {
 	Digest       digest.Digest
 	ConfigDigest digest.Digest
 	User         string
+	// SignatureVerification holds how the image satisfied the signature
+	// policy when it got pulled, if known.
+	SignatureVerification *criotypes.SignatureVerification
+	// OCIConfig is the configuration of the image.
+	OCIConfig *v1.Image
 }
 
 type indexInfo struct {
@@ -68,9 +79,22 @@ type imageService struct {
 	insecureRegistryCIDRs []*net.IPNet
 	indexConfigs          map[string]*indexInfo
 	registries            []string
+	registryConfigs       map[string]RegistryConfig
+	registryRewrites      map[string]string
 	imageCache            imageCache
 	imageCacheLock        sync.Mutex
 	ctx                   context.Context
+	// pulls holds the pulls in progress, by pull key.
+	pulls     map[string]*pullOperation
+	pullsLock sync.Mutex
+}
+
+// pullOperation is a pull in progress, whose result is shared with all the
+// callers asking for the same image with the same credentials meanwhile.
+type pullOperation struct {
+	done chan struct{}
+	ref  types.ImageReference
+	err  error
 }
 
 // sizer knows its size.
@@ -317,14 +341,16 @@ func (svc *imageService) ImageStatus(systemContext *types.SystemContext, nameOrI
 	name, tags, digests := sortNamesByType(image.Names)
 	imageDigest, repoDigests := svc.makeRepoDigests(digests, tags, image.ID)
 	result := ImageResult{
-		ID:           image.ID,
-		Name:         name,
-		RepoTags:     tags,
-		RepoDigests:  repoDigests,
-		Size:         size,
-		Digest:       imageDigest,
-		ConfigDigest: configDigest,
-		User:         imageConfig.Config.User,
+		ID:                    image.ID,
+		Name:                  name,
+		RepoTags:              tags,
+		RepoDigests:           repoDigests,
+		Size:                  size,
+		Digest:                imageDigest,
+		ConfigDigest:          configDigest,
+		User:                  imageConfig.Config.User,
+		SignatureVerification: svc.signatureVerification(image.ID),
+		OCIConfig:             imageConfig,
 	}
 
 	return &result, nil
@@ -357,21 +383,26 @@ func (svc *imageService) CanPull(imageName string, options *copy.Options) (bool,
 	if err != nil {
 		return false, err
 	}
-	rawSource, err := srcRef.NewImageSource(svc.ctx, options.SourceCtx)
+	sources, err := svc.sources(srcRef, options.SourceCtx)
 	if err != nil {
 		return false, err
 	}
-	sourceCtx := &types.SystemContext{}
-	if options.SourceCtx != nil {
-		sourceCtx = options.SourceCtx
-	}
-	src, err := image.FromSource(svc.ctx, sourceCtx, rawSource)
-	if err != nil {
-		rawSource.Close()
-		return false, err
+	for _, source := range sources {
+		var rawSource types.ImageSource
+		rawSource, err = source.ref.NewImageSource(svc.ctx, source.ctx)
+		if err != nil {
+			continue
+		}
+		var src types.ImageCloser
+		src, err = image.FromSource(svc.ctx, source.ctx, rawSource)
+		if err != nil {
+			rawSource.Close()
+			continue
+		}
+		src.Close()
+		return true, nil
 	}
-	src.Close()
-	return true, nil
+	return false, err
 }
 
 // prepareReference creates an image reference from an image string and set options
@@ -396,11 +427,6 @@ func (svc *imageService) prepareReference(imageName string, options *copy.Option
 	if options.SourceCtx == nil {
 		options.SourceCtx = &types.SystemContext{}
 	}
-
-	hostname := reference.Domain(srcRef.DockerReference())
-	if secure := svc.isSecureIndex(hostname); !secure {
-		options.SourceCtx.DockerInsecureSkipTLSVerify = !secure
-	}
 	return srcRef, nil
 }
 
@@ -413,7 +439,18 @@ func (svc *imageService) PrepareImage(systemContext *types.SystemContext, imageN
 	if err != nil {
 		return nil, err
 	}
-	return srcRef.NewImage(svc.ctx, systemContext)
+	sources, err := svc.sources(srcRef, systemContext)
+	if err != nil {
+		return nil, err
+	}
+	var img types.ImageCloser
+	for _, source := range sources {
+		img, err = source.ref.NewImage(svc.ctx, source.ctx)
+		if err == nil {
+			return img, nil
+		}
+	}
+	return nil, err
 }
 
 func (svc *imageService) PullImage(systemContext *types.SystemContext, imageName string, options *copy.Options) (types.ImageReference, error) {
@@ -425,6 +462,7 @@ func (svc *imageService) PullImage(systemContext *types.SystemContext, imageName
 	if err != nil {
 		return nil, err
 	}
+	defer policyContext.Destroy()
 	if options == nil {
 		options = &copy.Options{}
 	}
@@ -448,13 +486,76 @@ func (svc *imageService) PullImage(systemContext *types.SystemContext, imageName
 	if err != nil {
 		return nil, err
 	}
-	err = copy.Image(svc.ctx, policyContext, destRef, srcRef, options)
+
+	sources, err := svc.sources(srcRef, options.SourceCtx)
 	if err != nil {
 		return nil, err
 	}
+
+	key := pullKey(srcRef, options.SourceCtx)
+	svc.pullsLock.Lock()
+	if op, ok := svc.pulls[key]; ok {
+		svc.pullsLock.Unlock()
+		<-op.done
+		return op.ref, op.err
+	}
+	op := &pullOperation{done: make(chan struct{})}
+	svc.pulls[key] = op
+	svc.pullsLock.Unlock()
+
+	defer func() {
+		svc.pullsLock.Lock()
+		delete(svc.pulls, key)
+		svc.pullsLock.Unlock()
+		close(op.done)
+	}()
+
+	var (
+		pulledFrom pullSource
+		pulledRef  *recordingReference
+	)
+	for _, source := range sources {
+		sourceOptions := *options
+		sourceOptions.SourceCtx = source.ctx
+		ref := &recordingReference{ImageReference: source.ref}
+		op.err = copy.Image(svc.ctx, policyContext, destRef, ref, &sourceOptions)
+		if op.err == nil {
+			pulledFrom, pulledRef = source, ref
+			break
+		}
+		op.err = explainRejection(op.err, policy, source.ref)
+		logrus.Debugf("error pulling image %s from %s: %v", imageName, transports.ImageName(source.ref), op.err)
+	}
+	if op.err != nil {
+		return nil, op.err
+	}
+
+	verification, err := svc.verifySignatures(policy, policyContext, pulledFrom, pulledRef)
+	if err == nil {
+		var img *storage.Image
+		img, err = istorage.Transport.GetStoreImage(svc.store, destRef)
+		if err == nil {
+			err = svc.recordSignatureVerification(img.ID, verification)
+		}
+	}
+	if err != nil {
+		logrus.Warnf("failed to record the signature verification of image %s: %v", imageName, err)
+	}
+	op.ref = destRef
 	return destRef, nil
 }
 
+// pullKey identifies the pulls of the same image reference with the same
+// credentials, which can share their result.
+func pullKey(srcRef types.ImageReference, sourceCtx *types.SystemContext) string {
+	key := transports.ImageName(srcRef)
+	if sourceCtx != nil && sourceCtx.DockerAuthConfig != nil {
+		auth := sha256.Sum256([]byte(sourceCtx.DockerAuthConfig.Username + ":" + sourceCtx.DockerAuthConfig.Password))
+		key += "#" + hex.EncodeToString(auth[:])
+	}
+	return key
+}
+
 func (svc *imageService) UntagImage(systemContext *types.SystemContext, nameOrID string) error {
 	ref, err := svc.getRef(nameOrID)
 	if err != nil {
@@ -601,8 +702,13 @@ func (svc *imageService) ResolveNames(imageName string) ([]string, error) {
 // GetImageService returns an ImageServer that uses the passed-in store, and
 // which will prepend the passed-in defaultTransport value to an image name if
 // a name that's passed to its PullImage() method can't be resolved to an image
-// in the store and can't be resolved to a source on its own.
-func GetImageService(ctx context.Context, store storage.Store, defaultTransport string, insecureRegistries []string, registries []string) (ImageServer, error) {
+// in the store and can't be resolved to a source on its own. Images get pulled
+// through the mirrors of registryConfigs, once the prefix rewrites are
+// applied to their names.
+func GetImageService(ctx context.Context, store storage.Store, defaultTransport string, insecureRegistries []string, registries []string, registryConfigs map[string]RegistryConfig, registryRewrites map[string]string) (ImageServer, error) {
+	if err := validateRegistries(registryConfigs, registryRewrites); err != nil {
+		return nil, err
+	}
 	if store == nil {
 		var err error
 		store, err = storage.GetStore(storage.DefaultStoreOptions)
@@ -627,8 +733,11 @@ func GetImageService(ctx context.Context, store storage.Store, defaultTransport
 		indexConfigs:          make(map[string]*indexInfo),
 		insecureRegistryCIDRs: make([]*net.IPNet, 0),
 		registries:            cleanRegistries,
+		registryConfigs:       registryConfigs,
+		registryRewrites:      registryRewrites,
 		imageCache:            make(map[string]imageCacheItem),
 		ctx:                   ctx,
+		pulls:                 make(map[string]*pullOperation),
 	}
 
 	insecureRegistries = append(insecureRegistries, "127.0.0.0/8")
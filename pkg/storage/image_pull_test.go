@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containers/image/copy"
+	"github.com/containers/image/manifest"
+	"github.com/containers/image/types"
+	"github.com/containers/storage"
+	"github.com/containers/storage/pkg/reexec"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Layers get applied by a re-executed test binary.
+	if reexec.Init() {
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// testRegistry is a minimal docker registry serving a single image, which
+// counts the manifest and layer downloads and can hold the latter until
+// released.
+type testRegistry struct {
+	*httptest.Server
+	manifest         []byte
+	blobs            map[digest.Digest][]byte
+	layer            digest.Digest
+	manifestRequests int32
+	layerRequests    int32
+	release          chan struct{}
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	var layer bytes.Buffer
+	gz := gzip.NewWriter(&layer)
+	tw := tar.NewWriter(gz)
+	content := []byte("hello from the test registry\n")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "hello", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	uncompressed, err := gzipDigest(layer.Bytes())
+	require.NoError(t, err)
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": []string{uncompressed.String()},
+		},
+	})
+	require.NoError(t, err)
+
+	r := &testRegistry{
+		blobs:   make(map[digest.Digest][]byte),
+		layer:   digest.FromBytes(layer.Bytes()),
+		release: make(chan struct{}),
+	}
+	r.blobs[r.layer] = layer.Bytes()
+	configDigest := digest.FromBytes(config)
+	r.blobs[configDigest] = config
+	r.manifest, err = json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     manifest.DockerV2Schema2MediaType,
+		"config": map[string]interface{}{
+			"mediaType": manifest.DockerV2Schema2ConfigMediaType,
+			"size":      len(config),
+			"digest":    configDigest,
+		},
+		"layers": []map[string]interface{}{{
+			"mediaType": manifest.DockerV2Schema2LayerMediaType,
+			"size":      layer.Len(),
+			"digest":    r.layer,
+		}},
+	})
+	require.NoError(t, err)
+
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+func gzipDigest(compressed []byte) (digest.Digest, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	uncompressed, err := ioutil.ReadAll(gz)
+	if err != nil {
+		return "", err
+	}
+	return digest.FromBytes(uncompressed), nil
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasSuffix(req.URL.Path, "/manifests/latest"):
+		if req.Method == http.MethodGet {
+			atomic.AddInt32(&r.manifestRequests, 1)
+		}
+		w.Header().Set("Content-Type", manifest.DockerV2Schema2MediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(r.manifest).String())
+		w.Write(r.manifest)
+	case strings.Contains(req.URL.Path, "/blobs/"):
+		blob := digest.Digest(req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+		content, ok := r.blobs[blob]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		if blob == r.layer && req.Method == http.MethodGet {
+			atomic.AddInt32(&r.layerRequests, 1)
+			<-r.release
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+		w.Write(content)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func newTestImageService(t *testing.T, dir string) ImageServer {
+	store, err := storage.GetStore(storage.StoreOptions{
+		RunRoot:         filepath.Join(dir, "run"),
+		GraphRoot:       filepath.Join(dir, "root"),
+		GraphDriverName: "vfs",
+	})
+	require.NoError(t, err)
+	svc, err := GetImageService(context.Background(), store, "docker://", nil, nil, nil, nil)
+	require.NoError(t, err)
+	return svc
+}
+
+func TestPullImageDeduplicatesConcurrentPulls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crio-pull")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	policyPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(`{"default":[{"type":"insecureAcceptAnything"}]}`), 0644))
+	systemContext := &types.SystemContext{SignaturePolicyPath: policyPath}
+
+	registry := newTestRegistry(t)
+	defer registry.Close()
+	image := strings.TrimPrefix(registry.URL, "https://") + "/test/image:latest"
+
+	svc := newTestImageService(t, dir)
+	defer svc.GetStore().Shutdown(true)
+
+	const pulls = 4
+	var wg sync.WaitGroup
+	refs := make([]types.ImageReference, pulls)
+	errs := make([]error, pulls)
+	for i := 0; i < pulls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			refs[i], errs[i] = svc.PullImage(systemContext, image, &copy.Options{})
+		}(i)
+	}
+
+	// Let all the pulls reach the registry, or wait for the first one.
+	for atomic.LoadInt32(&registry.layerRequests) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+	close(registry.release)
+	wg.Wait()
+
+	for i := 0; i < pulls; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, refs[0], refs[i])
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&registry.layerRequests))
+
+	// Pulls with different credentials don't share their result.
+	require.NotEqual(t,
+		pullKey(refs[0], &types.SystemContext{DockerAuthConfig: &types.DockerAuthConfig{Username: "a", Password: "b"}}),
+		pullKey(refs[0], &types.SystemContext{DockerAuthConfig: &types.DockerAuthConfig{Username: "a", Password: "c"}}))
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/containers/image/docker"
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/types"
+	"github.com/pkg/errors"
+)
+
+// RegistryConfig is the configuration of a registry, given by its
+// host[:port].
+type RegistryConfig struct {
+	// Mirrors are the locations tried in order before the registry itself
+	// when pulling an image from it. A mirror is a host[:port], optionally
+	// followed by a repository namespace, replacing the registry host in
+	// the image names.
+	Mirrors []string `toml:"mirrors"`
+	// Blocked prevents the registry from being contacted. Its images can
+	// still be pulled through its mirrors, or the prefix rewrites.
+	Blocked bool `toml:"blocked"`
+	// CADir is the directory holding the CA certificates (*.crt) trusted
+	// for the registry, and the client certificates (*.cert and *.key) to
+	// authenticate with, as /etc/docker/certs.d/<host> does.
+	CADir string `toml:"ca_dir"`
+}
+
+// pullSource is a location an image gets pulled from, with the system
+// context to contact it with.
+type pullSource struct {
+	ref types.ImageReference
+	ctx *types.SystemContext
+}
+
+// locationRegexp matches the registry locations, host[:port] optionally
+// followed by a repository namespace.
+var locationRegexp = regexp.MustCompile("^" + reference.NameRegexp.String() + "$")
+
+// ErrRegistryBlocked is returned when all the locations an image could be
+// pulled from are blocked.
+var ErrRegistryBlocked = errors.New("registry is blocked")
+
+// validateRegistries checks the registry configurations and the prefix
+// rewrites.
+func validateRegistries(configs map[string]RegistryConfig, rewrites map[string]string) error {
+	for registry, config := range configs {
+		if err := validateLocation(registry); err != nil {
+			return errors.Wrapf(err, "invalid registry %q", registry)
+		}
+		if strings.Contains(registry, "/") {
+			return fmt.Errorf("invalid registry %q: must be a host[:port]", registry)
+		}
+		for _, mirror := range config.Mirrors {
+			if err := validateLocation(mirror); err != nil {
+				return errors.Wrapf(err, "invalid mirror %q of registry %q", mirror, registry)
+			}
+		}
+	}
+	for prefix, location := range rewrites {
+		if err := validateLocation(prefix); err != nil {
+			return errors.Wrapf(err, "invalid rewritten prefix %q", prefix)
+		}
+		if err := validateLocation(location); err != nil {
+			return errors.Wrapf(err, "invalid rewrite %q of prefix %q", location, prefix)
+		}
+	}
+	return nil
+}
+
+// validateLocation checks that location is a host[:port], optionally
+// followed by a repository namespace.
+func validateLocation(location string) error {
+	if location == "" {
+		return fmt.Errorf("empty location")
+	}
+	if strings.Contains(location, "://") {
+		return fmt.Errorf("location must not have a scheme")
+	}
+	if !locationRegexp.MatchString(location) {
+		return fmt.Errorf("location must be a host[:port] followed by an optional repository namespace")
+	}
+	if domain, _ := splitDockerDomain(location + "/image"); domain == "" {
+		return fmt.Errorf("location must start with a registry host[:port]")
+	}
+	return nil
+}
+
+// hasPathPrefix tells whether name starts with the path components of
+// prefix.
+func hasPathPrefix(name, prefix string) bool {
+	return name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// rewrite applies the longest matching prefix rewrite to the repository
+// name.
+func (svc *imageService) rewrite(name string) string {
+	prefixes := make([]string, 0, len(svc.registryRewrites))
+	for prefix := range svc.registryRewrites {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	for _, prefix := range prefixes {
+		if hasPathPrefix(name, prefix) {
+			return svc.registryRewrites[prefix] + name[len(prefix):]
+		}
+	}
+	return name
+}
+
+// registryOf returns the host[:port] of the location.
+func registryOf(location string) string {
+	return strings.SplitN(location, "/", 2)[0]
+}
+
+// sourceContext returns a copy of the system context set up to contact the
+// registry. The credentials of the pull are only kept for the registry of
+// the pulled image, which they were given for.
+func (svc *imageService) sourceContext(sourceCtx *types.SystemContext, registry, origin string) *types.SystemContext {
+	ctx := types.SystemContext{}
+	if sourceCtx != nil {
+		ctx = *sourceCtx
+	}
+	if registry != origin {
+		ctx.DockerAuthConfig = nil
+	}
+	if !svc.isSecureIndex(registry) {
+		ctx.DockerInsecureSkipTLSVerify = true
+	}
+	if config, ok := svc.registryConfigs[registry]; ok && config.CADir != "" {
+		ctx.DockerCertPath = config.CADir
+	}
+	return &ctx
+}
+
+// sources returns the locations to try in order when pulling the image
+// referenced by srcRef: the mirrors of its registry, once the prefix
+// rewrites are applied, then the registry itself, skipping the blocked
+// ones. The mirrors and the rewritten locations on other hosts than the
+// registry of the image get contacted without the credentials of the pull.
+func (svc *imageService) sources(srcRef types.ImageReference, sourceCtx *types.SystemContext) ([]pullSource, error) {
+	if srcRef.Transport().Name() != docker.Transport.Name() || srcRef.DockerReference() == nil {
+		return []pullSource{{ref: srcRef, ctx: sourceCtx}}, nil
+	}
+
+	named := srcRef.DockerReference()
+	suffix := strings.TrimPrefix(named.String(), named.Name())
+	location := svc.rewrite(named.Name())
+	registry := registryOf(location)
+
+	locations := []string{}
+	for _, mirror := range svc.registryConfigs[registry].Mirrors {
+		locations = append(locations, mirror+strings.TrimPrefix(location, registry))
+	}
+	locations = append(locations, location)
+
+	sources := []pullSource{}
+	for _, loc := range locations {
+		if svc.registryConfigs[registryOf(loc)].Blocked {
+			continue
+		}
+		ref, err := docker.ParseReference("//" + loc + suffix)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, pullSource{
+			ref: ref,
+			ctx: svc.sourceContext(sourceCtx, registryOf(loc), registryOf(named.Name())),
+		})
+	}
+	if len(sources) == 0 {
+		return nil, errors.Wrapf(ErrRegistryBlocked, "cannot pull %s", named.String())
+	}
+	return sources, nil
+}
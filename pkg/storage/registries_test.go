@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/containers/image/docker"
+	"github.com/containers/image/transports"
+	"github.com/containers/image/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources(t *testing.T) {
+	svc := &imageService{
+		indexConfigs: map[string]*indexInfo{},
+		registryConfigs: map[string]RegistryConfig{
+			"docker.io": {
+				Mirrors: []string{"mirror.example.com:5000/docker.io", "blocked.example.com"},
+				Blocked: true,
+			},
+			"quay.io": {
+				Mirrors: []string{"mirror.example.com:5000/quay.io"},
+			},
+			"blocked.example.com": {
+				Blocked: true,
+			},
+			"mirror.example.com:5000": {
+				CADir: "/etc/crio/certs.d/mirror.example.com:5000",
+			},
+			"internal.example.com": {
+				Mirrors: []string{"internal-mirror.example.com"},
+			},
+		},
+		registryRewrites: map[string]string{
+			"docker.io/library":         "internal.example.com/library",
+			"docker.io/library/busybox": "internal.example.com/busybox",
+			"registry.example.com/team": "internal.example.com/team",
+		},
+	}
+
+	cases := []struct {
+		image    string
+		expected []string
+		err      error
+	}{
+		{
+			image:    "docker.io/library/nginx:latest",
+			expected: []string{"//internal-mirror.example.com/library/nginx:latest", "//internal.example.com/library/nginx:latest"},
+		},
+		{
+			image:    "docker.io/library/busybox:1.28",
+			expected: []string{"//internal-mirror.example.com/busybox:1.28", "//internal.example.com/busybox:1.28"},
+		},
+		{
+			image:    "docker.io/library/busyboxes:latest",
+			expected: []string{"//internal-mirror.example.com/library/busyboxes:latest", "//internal.example.com/library/busyboxes:latest"},
+		},
+		{
+			image:    "docker.io/openshift/origin@sha256:dc5f67a48da730d67bf4bfb8824ea8a51be26711de090d6d5a1ffff2723168a3",
+			expected: []string{"//mirror.example.com:5000/docker.io/openshift/origin@sha256:dc5f67a48da730d67bf4bfb8824ea8a51be26711de090d6d5a1ffff2723168a3"},
+		},
+		{
+			image:    "quay.io/coreos/etcd:latest",
+			expected: []string{"//mirror.example.com:5000/quay.io/coreos/etcd:latest", "//quay.io/coreos/etcd:latest"},
+		},
+		{
+			image:    "registry.example.com/team/app:latest",
+			expected: []string{"//internal-mirror.example.com/team/app:latest", "//internal.example.com/team/app:latest"},
+		},
+		{
+			image:    "registry.example.com/teams/app:latest",
+			expected: []string{"//registry.example.com/teams/app:latest"},
+		},
+		{
+			image: "blocked.example.com/app:latest",
+			err:   ErrRegistryBlocked,
+		},
+	}
+	for _, c := range cases {
+		srcRef, err := docker.ParseReference("//" + c.image)
+		require.NoError(t, err)
+		sources, err := svc.sources(srcRef, nil)
+		if c.err != nil {
+			require.Equal(t, c.err, errors.Cause(err), c.image)
+			continue
+		}
+		require.NoError(t, err, c.image)
+		names := []string{}
+		for _, source := range sources {
+			names = append(names, source.ref.StringWithinTransport())
+		}
+		assert.Equal(t, c.expected, names, c.image)
+	}
+
+	srcRef, err := docker.ParseReference("//docker.io/openshift/origin:latest")
+	require.NoError(t, err)
+	sourceCtx := &types.SystemContext{DockerRegistryUserAgent: "test"}
+	sources, err := svc.sources(srcRef, sourceCtx)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "docker://mirror.example.com:5000/docker.io/openshift/origin:latest", transports.ImageName(sources[0].ref))
+	assert.Equal(t, "/etc/crio/certs.d/mirror.example.com:5000", sources[0].ctx.DockerCertPath)
+	assert.Equal(t, "test", sources[0].ctx.DockerRegistryUserAgent)
+	assert.Empty(t, sourceCtx.DockerCertPath)
+
+	// The credentials of the pull are only sent to the registry of the image,
+	// neither to its mirrors nor to the rewritten locations.
+	auth := &types.DockerAuthConfig{Username: "user", Password: "secret"}
+	for image, expected := range map[string][]*types.DockerAuthConfig{
+		"quay.io/coreos/etcd:latest":     {nil, auth},
+		"docker.io/library/nginx:latest": {nil, nil},
+	} {
+		srcRef, err := docker.ParseReference("//" + image)
+		require.NoError(t, err)
+		sources, err := svc.sources(srcRef, &types.SystemContext{DockerAuthConfig: auth})
+		require.NoError(t, err)
+		auths := []*types.DockerAuthConfig{}
+		for _, source := range sources {
+			auths = append(auths, source.ctx.DockerAuthConfig)
+		}
+		assert.Equal(t, expected, auths, image)
+	}
+}
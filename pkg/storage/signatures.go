@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/containers/image/signature"
+	"github.com/containers/image/transports"
+	"github.com/containers/image/types"
+	criotypes "github.com/kubernetes-incubator/cri-o/types"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// signatureVerificationBigDataKey is the key the signature verification
+// results of an image are stored under, with the image.
+const signatureVerificationBigDataKey = "crio-signature-verification"
+
+// policyScope returns the scope of the policy which applies to ref, as
+// "<transport>:<scope>" or "default", along with its requirements. It
+// mirrors the selection done by signature.PolicyContext.
+func policyScope(policy *signature.Policy, ref types.ImageReference) (string, signature.PolicyRequirements) {
+	transportName := ref.Transport().Name()
+	if transportScopes, ok := policy.Transports[transportName]; ok {
+		identity := ref.PolicyConfigurationIdentity()
+		if reqs, ok := transportScopes[identity]; ok {
+			return transportName + ":" + identity, reqs
+		}
+		for _, name := range ref.PolicyConfigurationNamespaces() {
+			if reqs, ok := transportScopes[name]; ok {
+				return transportName + ":" + name, reqs
+			}
+		}
+		if reqs, ok := transportScopes[""]; ok {
+			return transportName + ":", reqs
+		}
+	}
+	return "default", policy.Default
+}
+
+// requirementTypes returns the types of the policy requirements, like
+// "signedBy" or "insecureAcceptAnything".
+func requirementTypes(reqs signature.PolicyRequirements) []string {
+	names := []string{}
+	for _, req := range reqs {
+		var r struct {
+			Type string `json:"type"`
+		}
+		if b, err := json.Marshal(req); err == nil && json.Unmarshal(b, &r) == nil {
+			names = append(names, r.Type)
+		}
+	}
+	return names
+}
+
+// recordingReference is the reference of an image to pull, whose source
+// keeps what the copy reads, for the signatures the policy got evaluated
+// with during the copy to be verified again without fetching them again.
+type recordingReference struct {
+	types.ImageReference
+	source *recordingSource
+}
+
+func (r *recordingReference) NewImageSource(ctx context.Context, sys *types.SystemContext) (types.ImageSource, error) {
+	src, err := r.ImageReference.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	r.source = &recordingSource{ImageSource: src, manifests: make(map[digest.Digest]recordedManifest)}
+	return r.source, nil
+}
+
+type recordedManifest struct {
+	manifest []byte
+	mimeType string
+}
+
+// recordingSource is an image source keeping the manifests it returned, by
+// instance digest, and the signatures of the last instance they got read
+// for, the one evaluated against the policy.
+type recordingSource struct {
+	types.ImageSource
+	manifests  map[digest.Digest]recordedManifest
+	instance   digest.Digest
+	signatures [][]byte
+}
+
+func (s *recordingSource) GetManifest(ctx context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	manifest, mimeType, err := s.ImageSource.GetManifest(ctx, instanceDigest)
+	if err == nil {
+		s.manifests[instanceKey(instanceDigest)] = recordedManifest{manifest: manifest, mimeType: mimeType}
+	}
+	return manifest, mimeType, err
+}
+
+func (s *recordingSource) GetSignatures(ctx context.Context, instanceDigest *digest.Digest) ([][]byte, error) {
+	signatures, err := s.ImageSource.GetSignatures(ctx, instanceDigest)
+	if err == nil {
+		s.instance, s.signatures = instanceKey(instanceDigest), signatures
+	}
+	return signatures, err
+}
+
+func instanceKey(instanceDigest *digest.Digest) digest.Digest {
+	if instanceDigest == nil {
+		return ""
+	}
+	return *instanceDigest
+}
+
+// pulled returns the image which got pulled, with the manifest and the
+// signatures read during the copy.
+func (r *recordingReference) pulled() (types.UnparsedImage, error) {
+	if r.source == nil {
+		return nil, errors.Errorf("%s wasn't read", transports.ImageName(r.ImageReference))
+	}
+	m, ok := r.source.manifests[r.source.instance]
+	if !ok {
+		return nil, errors.Errorf("the manifest of %s wasn't read", transports.ImageName(r.ImageReference))
+	}
+	return &pulledImage{
+		ref:        r.source.Reference(),
+		manifest:   m,
+		signatures: r.source.signatures,
+	}, nil
+}
+
+// pulledImage is an image as read during its copy.
+type pulledImage struct {
+	ref        types.ImageReference
+	manifest   recordedManifest
+	signatures [][]byte
+}
+
+func (i *pulledImage) Reference() types.ImageReference {
+	return i.ref
+}
+
+func (i *pulledImage) Manifest(ctx context.Context) ([]byte, string, error) {
+	return i.manifest.manifest, i.manifest.mimeType, nil
+}
+
+func (i *pulledImage) Signatures(ctx context.Context) ([][]byte, error) {
+	return i.signatures, nil
+}
+
+// singleSignatureImage is an image with only one of its signatures, to
+// evaluate them one by one against the policy.
+type singleSignatureImage struct {
+	types.UnparsedImage
+	signature []byte
+}
+
+func (i *singleSignatureImage) Signatures(ctx context.Context) ([][]byte, error) {
+	return [][]byte{i.signature}, nil
+}
+
+// verifySignatures evaluates the signatures of the image pulled from source
+// through ref against the policy, with the policy context of the copy, and
+// returns the ones the policy accepts along with the policy scope which
+// applied. The signatures are verified against the manifest which got
+// pulled.
+func (svc *imageService) verifySignatures(policy *signature.Policy, policyContext *signature.PolicyContext, source pullSource, ref *recordingReference) (*criotypes.SignatureVerification, error) {
+	scope, reqs := policyScope(policy, source.ref)
+	verification := &criotypes.SignatureVerification{
+		Source:       transports.ImageName(source.ref),
+		PolicyScope:  scope,
+		Requirements: requirementTypes(reqs),
+		Signatures:   []criotypes.SignatureInfo{},
+		VerifiedTime: time.Now().UnixNano(),
+	}
+
+	pulled, err := ref.pulled()
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := pulled.Signatures(svc.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sig := range sigs {
+		accepted, err := policyContext.GetSignaturesWithAcceptedAuthor(svc.ctx, &singleSignatureImage{UnparsedImage: pulled, signature: sig})
+		if err != nil {
+			return nil, err
+		}
+		if len(accepted) != 1 {
+			continue
+		}
+		// The signature has been verified with a trusted key, its key
+		// identifier can be relied on.
+		info, err := signature.GetUntrustedSignatureInformationWithoutVerifying(sig)
+		if err != nil {
+			return nil, err
+		}
+		verification.Signatures = append(verification.Signatures, criotypes.SignatureInfo{
+			KeyIdentifier:  info.UntrustedShortKeyIdentifier,
+			Identity:       accepted[0].DockerReference,
+			ManifestDigest: accepted[0].DockerManifestDigest.String(),
+		})
+	}
+	return verification, nil
+}
+
+// recordSignatureVerification stores the signature verification results
+// with the image.
+func (svc *imageService) recordSignatureVerification(imageID string, verification *criotypes.SignatureVerification) error {
+	data, err := json.Marshal(verification)
+	if err != nil {
+		return err
+	}
+	return svc.store.SetImageBigData(imageID, signatureVerificationBigDataKey, data)
+}
+
+// signatureVerification returns the signature verification results stored
+// with the image, if any.
+func (svc *imageService) signatureVerification(imageID string) *criotypes.SignatureVerification {
+	data, err := svc.store.ImageBigData(imageID, signatureVerificationBigDataKey)
+	if err != nil {
+		return nil
+	}
+	verification := &criotypes.SignatureVerification{}
+	if err := json.Unmarshal(data, verification); err != nil {
+		return nil
+	}
+	return verification
+}
+
+// explainRejection adds the policy scope and requirements which rejected
+// the image pulled from ref to err, when it's a policy rejection.
+func explainRejection(err error, policy *signature.Policy, ref types.ImageReference) error {
+	if _, ok := errors.Cause(err).(signature.PolicyRequirementError); !ok {
+		return err
+	}
+	scope, reqs := policyScope(policy, ref)
+	return errors.Wrapf(err, "%s rejected by the signature policy scope %q requiring %v", transports.ImageName(ref), scope, requirementTypes(reqs))
+}
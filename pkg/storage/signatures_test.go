@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/containers/image/copy"
+	"github.com/containers/image/docker"
+	"github.com/containers/image/signature"
+	"github.com/containers/image/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyScope(t *testing.T) {
+	policy, err := signature.NewPolicyFromBytes([]byte(`{
+		"default": [{"type": "reject"}],
+		"transports": {
+			"docker": {
+				"quay.io/crio": [{"type": "insecureAcceptAnything"}],
+				"quay.io/crio/busybox:latest": [{"type": "signedBy", "keyType": "GPGKeys", "keyPath": "/etc/pki/crio.gpg"}]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	for image, expected := range map[string]struct {
+		scope        string
+		requirements []string
+	}{
+		"quay.io/crio/busybox:latest": {"docker:quay.io/crio/busybox:latest", []string{"signedBy"}},
+		"quay.io/crio/redis:alpine":   {"docker:quay.io/crio", []string{"insecureAcceptAnything"}},
+		"docker.io/library/redis:4":   {"default", []string{"reject"}},
+	} {
+		ref, err := docker.ParseReference("//" + image)
+		require.NoError(t, err)
+		scope, reqs := policyScope(policy, ref)
+		assert.Equal(t, expected.scope, scope, image)
+		assert.Equal(t, expected.requirements, requirementTypes(reqs), image)
+	}
+}
+
+// pullWithPolicy pulls the image of a test registry with the signature
+// policy, its signatures read from a file lookaside, and returns a function
+// cleaning everything up.
+func pullWithPolicy(t *testing.T, policyJSON string) (ImageServer, *testRegistry, string, func(), error) {
+	dir, err := ioutil.TempDir("", "crio-pull")
+	require.NoError(t, err)
+	policyPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(policyJSON), 0644))
+	registriesDir := filepath.Join(dir, "registries.d")
+	require.NoError(t, os.Mkdir(registriesDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(registriesDir, "default.yaml"),
+		[]byte("default-docker:\n  sigstore: file://"+filepath.Join(dir, "sigstore")+"\n"), 0644))
+
+	registry := newTestRegistry(t)
+	close(registry.release)
+	image := strings.TrimPrefix(registry.URL, "https://") + "/test/image:latest"
+
+	svc := newTestImageService(t, dir)
+	cleanup := func() {
+		svc.GetStore().Shutdown(true)
+		registry.Close()
+		os.RemoveAll(dir)
+	}
+	_, err = svc.PullImage(&types.SystemContext{SignaturePolicyPath: policyPath}, image, &copy.Options{
+		SourceCtx: &types.SystemContext{RegistriesDirPath: registriesDir},
+	})
+	return svc, registry, image, cleanup, err
+}
+
+func TestPullImageRecordsSignatureVerification(t *testing.T) {
+	svc, registry, image, cleanup, err := pullWithPolicy(t, `{"default":[{"type":"insecureAcceptAnything"}]}`)
+	defer cleanup()
+	require.NoError(t, err)
+
+	status, err := svc.ImageStatus(&types.SystemContext{}, image)
+	require.NoError(t, err)
+	require.NotNil(t, status.SignatureVerification)
+	assert.Equal(t, "docker://"+image, status.SignatureVerification.Source)
+	assert.Equal(t, "default", status.SignatureVerification.PolicyScope)
+	assert.Equal(t, []string{"insecureAcceptAnything"}, status.SignatureVerification.Requirements)
+	assert.Empty(t, status.SignatureVerification.Signatures)
+	assert.NotZero(t, status.SignatureVerification.VerifiedTime)
+	// The signatures got verified against the manifest the copy read, and
+	// weren't fetched again.
+	assert.Equal(t, int32(1), atomic.LoadInt32(&registry.manifestRequests))
+}
+
+func TestPullImageExplainsRejection(t *testing.T) {
+	_, _, _, cleanup, err := pullWithPolicy(t, `{"default":[{"type":"reject"}]}`)
+	defer cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `rejected by the signature policy scope "default" requiring [reject]`)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"os"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kubernetes-incubator/cri-o/pkg/apparmor"
+	"github.com/sirupsen/logrus"
+)
+
+// StartAppArmorProfileMonitor starts a routine that loads the apparmor
+// profiles of the profile directory again whenever their files change.
+// Profiles the files of which get removed stay loaded.
+func (s *Server) StartAppArmorProfileMonitor() {
+	if !s.appArmorEnabled || s.config.ApparmorProfileDir == "" {
+		return
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logrus.Warnf("failed to watch apparmor profile directory %s: %v", s.config.ApparmorProfileDir, err)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(s.config.ApparmorProfileDir); err != nil {
+		logrus.Warnf("failed to watch apparmor profile directory %s: %v", s.config.ApparmorProfileDir, err)
+		return
+	}
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			info, err := os.Stat(event.Name)
+			if err != nil || !apparmor.IsProfileFile(info) {
+				continue
+			}
+			if err := apparmor.LoadProfile(event.Name); err != nil {
+				logrus.Warnf("failed to load apparmor profile %s: %v", event.Name, err)
+				continue
+			}
+			logrus.Infof("loaded apparmor profile %s", event.Name)
+		case err := <-watcher.Errors:
+			logrus.Warnf("error watching apparmor profile directory %s: %v", s.config.ApparmorProfileDir, err)
+		case <-s.monitorsChan:
+			logrus.Debug("closing apparmor profile monitor...")
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/kubernetes-incubator/cri-o/pkg/apparmor"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// recordAudit writes the record of an operation requested by the client of
+// ctx to the audit log, along with the error the operation failed with.
+func (s *Server) recordAudit(ctx context.Context, record *audit.Record, err error) {
+	if s.auditLog == nil || record == nil {
+		return
+	}
+	record.Client = clientFromContext(ctx)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := s.auditLog.Log(record); err != nil {
+		logrus.Warnf("failed to write %s record to the audit log: %v", record.Operation, err)
+	}
+}
+
+// hostNamespaces returns the namespaces of the node the namespace options
+// ask for.
+func hostNamespaces(options ...*pb.NamespaceOption) []string {
+	hostNS := make(map[string]bool)
+	for _, o := range options {
+		if o.GetNetwork() == pb.NamespaceMode_NODE {
+			hostNS["network"] = true
+		}
+		if o.GetPid() == pb.NamespaceMode_NODE {
+			hostNS["pid"] = true
+		}
+		if o.GetIpc() == pb.NamespaceMode_NODE {
+			hostNS["ipc"] = true
+		}
+	}
+	namespaces := make([]string, 0, len(hostNS))
+	for ns := range hostNS {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// sandboxAuditRecord returns the audit record of the pod sandbox, or nil if
+// it doesn't get any host namespace or privilege.
+func (s *Server) sandboxAuditRecord(req *pb.RunPodSandboxRequest) *audit.Record {
+	securityContext := req.GetConfig().GetLinux().GetSecurityContext()
+	record := &audit.Record{
+		Operation:      audit.RunPodSandbox,
+		PodName:        req.GetConfig().GetMetadata().GetName(),
+		PodNamespace:   req.GetConfig().GetMetadata().GetNamespace(),
+		Privileged:     securityContext.GetPrivileged(),
+		HostNamespaces: hostNamespaces(securityContext.GetNamespaceOptions()),
+	}
+	if !record.Privileged && len(record.HostNamespaces) == 0 {
+		return nil
+	}
+	return record
+}
+
+// containerAuditRecord returns the audit record of the container, or nil if
+// it doesn't get any privilege, host namespace, host path mount or added
+// capability, and doesn't run unconfined.
+func (s *Server) containerAuditRecord(req *pb.CreateContainerRequest) *audit.Record {
+	containerConfig := req.GetConfig()
+	sandboxConfig := req.GetSandboxConfig()
+	securityContext := containerConfig.GetLinux().GetSecurityContext()
+	record := &audit.Record{
+		Operation:         audit.CreateContainer,
+		PodSandboxID:      req.PodSandboxId,
+		PodName:           sandboxConfig.GetMetadata().GetName(),
+		PodNamespace:      sandboxConfig.GetMetadata().GetNamespace(),
+		ContainerName:     containerConfig.GetMetadata().GetName(),
+		Image:             containerConfig.GetImage().GetImage(),
+		Privileged:        securityContext.GetPrivileged(),
+		HostNamespaces:    hostNamespaces(sandboxConfig.GetLinux().GetSecurityContext().GetNamespaceOptions(), securityContext.GetNamespaceOptions()),
+		AddedCapabilities: securityContext.GetCapabilities().GetAddCapabilities(),
+	}
+	for _, m := range containerConfig.GetMounts() {
+		record.HostPathMounts = append(record.HostPathMounts, m.HostPath)
+	}
+	seccompProfile := containerSeccompProfile(securityContext.GetSeccompProfilePath(), sandboxConfig.GetLinux().GetSecurityContext().GetSeccompProfilePath())
+	if s.seccompEnabled && (seccompProfile == "" || seccompProfile == seccompUnconfined) {
+		record.SeccompProfile = seccompUnconfined
+	}
+	if appArmorProfile := securityContext.GetApparmorProfile(); s.appArmorEnabled && !record.Privileged && (appArmorProfile == "" || appArmorProfile == apparmor.ProfileUnconfined) {
+		record.ApparmorProfile = apparmor.ProfileUnconfined
+	}
+	if !record.Privileged && len(record.HostNamespaces) == 0 && len(record.HostPathMounts) == 0 &&
+		len(record.AddedCapabilities) == 0 && record.SeccompProfile == "" && record.ApparmorProfile == "" {
+		return nil
+	}
+	return record
+}
+
+// sessionAuditRecord returns the audit record of a session in the container
+// with the given ID, or the infra container of the pod sandbox with the
+// given ID.
+func (s *Server) sessionAuditRecord(operation audit.Operation, containerID, podSandboxID string) *audit.Record {
+	record := &audit.Record{
+		Operation:    operation,
+		ContainerID:  containerID,
+		PodSandboxID: podSandboxID,
+	}
+	if containerID != "" {
+		if c, err := s.GetContainerFromShortID(containerID); err == nil {
+			record.ContainerID = c.ID()
+			record.ContainerName = c.Name()
+			record.PodSandboxID = c.Sandbox()
+		}
+	}
+	if record.PodSandboxID != "" {
+		if sandboxID, err := s.PodIDIndex().Get(record.PodSandboxID); err == nil {
+			if sb := s.getSandbox(sandboxID); sb != nil {
+				record.PodSandboxID = sb.ID()
+				record.PodName = sb.KubeName()
+				record.PodNamespace = sb.Namespace()
+			}
+		}
+	}
+	return record
+}
+
+// pullAuthIdentity returns the identity the image gets pulled with, without
+// any of its secrets.
+func pullAuthIdentity(auth *pb.AuthConfig) string {
+	switch {
+	case auth == nil:
+		return ""
+	case auth.Username != "":
+		return auth.Username
+	case auth.Auth != "":
+		if username, _, err := decodeDockerAuth(auth.Auth); err == nil {
+			return username
+		}
+		return ""
+	case auth.IdentityToken != "":
+		return "identity-token"
+	case auth.RegistryToken != "":
+		return "registry-token"
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Operation is the type of an operation recorded in the audit log.
+type Operation string
+
+const (
+	// RunPodSandbox is recorded for the pod sandboxes which get host
+	// namespaces or privileges.
+	RunPodSandbox Operation = "run_pod_sandbox"
+	// CreateContainer is recorded for the containers which get privileges,
+	// host namespaces, host path mounts, added capabilities or run
+	// unconfined.
+	CreateContainer Operation = "create_container"
+	// Exec is recorded for every exec session requested.
+	Exec Operation = "exec"
+	// ExecSync is recorded for every command executed synchronously.
+	ExecSync Operation = "exec_sync"
+	// Attach is recorded for every attach session requested.
+	Attach Operation = "attach"
+	// PortForward is recorded for every port forward session requested.
+	PortForward Operation = "port_forward"
+	// PullImage is recorded for every image pull.
+	PullImage Operation = "pull_image"
+)
+
+// Client identifies the process which requested an operation.
+type Client struct {
+	Pid       int32  `json:"pid,omitempty"`
+	UID       uint32 `json:"uid"`
+	GID       uint32 `json:"gid"`
+	Command   string `json:"command,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// Record is an entry of the audit log.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Operation Operation `json:"operation"`
+	// Error is set when the operation failed.
+	Error  string  `json:"error,omitempty"`
+	Client *Client `json:"client,omitempty"`
+
+	PodSandboxID  string `json:"pod_sandbox_id,omitempty"`
+	PodName       string `json:"pod_name,omitempty"`
+	PodNamespace  string `json:"pod_namespace,omitempty"`
+	ContainerID   string `json:"container_id,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+
+	Privileged        bool     `json:"privileged,omitempty"`
+	HostNamespaces    []string `json:"host_namespaces,omitempty"`
+	HostPathMounts    []string `json:"host_path_mounts,omitempty"`
+	AddedCapabilities []string `json:"added_capabilities,omitempty"`
+	SeccompProfile    string   `json:"seccomp_profile,omitempty"`
+	ApparmorProfile   string   `json:"apparmor_profile,omitempty"`
+
+	Command []string `json:"command,omitempty"`
+	TTY     bool     `json:"tty,omitempty"`
+	Ports   []int32  `json:"ports,omitempty"`
+
+	Image string `json:"image,omitempty"`
+	// AuthIdentity is the identity the image got pulled with, never its
+	// secret.
+	AuthIdentity string `json:"auth_identity,omitempty"`
+}
+
+// Logger appends records as JSON lines to the audit log, and rotates it once
+// it grows past its maximum size.
+type Logger struct {
+	lock     sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// New opens the audit log at path for appending. Once the log grows past
+// maxSize bytes it gets rotated, keeping maxFiles rotated logs around as
+// path.1 to path.<maxFiles>. A maxSize of 0 disables the rotation.
+func New(path string, maxSize int64, maxFiles int) (*Logger, error) {
+	l := &Logger{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %v", l.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit log %s: %v", l.path, err)
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// Log appends the record to the audit log, setting its time if it isn't yet.
+// Logging to a nil logger does nothing.
+func (l *Logger) Log(record *Record) error {
+	if l == nil {
+		return nil
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("audit log %s is closed", l.path)
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotate moves the current log to path.1, shifting the older ones and
+// dropping the oldest one, and opens a new log.
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.maxFiles < 1 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return l.open()
+	}
+	for i := l.maxFiles - 1; i > 0; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the audit log.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("expected %q to be a JSON record: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := New(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(&Record{Operation: Exec, ContainerID: "ctrid", Command: []string{"sh"}, TTY: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The log gets appended to when opened again.
+	if l, err = New(path, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(&Record{Operation: PullImage, Image: "busybox", AuthIdentity: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %+v", records)
+	}
+	if records[0].Operation != Exec || records[0].ContainerID != "ctrid" || !records[0].TTY || records[0].Time.IsZero() {
+		t.Errorf("unexpected exec record %+v", records[0])
+	}
+	if records[1].Operation != PullImage || records[1].AuthIdentity != "user" {
+		t.Errorf("unexpected pull record %+v", records[1])
+	}
+
+	var nilLogger *Logger
+	if err := nilLogger.Log(&Record{Operation: Exec}); err != nil {
+		t.Errorf("expected logging to a nil logger to do nothing, got %v", err)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	record := &Record{Time: time.Date(2018, 10, 18, 12, 0, 0, 0, time.UTC), Operation: Attach, ContainerID: "ctrid"}
+	line, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every log holds two records.
+	l, err := New(path, int64(2*(len(line)+1)+1), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 7; i++ {
+		if err := l.Log(&Record{Time: record.Time, Operation: Attach, ContainerID: "ctrid"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		path    string
+		records int
+	}{
+		{path, 1},
+		{path + ".1", 2},
+		{path + ".2", 2},
+	} {
+		if records := readRecords(t, tc.path); len(records) != tc.records {
+			t.Errorf("expected %d records in %s, got %d", tc.records, tc.path, len(records))
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected the oldest log to be dropped, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-incubator/cri-o/server/audit"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestContainerAuditRecord(t *testing.T) {
+	s := &Server{seccompEnabled: true, appArmorEnabled: true}
+	sandboxConfig := &pb.PodSandboxConfig{
+		Metadata: &pb.PodSandboxMetadata{Name: "pod", Namespace: "ns"},
+		Linux: &pb.LinuxPodSandboxConfig{
+			SecurityContext: &pb.LinuxSandboxSecurityContext{
+				NamespaceOptions:   &pb.NamespaceOption{Network: pb.NamespaceMode_NODE},
+				SeccompProfilePath: "runtime/default",
+			},
+		},
+	}
+	securityContext := &pb.LinuxContainerSecurityContext{
+		Capabilities:     &pb.Capability{AddCapabilities: []string{"NET_ADMIN"}},
+		NamespaceOptions: &pb.NamespaceOption{Pid: pb.NamespaceMode_NODE},
+	}
+	req := &pb.CreateContainerRequest{
+		PodSandboxId: "sandbox",
+		Config: &pb.ContainerConfig{
+			Metadata: &pb.ContainerMetadata{Name: "ctr"},
+			Image:    &pb.ImageSpec{Image: "busybox"},
+			Mounts:   []*pb.Mount{{ContainerPath: "/host", HostPath: "/"}},
+			Linux:    &pb.LinuxContainerConfig{SecurityContext: securityContext},
+		},
+		SandboxConfig: sandboxConfig,
+	}
+
+	expected := &audit.Record{
+		Operation:         audit.CreateContainer,
+		PodSandboxID:      "sandbox",
+		PodName:           "pod",
+		PodNamespace:      "ns",
+		ContainerName:     "ctr",
+		Image:             "busybox",
+		HostNamespaces:    []string{"network", "pid"},
+		HostPathMounts:    []string{"/"},
+		AddedCapabilities: []string{"NET_ADMIN"},
+		ApparmorProfile:   "unconfined",
+	}
+	if record := s.containerAuditRecord(req); !reflect.DeepEqual(record, expected) {
+		t.Errorf("expected the record %+v, got %+v", expected, record)
+	}
+
+	// A container without any of them isn't recorded.
+	sandboxConfig.Linux.SecurityContext.NamespaceOptions = nil
+	securityContext.NamespaceOptions = nil
+	securityContext.Capabilities = nil
+	securityContext.ApparmorProfile = "localhost/profile"
+	req.Config.Mounts = nil
+	if record := s.containerAuditRecord(req); record != nil {
+		t.Errorf("expected no record, got %+v", record)
+	}
+
+	// Neither is the seccomp profile the container inherits from its pod
+	// unconfined, unless seccomp is enabled.
+	sandboxConfig.Linux.SecurityContext.SeccompProfilePath = "unconfined"
+	if record := s.containerAuditRecord(req); record == nil || record.SeccompProfile != "unconfined" {
+		t.Errorf("expected an unconfined seccomp profile record, got %+v", record)
+	}
+	s.seccompEnabled = false
+	if record := s.containerAuditRecord(req); record != nil {
+		t.Errorf("expected no record without seccomp, got %+v", record)
+	}
+
+	// Privileged containers don't run with AppArmor.
+	securityContext.Privileged = true
+	securityContext.ApparmorProfile = ""
+	if record := s.containerAuditRecord(req); record == nil || !record.Privileged || record.ApparmorProfile != "" {
+		t.Errorf("expected a privileged record, got %+v", record)
+	}
+}
+
+func TestPullAuthIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		auth     *pb.AuthConfig
+		identity string
+	}{
+		{nil, ""},
+		{&pb.AuthConfig{}, ""},
+		{&pb.AuthConfig{Username: "user", Password: "secret"}, "user"},
+		{&pb.AuthConfig{Auth: base64.StdEncoding.EncodeToString([]byte("user:secret"))}, "user"},
+		{&pb.AuthConfig{Auth: "not base64"}, ""},
+		{&pb.AuthConfig{IdentityToken: "secret"}, "identity-token"},
+		{&pb.AuthConfig{RegistryToken: "secret"}, "registry-token"},
+	} {
+		if identity := pullAuthIdentity(tc.auth); identity != tc.identity {
+			t.Errorf("expected the identity %q for %+v, got %q", tc.identity, tc.auth, identity)
+		}
+	}
+}
@@ -40,8 +40,40 @@ type APIConfig struct {
 	// StreamTLSCA is the x509 CA(s) file used to verify and authenticate client
 	// communication with the tls encrypted stream
 	StreamTLSCA string `toml:"stream_tls_ca"`
+
+	// AuditLogPath is the path of the JSON audit log of the security relevant
+	// operations requested by the clients. The audit log is disabled when
+	// empty.
+	AuditLogPath string `toml:"audit_log_path"`
+
+	// AuditLogMaxSize is the size in bytes past which the audit log gets
+	// rotated. The audit log isn't rotated when 0.
+	AuditLogMaxSize int64 `toml:"audit_log_max_size"`
+
+	// AuditLogMaxFiles is the number of rotated audit logs kept around.
+	AuditLogMaxFiles int `toml:"audit_log_max_files"`
+
+	// SessionRecordingDir is the directory the exec and attach sessions get
+	// recorded to, in the asciicast v2 format. Sessions aren't recorded when
+	// empty.
+	SessionRecordingDir string `toml:"session_recording_dir"`
+
+	// SessionRecordingMaxSize is the size in bytes the session recordings
+	// may take in total, past which the oldest ones get removed. They
+	// aren't removed when 0.
+	SessionRecordingMaxSize int64 `toml:"session_recording_max_size"`
 }
 
+const (
+	// DefaultAuditLogMaxSize is the default size past which the audit log
+	// gets rotated.
+	DefaultAuditLogMaxSize = 10 * 1024 * 1024
+
+	// DefaultAuditLogMaxFiles is the default number of rotated audit logs
+	// kept around.
+	DefaultAuditLogMaxFiles = 5
+)
+
 // tomlConfig is another way of looking at a Config, which is
 // TOML-friendly (it has all of the explicit tables). It's just used for
 // conversions.
@@ -117,6 +149,9 @@ func DefaultConfig() *Config {
 			Listen:        CrioSocketPath,
 			StreamAddress: "127.0.0.1",
 			StreamPort:    "0",
+
+			AuditLogMaxSize:  DefaultAuditLogMaxSize,
+			AuditLogMaxFiles: DefaultAuditLogMaxFiles,
 		},
 	}
 }
@@ -33,6 +33,9 @@ func assertAllFieldsEquality(t *testing.T, c Config) {
 		{c.RuntimeConfig.ApparmorProfile, "crio-default"},
 		{c.RuntimeConfig.CgroupManager, "cgroupfs"},
 		{c.RuntimeConfig.PidsLimit, int64(1024)},
+		{c.RuntimeConfig.Runtimes["crun"].RuntimePath, "/usr/local/bin/crun"},
+		{c.RuntimeConfig.Runtimes["crun"].RuntimeRoot, "/run/crun"},
+		{c.RuntimeConfig.Runtimes["crun"].RuntimeType, "oci"},
 
 		{c.ImageConfig.DefaultTransport, "docker://"},
 		{c.ImageConfig.PauseImage, "kubernetes/pause"},
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
 	"github.com/kubernetes-incubator/cri-o/utils"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -32,6 +33,11 @@ func (s *Server) Attach(ctx context.Context, req *pb.AttachRequest) (resp *pb.At
 		recordError(operation, err)
 	}()
 	logrus.Debugf("AttachRequest %+v", req)
+	record := s.sessionAuditRecord(audit.Attach, req.ContainerId, "")
+	record.TTY = req.Tty
+	defer func() {
+		s.recordAudit(ctx, record, err)
+	}()
 
 	resp, err = s.getAttach(req)
 	if err != nil {
@@ -57,6 +63,15 @@ func (ss streamService) Attach(containerID string, inputStream io.Reader, output
 		return fmt.Errorf("container is not created or running")
 	}
 
+	recording, err := ss.runtimeServer.startSessionRecording(c, sessionTypeAttach, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record attach session: %v", err)
+	}
+	if recording != nil {
+		defer recording.Close()
+		inputStream, outputStream, errorStream, resize = recording.tee(inputStream, outputStream, errorStream, resize)
+	}
+
 	controlPath := filepath.Join(c.BundlePath(), "ctl")
 	controlFile, err := os.OpenFile(controlPath, os.O_WRONLY, 0)
 	if err != nil {
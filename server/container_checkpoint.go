@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/go-zoo/bone"
+	"github.com/kubernetes-incubator/cri-o/lib"
+	"github.com/kubernetes-incubator/cri-o/server/events"
+	"github.com/sirupsen/logrus"
+)
+
+// serveContainerCheckpoint checkpoints the container and sends its
+// checkpoint archive back, unless the name of an archive to keep in the
+// checkpoints directory of the container is given as target.
+func (s *Server) serveContainerCheckpoint(w http.ResponseWriter, req *http.Request) {
+	containerID := bone.GetValue(req, "id")
+	query := req.URL.Query()
+	opts := &lib.ContainerCheckpointOptions{
+		Archive: query.Get("target"),
+	}
+	if opts.Archive != "" {
+		ctr, err := s.LookupContainer(containerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if _, err := s.CheckpointArchivePath(ctr, opts.Archive); err != nil {
+			http.Error(w, fmt.Sprintf("invalid target parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	for param, value := range map[string]*bool{
+		"leave_running":   &opts.LeaveRunning,
+		"tcp_established": &opts.TCPEstablished,
+	} {
+		if query.Get(param) == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(query.Get(param))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s parameter: %v", param, err), http.StatusBadRequest)
+			return
+		}
+		*value = b
+	}
+
+	archivePath, err := s.ContainerCheckpoint(req.Context(), containerID, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if opts.Archive != "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	defer os.Remove(archivePath)
+	f, err := os.Open(archivePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/x-tar")
+	if _, err := io.Copy(w, f); err != nil {
+		logrus.Debugf("failed to send checkpoint archive of container %s: %v", containerID, err)
+	}
+}
+
+// serveContainerRestore restores the container from a checkpoint archive,
+// either one kept in the checkpoints directory of the container or sent as
+// the request body, and sends the container info back.
+func (s *Server) serveContainerRestore(w http.ResponseWriter, req *http.Request) {
+	containerID := bone.GetValue(req, "id")
+	var archive io.Reader = req.Body
+	if name := req.URL.Query().Get("archive"); name != "" {
+		ctr, err := s.LookupContainer(containerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		archivePath, err := s.CheckpointArchivePath(ctr, name)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid archive parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		f, err := os.Open(archivePath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		archive = f
+	}
+
+	id, err := s.ContainerRestore(req.Context(), containerID, archive)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if c := s.GetContainer(id); c != nil {
+		s.publishContainerEvent(events.ContainerStarted, c)
+	}
+
+	ci, err := s.getContainerInfo(id, s.GetContainer, s.getInfraContainer, s.getSandbox)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	js, err := json.Marshal(ci)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
@@ -3,7 +3,6 @@ package server
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -15,9 +14,11 @@ import (
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/kubernetes-incubator/cri-o/lib"
 	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
+	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
 	"github.com/kubernetes-incubator/cri-o/pkg/apparmor"
 	"github.com/kubernetes-incubator/cri-o/pkg/seccomp"
 	"github.com/kubernetes-incubator/cri-o/pkg/storage"
+	"github.com/kubernetes-incubator/cri-o/server/events"
 	"github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runc/libcontainer/user"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
@@ -443,6 +444,12 @@ func (s *Server) CreateContainer(ctx context.Context, req *pb.CreateContainerReq
 		recordError(operation, err)
 	}()
 	logrus.Debugf("CreateContainerRequest %+v", req)
+	if record := s.containerAuditRecord(req); record != nil {
+		defer func() {
+			record.ContainerID = res.GetContainerId()
+			s.recordAudit(ctx, record, err)
+		}()
+	}
 
 	s.updateLock.RLock()
 	defer s.updateLock.RUnlock()
@@ -523,7 +530,12 @@ func (s *Server) CreateContainer(ctx context.Context, req *pb.CreateContainerReq
 		return nil, err
 	}
 
+	if _, ok := container.CrioAnnotations()[annotations.SeccompAuditProfilePath]; ok {
+		s.seccompAudit.watch(containerID)
+	}
+
 	s.ContainerStateToDisk(container)
+	s.publishContainerEvent(events.ContainerCreated, container)
 
 	resp := &pb.CreateContainerResponse{
 		ContainerId: containerID,
@@ -542,6 +554,16 @@ func isInCRIMounts(dst string, mounts []*pb.Mount) bool {
 	return false
 }
 
+// containerSeccompProfile returns the seccomp profile a container runs with:
+// the one of its security context, or the one of its pod when it doesn't set
+// any.
+func containerSeccompProfile(containerProfile, podProfile string) string {
+	if containerProfile != "" {
+		return containerProfile
+	}
+	return podProfile
+}
+
 func (s *Server) setupSeccomp(specgen *generate.Generator, profile string) error {
 	if profile == "" {
 		// running w/o seccomp, aka unconfined
@@ -550,41 +572,81 @@ func (s *Server) setupSeccomp(specgen *generate.Generator, profile string) error
 	}
 	if !s.seccompEnabled {
 		if profile != seccompUnconfined {
-			return fmt.Errorf("seccomp is not enabled in your kernel, cannot run with a profile")
+			return fmt.Errorf("seccomp is not enabled in your kernel, cannot run with profile %q", profile)
 		}
 		logrus.Warn("seccomp is not enabled in your kernel, running container without profile")
 	}
-	if profile == seccompUnconfined {
+	config, err := s.resolveSeccompProfile(profile)
+	if err != nil {
+		return err
+	}
+	if config == nil {
 		// running w/o seccomp, aka unconfined
 		specgen.Spec().Linux.Seccomp = nil
 		return nil
 	}
+	return seccomp.LoadProfileFromStruct(*config, specgen)
+}
+
+// resolveSeccompProfile returns the seccomp profile the profile option refers
+// to, or nil if it means running unconfined.
+func (s *Server) resolveSeccompProfile(profile string) (*seccomp.Seccomp, error) {
+	if profile == "" || profile == seccompUnconfined {
+		return nil, nil
+	}
 	if profile == seccompRuntimeDefault || profile == seccompDockerDefault {
-		return seccomp.LoadProfileFromStruct(s.seccompProfile, specgen)
+		return &s.seccompProfile, nil
 	}
 	if !strings.HasPrefix(profile, seccompLocalhostPrefix) {
-		return fmt.Errorf("unknown seccomp profile option: %q", profile)
+		return nil, fmt.Errorf("unknown seccomp profile option: %q", profile)
 	}
-	fname := strings.TrimPrefix(profile, "localhost/")
-	file, err := ioutil.ReadFile(filepath.FromSlash(fname))
+	fname, err := seccomp.LocalhostProfilePath(s.config.SeccompProfileRoot, strings.TrimPrefix(profile, seccompLocalhostPrefix))
 	if err != nil {
-		return fmt.Errorf("cannot load seccomp profile %q: %v", fname, err)
+		return nil, fmt.Errorf("cannot resolve seccomp profile %q: %v", profile, err)
 	}
-	return seccomp.LoadProfileFromBytes(file, specgen)
+	config, err := s.seccompProfiles.Load(fname)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load seccomp profile %q: %v", profile, err)
+	}
+	return config, nil
 }
 
-// getAppArmorProfileName gets the profile name for the given container.
-func (s *Server) getAppArmorProfileName(profile string) string {
-	if profile == "" {
-		return ""
+// resolveAppArmorProfile returns the name of the apparmor profile the
+// profile option of a container refers to, or "" to run it unconfined. The
+// profile must be loaded.
+func (s *Server) resolveAppArmorProfile(profile string) (string, error) {
+	switch {
+	case profile == "" || profile == apparmor.ProfileUnconfined:
+		return "", nil
+	case profile == apparmor.ProfileRuntimeDefault:
+		if s.appArmorProfile == apparmor.DefaultApparmorProfile {
+			// reload default apparmor profile if it is unloaded.
+			if err := apparmor.EnsureDefaultApparmorProfile(); err != nil {
+				return "", err
+			}
+			return s.appArmorProfile, nil
+		}
+		return s.appArmorProfile, ensureAppArmorProfileLoaded(s.appArmorProfile)
+	case strings.HasPrefix(profile, apparmor.ProfileNamePrefix):
+		name := strings.TrimPrefix(profile, apparmor.ProfileNamePrefix)
+		if name == "" {
+			return "", fmt.Errorf("no name given for the localhost apparmor profile")
+		}
+		return name, ensureAppArmorProfileLoaded(name)
+	default:
+		return "", fmt.Errorf("unknown apparmor profile option: %q", profile)
 	}
+}
 
-	if profile == apparmor.ProfileRuntimeDefault {
-		// If the value is runtime/default, then return default profile.
-		return s.appArmorProfile
+func ensureAppArmorProfileLoaded(name string) error {
+	loaded, err := apparmor.IsLoaded(name)
+	if err != nil {
+		return fmt.Errorf("could not check if apparmor profile %q is loaded: %v", name, err)
 	}
-
-	return strings.TrimPrefix(profile, apparmor.ProfileNamePrefix)
+	if !loaded {
+		return fmt.Errorf("apparmor profile %q is not loaded", name)
+	}
+	return nil
 }
 
 // openContainerFile opens a file inside a container rootfs safely
@@ -9,16 +9,18 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/containers/storage/pkg/idtools"
 	dockermounts "github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/symlink"
+	"github.com/kubernetes-incubator/cri-o/lib"
 	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
 	"github.com/kubernetes-incubator/cri-o/oci"
 	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
-	"github.com/kubernetes-incubator/cri-o/pkg/apparmor"
+	"github.com/kubernetes-incubator/cri-o/pkg/cgroupv2"
 	"github.com/kubernetes-incubator/cri-o/pkg/storage"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/devices"
@@ -33,6 +35,15 @@ import (
 )
 
 func findCgroupMountpoint(name string) error {
+	// The controllers of the unified hierarchy are all in the same mount,
+	// and only listed by its root.
+	if unified, err := cgroupv2.IsUnifiedMode(); err == nil && unified {
+		available, err := cgroupv2.HasController(name)
+		if err == nil && !available {
+			err = fmt.Errorf("cgroup controller %s is not available", name)
+		}
+		return err
+	}
 	// Set up pids limit if pids cgroup is mounted
 	_, err := cgroups.FindCgroupMountpoint(name)
 	return err
@@ -371,19 +382,13 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 
 	// set this container's apparmor profile if it is set by sandbox
 	if s.appArmorEnabled && !privileged {
-
-		appArmorProfileName := s.getAppArmorProfileName(containerConfig.GetLinux().GetSecurityContext().GetApparmorProfile())
+		appArmorProfileName, err := s.resolveAppArmorProfile(containerConfig.GetLinux().GetSecurityContext().GetApparmorProfile())
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up apparmor for container %s: %v", containerName, err)
+		}
 		if appArmorProfileName != "" {
-			// reload default apparmor profile if it is unloaded.
-			if s.appArmorProfile == apparmor.DefaultApparmorProfile {
-				if err := apparmor.EnsureDefaultApparmorProfile(); err != nil {
-					return nil, err
-				}
-			}
-
 			specgen.SetProcessApparmorProfile(appArmorProfileName)
 		}
-
 	}
 
 	logPath := containerConfig.GetLogPath()
@@ -406,6 +411,27 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 		return nil, err
 	}
 
+	defaultLogRotation, err := s.config.LogRotation()
+	if err != nil {
+		return nil, err
+	}
+	logRotation, err := lib.ContainerLogRotation(defaultLogRotation, containerConfig.GetAnnotations(), sb.Annotations())
+	if err != nil {
+		return nil, err
+	}
+	defaultLogRateLimit, err := s.config.LogRateLimit()
+	if err != nil {
+		return nil, err
+	}
+	logRateLimit, err := lib.ContainerLogRateLimit(defaultLogRateLimit, containerConfig.GetAnnotations(), sb.Annotations())
+	if err != nil {
+		return nil, err
+	}
+	logDrivers, err := lib.ContainerLogDrivers(s.config.LogDrivers, s.config.LogSyslogAddress, containerConfig.GetAnnotations(), sb.Annotations())
+	if err != nil {
+		return nil, err
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"sbox.logdir": sboxLogDir,
 		"ctr.logfile": containerConfig.GetLogPath(),
@@ -560,6 +586,10 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 	if image == "" {
 		return nil, fmt.Errorf("CreateContainerRequest.ContainerConfig.Image.Image is empty")
 	}
+	// The image garbage collection can't remove the image until the
+	// container got created from it.
+	s.imageGC.inUse.RLock()
+	defer s.imageGC.inUse.RUnlock()
 	images, err := s.StorageImageServer().ResolveNames(image)
 	if err != nil {
 		if err == storage.ErrCannotParseImageID {
@@ -584,6 +614,8 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 		return nil, imgResultErr
 	}
 
+	s.imageGC.recordUse(imgResult.ID)
+
 	imageName := imgResult.Name
 	imageRef := imgResult.ID
 	if len(imgResult.RepoDigests) > 0 {
@@ -661,6 +693,18 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 	specgen.AddAnnotation(annotations.SandboxName, sb.InfraContainer().Name())
 	specgen.AddAnnotation(annotations.ContainerType, annotations.ContainerTypeContainer)
 	specgen.AddAnnotation(annotations.LogPath, logPath)
+	if logRotation.Files > 0 {
+		specgen.AddAnnotation(annotations.LogRotateFiles, strconv.Itoa(logRotation.Files))
+		specgen.AddAnnotation(annotations.LogRotateSize, strconv.FormatInt(logRotation.Size, 10))
+		specgen.AddAnnotation(annotations.LogRotateAge, logRotation.Age.String())
+	}
+	if logRateLimit.Bytes > 0 || logRateLimit.Lines > 0 {
+		specgen.AddAnnotation(annotations.LogRateLimitBytes, strconv.FormatInt(logRateLimit.Bytes, 10))
+		specgen.AddAnnotation(annotations.LogRateLimitLines, strconv.FormatInt(logRateLimit.Lines, 10))
+		specgen.AddAnnotation(annotations.LogRateLimitBurst, strconv.Itoa(logRateLimit.Burst))
+	}
+	specgen.AddAnnotation(annotations.LogDrivers, strings.Join(logDrivers, ","))
+	specgen.AddAnnotation(annotations.LogSyslogAddress, s.config.LogSyslogAddress)
 	specgen.AddAnnotation(annotations.TTY, fmt.Sprintf("%v", containerConfig.Tty))
 	specgen.AddAnnotation(annotations.Stdin, fmt.Sprintf("%v", containerConfig.Stdin))
 	specgen.AddAnnotation(annotations.StdinOnce, fmt.Sprintf("%v", containerConfig.StdinOnce))
@@ -687,10 +731,19 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 	}
 	specgen.AddAnnotation(annotations.Annotations, string(kubeAnnotationsJSON))
 
-	spp := containerConfig.GetLinux().GetSecurityContext().GetSeccompProfilePath()
+	spp := containerSeccompProfile(containerConfig.GetLinux().GetSecurityContext().GetSeccompProfilePath(), sb.SeccompProfilePath())
 	if !privileged {
-		if err = s.setupSeccomp(&specgen, spp); err != nil {
-			return nil, err
+		if auditMode := seccompAuditMode(containerConfig.GetAnnotations(), sb.Annotations()); auditMode != "" {
+			auditProfilePath, err := seccompAuditProfilePath(s.config.SeccompAuditDir, containerName)
+			if err != nil {
+				return nil, err
+			}
+			if err = s.setupSeccompAudit(&specgen, spp, auditMode); err != nil {
+				return nil, fmt.Errorf("failed to set up seccomp audit for container %s: %v", containerName, err)
+			}
+			specgen.AddAnnotation(annotations.SeccompAuditProfilePath, auditProfilePath)
+		} else if err = s.setupSeccomp(&specgen, spp); err != nil {
+			return nil, fmt.Errorf("failed to set up seccomp for container %s: %v", containerName, err)
 		}
 	}
 	specgen.AddAnnotation(annotations.SeccompProfilePath, spp)
@@ -836,7 +889,7 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 
 	crioAnnotations := specgen.Spec().Annotations
 
-	container, err := oci.NewContainer(containerID, containerName, containerInfo.RunDir, logPath, sb.NetNs().Path(), labels, crioAnnotations, kubeAnnotations, image, imageName, imageRef, metadata, sb.ID(), containerConfig.Tty, containerConfig.Stdin, containerConfig.StdinOnce, sb.Privileged(), sb.Trusted(), containerInfo.RunDir, created, containerImageConfig.Config.StopSignal)
+	container, err := oci.NewContainer(containerID, containerName, containerInfo.RunDir, logPath, sb.NetNs().Path(), labels, crioAnnotations, kubeAnnotations, image, imageName, imageRef, metadata, sb.ID(), containerConfig.Tty, containerConfig.Stdin, containerConfig.StdinOnce, sb.Privileged(), sb.Trusted(), sb.RuntimeHandler(), containerInfo.RunDir, created, containerImageConfig.Config.StopSignal)
 	if err != nil {
 		return nil, err
 	}
@@ -882,6 +935,11 @@ func (s *Server) createSandboxContainer(ctx context.Context, containerID string,
 	container.SetSpec(specgen.Spec())
 	container.SetMountPoint(mountPoint)
 	container.SetSeccompProfilePath(spp)
+	container.SetLogRotation(logRotation)
+	container.SetLogRateLimit(logRateLimit)
+	container.SetLogDrivers(logDrivers)
+	container.SetLogSyslogAddress(s.config.LogSyslogAddress)
+	container.SetPodName(sb.KubeName(), sb.Namespace())
 
 	for _, cv := range containerVolumes {
 		container.AddVolume(cv)
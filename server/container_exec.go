@@ -3,17 +3,14 @@ package server
 import (
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
 	"time"
 
-	"github.com/docker/docker/pkg/pools"
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"k8s.io/client-go/tools/remotecommand"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
-	utilexec "k8s.io/utils/exec"
 )
 
 // Exec prepares a streaming endpoint to execute a command in the container.
@@ -25,6 +22,11 @@ func (s *Server) Exec(ctx context.Context, req *pb.ExecRequest) (resp *pb.ExecRe
 	}()
 
 	logrus.Debugf("ExecRequest %+v", req)
+	record := s.sessionAuditRecord(audit.Exec, req.ContainerId, "")
+	record.Command, record.TTY = req.Cmd, req.Tty
+	defer func() {
+		s.recordAudit(ctx, record, err)
+	}()
 
 	resp, err = s.getExec(req)
 	if err != nil {
@@ -50,45 +52,14 @@ func (ss streamService) Exec(containerID string, cmd []string, stdin io.Reader,
 		return fmt.Errorf("container is not created or running")
 	}
 
-	processFile, err := oci.PrepareProcessExec(c, cmd, tty)
+	recording, err := ss.runtimeServer.startSessionRecording(c, sessionTypeExec, cmd)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to record exec session: %v", err)
 	}
-	defer os.RemoveAll(processFile.Name())
-
-	args := []string{"exec"}
-	args = append(args, "--process", processFile.Name())
-	args = append(args, c.ID())
-	execCmd := exec.Command(ss.runtimeServer.Runtime().Path(c), args...)
-	var cmdErr error
-	if tty {
-		cmdErr = ss.ttyCmd(execCmd, stdin, stdout, resize)
-	} else {
-		if stdin != nil {
-			// Use an os.Pipe here as it returns true *os.File objects.
-			// This way, if you run 'kubectl exec <pod> -i bash' (no tty) and type 'exit',
-			// the call below to execCmd.Run() can unblock because its Stdin is the read half
-			// of the pipe.
-			r, w, err := os.Pipe()
-			if err != nil {
-				return err
-			}
-			go pools.Copy(w, stdin)
-
-			execCmd.Stdin = r
-		}
-		if stdout != nil {
-			execCmd.Stdout = stdout
-		}
-		if stderr != nil {
-			execCmd.Stderr = stderr
-		}
-
-		cmdErr = execCmd.Run()
+	if recording != nil {
+		defer recording.Close()
+		stdin, stdout, stderr, resize = recording.tee(stdin, stdout, stderr, resize)
 	}
 
-	if exitErr, ok := cmdErr.(*exec.ExitError); ok {
-		return &utilexec.ExitErrorWrapper{ExitError: exitErr}
-	}
-	return cmdErr
+	return ss.runtimeServer.Runtime().ExecContainer(c, cmd, stdin, stdout, stderr, tty, resize)
 }
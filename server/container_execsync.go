@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -18,6 +19,11 @@ func (s *Server) ExecSync(ctx context.Context, req *pb.ExecSyncRequest) (resp *p
 		recordError(operation, err)
 	}()
 	logrus.Debugf("ExecSyncRequest %+v", req)
+	record := s.sessionAuditRecord(audit.ExecSync, req.ContainerId, "")
+	record.Command = req.Cmd
+	defer func() {
+		s.recordAudit(ctx, record, err)
+	}()
 	c, err := s.GetContainerFromShortID(req.ContainerId)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"github.com/kubernetes-incubator/cri-o/lib"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// listContainerMetrics returns the resource usage statistics of the running
+// containers, as reported by ContainerStats.
+func (s *Server) listContainerMetrics() []*metrics.ContainerStats {
+	ctrs, err := s.ContainerServer.ListContainers(func(c *oci.Container) bool {
+		return s.Runtime().ContainerStatus(c).Status == oci.ContainerStateRunning
+	})
+	if err != nil {
+		logrus.Warnf("failed to list containers for metrics: %v", err)
+		return nil
+	}
+
+	var list []*metrics.ContainerStats
+	for _, c := range ctrs {
+		stats, err := s.GetContainerStats(c, &lib.ContainerStats{})
+		if err != nil {
+			logrus.Debugf("failed to get stats of container %s: %v", c.ID(), err)
+			continue
+		}
+		m := &metrics.ContainerStats{
+			ID:          c.ID(),
+			Container:   c.Metadata().GetName(),
+			CPUNano:     stats.CPUNano,
+			MemUsage:    stats.MemUsage,
+			MemLimit:    stats.MemLimit,
+			NetInput:    stats.NetInput,
+			NetOutput:   stats.NetOutput,
+			BlockInput:  stats.BlockInput,
+			BlockOutput: stats.BlockOutput,
+			PIDs:        stats.PIDs,
+
+			LogDroppedLines: stats.LogDroppedLines,
+			LogDroppedBytes: stats.LogDroppedBytes,
+		}
+		if sb := s.getSandbox(c.Sandbox()); sb != nil {
+			m.Namespace = sb.Namespace()
+			m.Pod = sb.KubeName()
+		}
+		list = append(list, m)
+	}
+	return list
+}
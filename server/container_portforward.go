@@ -1,15 +1,13 @@
 package server
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"os/exec"
-	"strings"
 	"time"
 
 	"github.com/docker/docker/pkg/pools"
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -23,6 +21,11 @@ func (s *Server) PortForward(ctx context.Context, req *pb.PortForwardRequest) (r
 		recordError(operation, err)
 	}()
 	logrus.Debugf("PortForwardRequest %+v", req)
+	record := s.sessionAuditRecord(audit.PortForward, "", req.PodSandboxId)
+	record.Ports = req.Port
+	defer func() {
+		s.recordAudit(ctx, record, err)
+	}()
 
 	resp, err = s.getPortForward(req)
 	if err != nil {
@@ -37,8 +40,11 @@ func (ss streamService) PortForward(podSandboxID string, port int32, stream io.R
 	if err != nil {
 		return fmt.Errorf("PodSandbox with ID starting with %s not found: %v", podSandboxID, err)
 	}
-	c := ss.runtimeServer.GetSandboxContainer(sandboxID)
-
+	sb := ss.runtimeServer.getSandbox(sandboxID)
+	if sb == nil {
+		return fmt.Errorf("could not find sandbox %q", podSandboxID)
+	}
+	c := sb.InfraContainer()
 	if c == nil {
 		return fmt.Errorf("could not find container for sandbox %q", podSandboxID)
 	}
@@ -52,49 +58,25 @@ func (ss streamService) PortForward(podSandboxID string, port int32, stream io.R
 		return fmt.Errorf("container is not created or running")
 	}
 
-	containerPid := cState.Pid
-	socatPath, lookupErr := exec.LookPath("socat")
-	if lookupErr != nil {
-		return fmt.Errorf("unable to do port forwarding: socat not found")
-	}
-
-	args := []string{"-t", fmt.Sprintf("%d", containerPid), "-n", socatPath, "-", fmt.Sprintf("TCP4:localhost:%d", port)}
-
-	nsenterPath, lookupErr := exec.LookPath("nsenter")
-	if lookupErr != nil {
-		return fmt.Errorf("unable to do port forwarding: nsenter not found")
-	}
-
-	commandString := fmt.Sprintf("%s %s", nsenterPath, strings.Join(args, " "))
-	logrus.Debugf("executing port forwarding command: %s", commandString)
-
-	command := exec.Command(nsenterPath, args...)
-	command.Stdout = stream
-
-	stderr := new(bytes.Buffer)
-	command.Stderr = stderr
-
-	// If we use Stdin, command.Run() won't return until the goroutine that's copying
-	// from stream finishes. Unfortunately, if you have a client like telnet connected
-	// via port forwarding, as long as the user's telnet client is connected to the user's
-	// local listener that port forwarding sets up, the telnet session never exits. This
-	// means that even if socat has finished running, command.Run() won't ever return
-	// (because the client still has the connection and stream open).
-	//
-	// The work around is to use StdinPipe(), as Wait() (called by Run()) closes the pipe
-	// when the command (socat) exits.
-	inPipe, err := command.StdinPipe()
+	netNsPath := sb.NetNsPath()
+	logrus.Debugf("forwarding port %d of sandbox %s in network namespace %s", port, sandboxID, netNsPath)
+	conn, err := dialPortInNetNs(netNsPath, port)
 	if err != nil {
-		return fmt.Errorf("unable to do port forwarding: error creating stdin pipe: %v", err)
+		return fmt.Errorf("unable to do port forwarding: %v", err)
 	}
+	defer conn.Close()
+
+	// Like with socat, the forwarding ends as soon as the port side gets
+	// closed, even if the client keeps the stream open.
 	go func() {
-		pools.Copy(inPipe, stream)
-		inPipe.Close()
+		if _, err := pools.Copy(conn, stream); err != nil {
+			logrus.Debugf("error copying port forward stream of sandbox %s: %v", sandboxID, err)
+		}
+		conn.CloseWrite()
 	}()
 
-	if err := command.Run(); err != nil {
-		return fmt.Errorf("%v: %s", err, stderr.String())
+	if _, err := pools.Copy(stream, conn); err != nil {
+		return fmt.Errorf("error copying port %d of sandbox %s: %v", port, sandboxID, err)
 	}
-
 	return nil
 }
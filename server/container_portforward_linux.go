@@ -0,0 +1,44 @@
+// +build linux
+
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+)
+
+// dialPortInNetNs connects to the port on localhost in the network namespace
+// found at netNsPath, over IPv4 first, then IPv6.
+func dialPortInNetNs(netNsPath string, port int32) (*net.TCPConn, error) {
+	netNs, err := ns.GetNS(netNsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network namespace %s: %v", netNsPath, err)
+	}
+	defer netNs.Close()
+
+	var conn *net.TCPConn
+	// Do runs on a locked OS thread switched to the network namespace. The
+	// socket stays in that namespace once the thread switched back.
+	err = netNs.Do(func(ns.NetNS) error {
+		var err4, err6 error
+		conn, err4 = dialTCP("tcp4", net.IPv4(127, 0, 0, 1), port)
+		if err4 == nil {
+			return nil
+		}
+		conn, err6 = dialTCP("tcp6", net.IPv6loopback, port)
+		if err6 == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to localhost:%d: %v, %v", port, err4, err6)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func dialTCP(network string, ip net.IP, port int32) (*net.TCPConn, error) {
+	return net.DialTCP(network, nil, &net.TCPAddr{IP: ip, Port: int(port)})
+}
@@ -0,0 +1,12 @@
+// +build !linux
+
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+func dialPortInNetNs(netNsPath string, port int32) (*net.TCPConn, error) {
+	return nil, fmt.Errorf("port forwarding is not supported on this platform")
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"time"
 
+	"github.com/kubernetes-incubator/cri-o/server/events"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -18,10 +19,18 @@ func (s *Server) RemoveContainer(ctx context.Context, req *pb.RemoveContainerReq
 	}()
 	logrus.Debugf("RemoveContainerRequest: %+v", req)
 
-	_, err = s.ContainerServer.Remove(ctx, req.ContainerId, true)
+	c, err := s.GetContainerFromShortID(req.ContainerId)
 	if err != nil {
 		return nil, err
 	}
+	_, err = s.ContainerServer.Remove(ctx, c.ID(), true)
+	if err != nil {
+		return nil, err
+	}
+	// The container may have been stopped by its removal.
+	s.finishSeccompAudit(c)
+	s.removeSessionRecordings(c.ID())
+	s.publishContainerEvent(events.ContainerRemoved, c)
 
 	resp = &pb.RemoveContainerResponse{}
 	logrus.Debugf("RemoveContainerResponse: %+v", resp)
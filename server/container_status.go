@@ -101,6 +101,15 @@ func (s *Server) ContainerStatus(ctx context.Context, req *pb.ContainerStatusReq
 	resp.Status.State = rStatus
 	resp.Status.LogPath = c.LogPath()
 
+	if req.Verbose {
+		info := s.containerVerboseValues(c, s.getSandbox(c.Sandbox()), c.SeccompProfilePath())
+		info["oomKillCount"] = cState.OOMKillCount
+		if !cState.LastOOMKill.IsZero() {
+			info["lastOOMKill"] = cState.LastOOMKill
+		}
+		resp.Info = verboseInfo(info)
+	}
+
 	logrus.Debugf("ContainerStatusResponse: %+v", resp)
 	return resp, nil
 }
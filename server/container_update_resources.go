@@ -6,6 +6,7 @@ import (
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/cgroupv2"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -39,6 +40,9 @@ func (s *Server) UpdateContainerResources(ctx context.Context, req *pb.UpdateCon
 
 // toOCIResources converts CRI resource constraints to OCI.
 func toOCIResources(r *pb.LinuxContainerResources) *rspec.LinuxResources {
+	if unified, err := cgroupv2.IsUnifiedMode(); err == nil && unified {
+		return toOCIResourcesUnified(r)
+	}
 	return &rspec.LinuxResources{
 		CPU: &rspec.LinuxCPU{
 			Shares: proto.Uint64(uint64(r.GetCpuShares())),
@@ -53,3 +57,49 @@ func toOCIResources(r *pb.LinuxContainerResources) *rspec.LinuxResources {
 		// TODO(runcom): OOMScoreAdj is missing
 	}
 }
+
+// The range of the CPU shares the runtimes convert to a cpu.weight of the
+// unified cgroup hierarchy, from 1 to 10000.
+const (
+	minCPUShares = 2
+	maxCPUShares = 262144
+)
+
+// toOCIResourcesUnified converts CRI resource constraints to OCI, for the
+// runtime to write them to the controllers of the unified cgroup
+// hierarchy: the CPU shares to cpu.weight, the CPU quota and period to
+// cpu.max, and the memory limit to memory.max. As those files don't take a
+// zero for no limit, the constraints the CRI leaves unset with a zero are
+// either left out, or converted to "max" with a negative limit.
+func toOCIResourcesUnified(r *pb.LinuxContainerResources) *rspec.LinuxResources {
+	cpu := &rspec.LinuxCPU{
+		Cpus: r.GetCpusetCpus(),
+		Mems: r.GetCpusetMems(),
+	}
+	if shares := r.GetCpuShares(); shares > 0 {
+		if shares < minCPUShares {
+			shares = minCPUShares
+		} else if shares > maxCPUShares {
+			shares = maxCPUShares
+		}
+		cpu.Shares = proto.Uint64(uint64(shares))
+	}
+	if period := r.GetCpuPeriod(); period > 0 {
+		cpu.Period = proto.Uint64(uint64(period))
+		cpu.Quota = proto.Int64(-1)
+	}
+	if quota := r.GetCpuQuota(); quota > 0 {
+		cpu.Quota = proto.Int64(quota)
+	}
+
+	limit := r.GetMemoryLimitInBytes()
+	if limit <= 0 {
+		limit = -1
+	}
+	return &rspec.LinuxResources{
+		CPU: cpu,
+		Memory: &rspec.LinuxMemory{
+			Limit: proto.Int64(limit),
+		},
+	}
+}
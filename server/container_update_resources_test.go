@@ -0,0 +1,53 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestToOCIResourcesUnified(t *testing.T) {
+	for _, tc := range []struct {
+		resources pb.LinuxContainerResources
+		expected  rspec.LinuxResources
+	}{
+		{
+			pb.LinuxContainerResources{CpuShares: 512, CpuPeriod: 100000, CpuQuota: 50000, MemoryLimitInBytes: 1 << 20, CpusetCpus: "0-1"},
+			rspec.LinuxResources{
+				CPU:    &rspec.LinuxCPU{Shares: proto.Uint64(512), Period: proto.Uint64(100000), Quota: proto.Int64(50000), Cpus: "0-1"},
+				Memory: &rspec.LinuxMemory{Limit: proto.Int64(1 << 20)},
+			},
+		},
+		// No quota nor memory limit is "max".
+		{
+			pb.LinuxContainerResources{CpuShares: 2, CpuPeriod: 100000},
+			rspec.LinuxResources{
+				CPU:    &rspec.LinuxCPU{Shares: proto.Uint64(2), Period: proto.Uint64(100000), Quota: proto.Int64(-1)},
+				Memory: &rspec.LinuxMemory{Limit: proto.Int64(-1)},
+			},
+		},
+		// The shares convert to a weight within its range.
+		{
+			pb.LinuxContainerResources{CpuShares: 1 << 20},
+			rspec.LinuxResources{
+				CPU:    &rspec.LinuxCPU{Shares: proto.Uint64(262144)},
+				Memory: &rspec.LinuxMemory{Limit: proto.Int64(-1)},
+			},
+		},
+		{
+			pb.LinuxContainerResources{},
+			rspec.LinuxResources{
+				CPU:    &rspec.LinuxCPU{},
+				Memory: &rspec.LinuxMemory{Limit: proto.Int64(-1)},
+			},
+		},
+	} {
+		resources := tc.resources
+		if converted := toOCIResourcesUnified(&resources); !reflect.DeepEqual(*converted, tc.expected) {
+			t.Errorf("expected %+v to be converted to %+v, got %+v", tc.resources, tc.expected, *converted)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/server/events"
+	"github.com/sirupsen/logrus"
+)
+
+// publishSandboxEvent publishes a lifecycle event of the sandbox.
+func (s *Server) publishSandboxEvent(t events.Type, sb *sandbox.Sandbox) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(events.Event{
+		Type:         t,
+		ID:           sb.ID(),
+		Name:         sb.Name(),
+		PodSandboxID: sb.ID(),
+		PodName:      sb.KubeName(),
+		PodNamespace: sb.Namespace(),
+	})
+}
+
+// publishContainerEvent publishes a lifecycle event of the container.
+func (s *Server) publishContainerEvent(t events.Type, c *oci.Container) {
+	if s.events == nil {
+		return
+	}
+	e := events.Event{
+		Type:         t,
+		ID:           c.ID(),
+		Name:         c.Name(),
+		PodSandboxID: c.Sandbox(),
+		Image:        c.ImageName(),
+	}
+	if sb := s.getSandbox(c.Sandbox()); sb != nil {
+		e.PodName = sb.KubeName()
+		e.PodNamespace = sb.Namespace()
+	}
+	if t == events.ContainerExited {
+		exitCode := s.Runtime().ContainerStatus(c).ExitCode
+		e.ExitCode = &exitCode
+	}
+	s.events.Publish(e)
+}
+
+// publishImageEvent publishes a lifecycle event of the image.
+func (s *Server) publishImageEvent(t events.Type, id, name string) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(events.Event{
+		Type:  t,
+		ID:    id,
+		Name:  name,
+		Image: name,
+	})
+}
+
+// parseEventsFilter builds the events filter from the query parameters of
+// the request. Event types can be given as a comma separated list, or by
+// repeating the type parameter.
+func parseEventsFilter(req *http.Request) (events.Filter, error) {
+	query := req.URL.Query()
+	filter := events.Filter{
+		Pod:       query.Get("pod"),
+		Namespace: query.Get("namespace"),
+	}
+	for _, value := range query["type"] {
+		for _, t := range strings.Split(value, ",") {
+			if t == "" {
+				continue
+			}
+			if !isEventType(events.Type(t)) {
+				return filter, fmt.Errorf("unknown event type %q", t)
+			}
+			filter.Types = append(filter.Types, events.Type(t))
+		}
+	}
+	return filter, nil
+}
+
+func isEventType(t events.Type) bool {
+	for _, known := range events.Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// serveEvents streams the events matching the request filters as JSON
+// objects, one per line, until the client goes away.
+func (s *Server) serveEvents(w http.ResponseWriter, req *http.Request) {
+	filter, err := parseEventsFilter(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	if s.events == nil {
+		http.Error(w, "events are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	ch, cancel := s.events.Subscribe(filter)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(e); err != nil {
+				logrus.Debugf("failed to send event to subscriber: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Type is the type of a lifecycle event.
+type Type string
+
+const (
+	// SandboxCreated is emitted once a pod sandbox got created.
+	SandboxCreated Type = "sandbox_created"
+	// SandboxStarted is emitted once the infra container of a pod sandbox
+	// got started.
+	SandboxStarted Type = "sandbox_started"
+	// SandboxStopped is emitted once a pod sandbox got stopped.
+	SandboxStopped Type = "sandbox_stopped"
+	// SandboxRemoved is emitted once a pod sandbox got removed.
+	SandboxRemoved Type = "sandbox_removed"
+
+	// ContainerCreated is emitted once a container got created.
+	ContainerCreated Type = "container_created"
+	// ContainerStarted is emitted once a container got started.
+	ContainerStarted Type = "container_started"
+	// ContainerExited is emitted once the process of a container exited.
+	ContainerExited Type = "container_exited"
+	// ContainerOOMKilled is emitted when a container got killed by the OOM
+	// killer.
+	ContainerOOMKilled Type = "container_oom_killed"
+	// ContainerRemoved is emitted once a container got removed.
+	ContainerRemoved Type = "container_removed"
+
+	// ImagePulled is emitted once an image got pulled.
+	ImagePulled Type = "image_pulled"
+	// ImageRemoved is emitted once an image got removed.
+	ImageRemoved Type = "image_removed"
+)
+
+// Types lists all the known event types.
+var Types = []Type{
+	SandboxCreated,
+	SandboxStarted,
+	SandboxStopped,
+	SandboxRemoved,
+	ContainerCreated,
+	ContainerStarted,
+	ContainerExited,
+	ContainerOOMKilled,
+	ContainerRemoved,
+	ImagePulled,
+	ImageRemoved,
+}
+
+// subscriberBufferSize is the number of events buffered for a subscriber
+// before it's considered too slow and gets dropped.
+const subscriberBufferSize = 128
+
+// Event is a lifecycle event of a sandbox, a container or an image.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// ID is the ID of the sandbox, container or image the event is about.
+	ID string `json:"id"`
+	// Name is the name of the sandbox or container, or the image reference.
+	Name         string `json:"name,omitempty"`
+	PodSandboxID string `json:"pod_sandbox_id,omitempty"`
+	PodName      string `json:"pod_name,omitempty"`
+	PodNamespace string `json:"pod_namespace,omitempty"`
+	Image        string `json:"image,omitempty"`
+	// ExitCode is only set for container_exited events.
+	ExitCode *int32 `json:"exit_code,omitempty"`
+}
+
+// Filter selects the events a subscriber receives. Empty fields match any
+// event.
+type Filter struct {
+	// Pod matches either the ID or the name of the pod sandbox.
+	Pod       string
+	Namespace string
+	Types     []Type
+}
+
+// Match returns whether the event passes the filter.
+func (f *Filter) Match(e *Event) bool {
+	if f.Pod != "" && f.Pod != e.PodSandboxID && f.Pod != e.PodName {
+		return false
+	}
+	if f.Namespace != "" && f.Namespace != e.PodNamespace {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+}
+
+// Bus dispatches the published events to its subscribers.
+type Bus struct {
+	lock        sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+// NewBus creates a new event bus.
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish sends the event to all the subscribers it matches. Publish never
+// blocks: subscribers not keeping up get their channel closed, and are
+// expected to subscribe again.
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	logrus.Debugf("publishing event %s for %s", e.Type, e.ID)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for sub := range b.subscribers {
+		if !sub.filter.Match(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			logrus.Warnf("dropping slow event subscriber")
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events matching the filter, and
+// a function to call to unsubscribe.
+func (b *Bus) Subscribe(filter Filter) (<-chan Event, func()) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan Event, subscriberBufferSize),
+	}
+
+	b.lock.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.lock.Unlock()
+
+	cancel := func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, cancel
+}
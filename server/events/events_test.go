@@ -0,0 +1,72 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	e := &Event{
+		Type:         ContainerStarted,
+		ID:           "ctrid",
+		PodSandboxID: "podid",
+		PodName:      "podname",
+		PodNamespace: "default",
+	}
+	for _, tc := range []struct {
+		filter Filter
+		match  bool
+	}{
+		{Filter{}, true},
+		{Filter{Pod: "podid"}, true},
+		{Filter{Pod: "podname"}, true},
+		{Filter{Pod: "otherpod"}, false},
+		{Filter{Namespace: "default"}, true},
+		{Filter{Namespace: "kube-system"}, false},
+		{Filter{Types: []Type{ContainerCreated, ContainerStarted}}, true},
+		{Filter{Types: []Type{ContainerExited}}, false},
+		{Filter{Pod: "podname", Namespace: "kube-system"}, false},
+	} {
+		if match := tc.filter.Match(e); match != tc.match {
+			t.Errorf("expected match of %+v to be %v, got %v", tc.filter, tc.match, match)
+		}
+	}
+}
+
+func TestBusPublish(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe(Filter{Types: []Type{ImagePulled}})
+
+	bus.Publish(Event{Type: ImageRemoved, ID: "removed"})
+	bus.Publish(Event{Type: ImagePulled, ID: "pulled"})
+
+	e := <-ch
+	if e.ID != "pulled" {
+		t.Fatalf("expected event for %q, got %q", "pulled", e.ID)
+	}
+	if e.Time.IsZero() {
+		t.Fatal("expected event time to be set")
+	}
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed after cancel")
+	}
+	// Cancelling twice must be harmless.
+	cancel()
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe(Filter{})
+	defer cancel()
+
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		bus.Publish(Event{Type: ContainerExited})
+	}
+	for i := 0; i < subscriberBufferSize; i++ {
+		<-ch
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected slow subscriber channel to be closed")
+	}
+}
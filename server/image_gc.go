@@ -0,0 +1,277 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	cstorage "github.com/containers/storage"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/kubernetes-incubator/cri-o/server/events"
+	"github.com/kubernetes-incubator/cri-o/server/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// imageGCInterval is how often the image filesystem usage is checked against
+// the image garbage collection thresholds.
+const imageGCInterval = time.Minute
+
+// imageGCStateFile is the name of the file, in the storage root, the last
+// use of the images gets persisted to.
+const imageGCStateFile = "image-gc.json"
+
+// imageGC keeps track of the last use of the images by CreateContainer, so
+// that the image garbage collection removes the least recently used ones
+// first. Images never used are ordered by the time they got pulled.
+type imageGC struct {
+	// inUse is held for reading by CreateContainer from the resolution of
+	// the image until the container got created from it, and for writing
+	// by the image garbage collection while it checks that an image is
+	// unused and removes it.
+	inUse sync.RWMutex
+	// lock protects lastUsed, persisted to path unless it's empty.
+	lock     sync.Mutex
+	lastUsed map[string]time.Time
+	path     string
+	minAge   time.Duration
+}
+
+func newImageGC(minAge time.Duration, path string) *imageGC {
+	g := &imageGC{
+		lastUsed: make(map[string]time.Time),
+		path:     path,
+		minAge:   minAge,
+	}
+	if path == "" {
+		return g
+	}
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &g.lastUsed)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		logrus.Warnf("failed to load the last use of the images from %s: %v", path, err)
+		g.lastUsed = make(map[string]time.Time)
+	}
+	return g
+}
+
+// save persists the last use of the images, with the lock held.
+func (g *imageGC) save() {
+	if g.path == "" {
+		return
+	}
+	data, err := json.Marshal(g.lastUsed)
+	if err == nil {
+		err = ioutils.AtomicWriteFile(g.path, data, 0600)
+	}
+	if err != nil {
+		logrus.Warnf("failed to save the last use of the images to %s: %v", g.path, err)
+	}
+}
+
+// recordUse records that a container got created from the image.
+func (g *imageGC) recordUse(imageID string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.lastUsed[imageID] = time.Now()
+	g.save()
+}
+
+func (g *imageGC) forget(imageID string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.lastUsed, imageID)
+	g.save()
+}
+
+// imageGCCandidate is an image the image garbage collection can remove.
+type imageGCCandidate struct {
+	id       string
+	names    []string
+	lastUsed time.Time
+}
+
+// candidates returns the images which can be removed, least recently used
+// first: the ones neither used by a container nor pinned, and neither pulled
+// nor used for the minimum age. The last use of the images which got removed
+// otherwise is forgotten.
+func (g *imageGC) candidates(images []cstorage.Image, containers []cstorage.Container, pinned map[string]bool, now time.Time) []imageGCCandidate {
+	inUse := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		inUse[c.ImageID] = true
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	present := make(map[string]bool, len(images))
+	candidates := []imageGCCandidate{}
+	for _, img := range images {
+		present[img.ID] = true
+		if inUse[img.ID] || pinned[img.ID] {
+			continue
+		}
+		lastUsed := img.Created
+		if t, ok := g.lastUsed[img.ID]; ok && t.After(lastUsed) {
+			lastUsed = t
+		}
+		if now.Sub(lastUsed) < g.minAge {
+			continue
+		}
+		candidates = append(candidates, imageGCCandidate{
+			id:       img.ID,
+			names:    img.Names,
+			lastUsed: lastUsed,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+
+	removed := false
+	for id := range g.lastUsed {
+		if !present[id] {
+			delete(g.lastUsed, id)
+			removed = true
+		}
+	}
+	if removed {
+		g.save()
+	}
+	return candidates
+}
+
+// StartImageGC starts a routine that removes the least recently used images
+// whenever the image filesystem usage goes above the high threshold, until
+// it goes below the low threshold.
+func (s *Server) StartImageGC() {
+	if s.config.ImageGCHighThreshold == 0 {
+		return
+	}
+	ticker := time.NewTicker(imageGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.garbageCollectImages()
+		case <-s.monitorsChan:
+			logrus.Debug("closing image garbage collection...")
+			return
+		}
+	}
+}
+
+// pinnedImages returns the IDs of the images which must not be removed.
+func (s *Server) pinnedImages() map[string]bool {
+	store := s.StorageImageServer().GetStore()
+	pinned := make(map[string]bool)
+	for _, image := range append([]string{s.config.PauseImage}, s.config.PinnedImages...) {
+		names, err := s.StorageImageServer().ResolveNames(image)
+		if err != nil {
+			names = []string{image}
+		}
+		for _, name := range names {
+			if img, err := store.Image(name); err == nil {
+				pinned[img.ID] = true
+			}
+		}
+	}
+	return pinned
+}
+
+// removeUnusedImage removes the image unless a container got created from
+// it since the candidates got listed, and returns whether it got removed.
+// CreateContainer can't resolve the image to create a container from it
+// until then.
+func (s *Server) removeUnusedImage(id string) (bool, error) {
+	s.imageGC.inUse.Lock()
+	defer s.imageGC.inUse.Unlock()
+	containers, err := s.StorageImageServer().GetStore().Containers()
+	if err != nil {
+		return false, err
+	}
+	for _, c := range containers {
+		if c.ImageID == id {
+			return false, nil
+		}
+	}
+	if err := s.StorageImageServer().RemoveImage(s.ImageContext(), id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Server) garbageCollectImages() {
+	store := s.StorageImageServer().GetStore()
+	used, capacity, err := imageFsUsage(store.GraphRoot())
+	if err != nil {
+		logrus.Warnf("failed to get the image filesystem usage: %v", err)
+		return
+	}
+	if capacity == 0 || used*100 < capacity*uint64(s.config.ImageGCHighThreshold) {
+		return
+	}
+
+	start := time.Now()
+	target := capacity * uint64(s.config.ImageGCLowThreshold) / 100
+	usedBefore := used
+	logrus.Infof("image filesystem usage is %d%%, above the %d%% high threshold, removing unused images down to %d%%",
+		used*100/capacity, s.config.ImageGCHighThreshold, s.config.ImageGCLowThreshold)
+
+	images, err := store.Images()
+	if err != nil {
+		logrus.Warnf("failed to list images for the image garbage collection: %v", err)
+		return
+	}
+	containers, err := store.Containers()
+	if err != nil {
+		logrus.Warnf("failed to list containers for the image garbage collection: %v", err)
+		return
+	}
+
+	removed := 0
+	for _, candidate := range s.imageGC.candidates(images, containers, s.pinnedImages(), start) {
+		if used <= target {
+			break
+		}
+		ok, err := s.removeUnusedImage(candidate.id)
+		if err != nil {
+			logrus.Warnf("image garbage collection failed to remove image %s: %v", candidate.id, err)
+			continue
+		}
+		if !ok {
+			logrus.Debugf("image garbage collection skipped image %s, a container got created from it", candidate.id)
+			continue
+		}
+		removed++
+		s.imageGC.forget(candidate.id)
+		name := ""
+		if len(candidate.names) > 0 {
+			name = candidate.names[0]
+		}
+		s.publishImageEvent(events.ImageRemoved, candidate.id, name)
+		logrus.Infof("image garbage collection removed image %s %v, last used %v", candidate.id, candidate.names, candidate.lastUsed)
+
+		if used, _, err = imageFsUsage(store.GraphRoot()); err != nil {
+			logrus.Warnf("failed to get the image filesystem usage: %v", err)
+			break
+		}
+	}
+
+	var freed uint64
+	if used < usedBefore {
+		freed = usedBefore - used
+	}
+	metrics.ImageGCRuns.Inc()
+	metrics.ImageGCRemovedImages.Add(float64(removed))
+	metrics.ImageGCFreedBytes.Add(float64(freed))
+	metrics.ImageGCDuration.Observe(time.Since(start).Seconds())
+	logrus.Infof("image garbage collection removed %d images and freed %d bytes in %v", removed, freed, time.Since(start))
+	if used > target {
+		logrus.Warnf("image garbage collection couldn't free the image filesystem down to the %d%% low threshold, it's still at %d%%",
+			s.config.ImageGCLowThreshold, used*100/capacity)
+	}
+}
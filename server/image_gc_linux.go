@@ -0,0 +1,19 @@
+// +build linux
+
+package server
+
+import (
+	"syscall"
+)
+
+// imageFsUsage returns the used and total bytes of the filesystem holding
+// path, not counting the blocks reserved to the root user, as df does.
+func imageFsUsage(path string) (used uint64, capacity uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	used = (st.Blocks - st.Bfree) * uint64(st.Bsize)
+	capacity = used + st.Bavail*uint64(st.Bsize)
+	return used, capacity, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cstorage "github.com/containers/storage"
+)
+
+func TestImageGCCandidates(t *testing.T) {
+	now := time.Now()
+	gc := newImageGC(time.Hour, "")
+	gc.lastUsed["used-recently"] = now.Add(-time.Minute)
+	gc.lastUsed["used-yesterday"] = now.Add(-24 * time.Hour)
+	gc.lastUsed["removed"] = now.Add(-time.Minute)
+
+	images := []cstorage.Image{
+		{ID: "pulled-last-week", Created: now.Add(-7 * 24 * time.Hour)},
+		{ID: "used-recently", Created: now.Add(-7 * 24 * time.Hour)},
+		{ID: "used-yesterday", Created: now.Add(-7 * 24 * time.Hour)},
+		{ID: "pulled-recently", Created: now.Add(-time.Minute)},
+		{ID: "pulled-two-days-ago", Created: now.Add(-48 * time.Hour)},
+		{ID: "running", Created: now.Add(-30 * 24 * time.Hour)},
+		{ID: "pinned", Created: now.Add(-30 * 24 * time.Hour)},
+	}
+	containers := []cstorage.Container{
+		{ID: "container", ImageID: "running"},
+	}
+	pinned := map[string]bool{"pinned": true}
+
+	candidates := gc.candidates(images, containers, pinned, now)
+	expected := []string{"pulled-last-week", "pulled-two-days-ago", "used-yesterday"}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates, got %+v", len(expected), candidates)
+	}
+	for i, id := range expected {
+		if candidates[i].id != id {
+			t.Errorf("expected candidate %d to be %s, got %s", i, id, candidates[i].id)
+		}
+	}
+	if _, ok := gc.lastUsed["removed"]; ok {
+		t.Error("expected the last use of the removed image to be forgotten")
+	}
+}
+
+func TestImageGCPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-gc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, imageGCStateFile)
+
+	gc := newImageGC(time.Hour, path)
+	gc.recordUse("used")
+	gc.recordUse("removed")
+	gc.forget("removed")
+
+	restarted := newImageGC(time.Hour, path)
+	if len(restarted.lastUsed) != 1 || !restarted.lastUsed["used"].Equal(gc.lastUsed["used"]) {
+		t.Errorf("expected the last use %v to be loaded, got %v", gc.lastUsed, restarted.lastUsed)
+	}
+}
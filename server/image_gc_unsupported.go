@@ -0,0 +1,11 @@
+// +build !linux
+
+package server
+
+import (
+	"fmt"
+)
+
+func imageFsUsage(path string) (uint64, uint64, error) {
+	return 0, 0, fmt.Errorf("image garbage collection is not supported on this platform")
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/containers/image/copy"
 	"github.com/containers/image/types"
 	"github.com/kubernetes-incubator/cri-o/pkg/storage"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
+	"github.com/kubernetes-incubator/cri-o/server/events"
 	"github.com/kubernetes-incubator/cri-o/server/useragent"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -34,6 +36,19 @@ func (s *Server) PullImage(ctx context.Context, req *pb.PullImageRequest) (resp
 		images []string
 		pulled string
 	)
+	record := &audit.Record{
+		Operation:    audit.PullImage,
+		PodName:      req.GetSandboxConfig().GetMetadata().GetName(),
+		PodNamespace: req.GetSandboxConfig().GetMetadata().GetNamespace(),
+		Image:        image,
+		AuthIdentity: pullAuthIdentity(req.GetAuth()),
+	}
+	defer func() {
+		if pulled != "" {
+			record.Image = pulled
+		}
+		s.recordAudit(ctx, record, err)
+	}()
 	images, err = s.StorageImageServer().ResolveNames(image)
 	if err != nil {
 		return nil, err
@@ -77,12 +92,18 @@ func (s *Server) PullImage(ctx context.Context, req *pb.PullImageRequest) (resp
 			continue
 		}
 
+		// The layers of the image are known before the pull when it gets
+		// prepared to be compared with the one in store, and otherwise
+		// learned as their download gets reported.
+		var layers []types.BlobInfo
+
 		// let's be smart, docker doesn't repull if image already exists.
 		var storedImage *storage.ImageResult
 		storedImage, err = s.StorageImageServer().ImageStatus(s.ImageContext(), img)
 		if err == nil {
 			tmpImg, err := s.StorageImageServer().PrepareImage(s.ImageContext(), img, options)
 			if err == nil {
+				layers = tmpImg.LayerInfos()
 				tmpImgConfigDigest := tmpImg.ConfigInfo().Digest
 				if tmpImgConfigDigest.String() == "" {
 					// this means we are playing with a schema1 image, in which
@@ -97,7 +118,12 @@ func (s *Server) PullImage(ctx context.Context, req *pb.PullImageRequest) (resp
 			logrus.Debugf("image in store has different ID, re-pulling %s", img)
 		}
 
+		pull, progress := s.pulls.start(img, layers)
+		options.Progress = progress
+		options.ProgressInterval = pullProgressInterval
 		_, err = s.StorageImageServer().PullImage(s.ImageContext(), img, options)
+		close(progress)
+		s.pulls.finish(pull, err)
 		if err != nil {
 			logrus.Debugf("error pulling image %s: %v", img, err)
 			continue
@@ -116,6 +142,7 @@ func (s *Server) PullImage(ctx context.Context, req *pb.PullImageRequest) (resp
 	if len(status.RepoDigests) > 0 {
 		imageRef = status.RepoDigests[0]
 	}
+	s.publishImageEvent(events.ImagePulled, status.ID, pulled)
 	resp = &pb.PullImageResponse{
 		ImageRef: imageRef,
 	}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containers/image/docker/reference"
+	cimage "github.com/containers/image/types"
+	"github.com/kubernetes-incubator/cri-o/server/metrics"
+	"github.com/kubernetes-incubator/cri-o/types"
+	"github.com/opencontainers/go-digest"
+)
+
+// pullProgressInterval is how often the progress of blob downloads gets
+// reported while pulling an image.
+const pullProgressInterval = time.Second
+
+// imagePull tracks the progress of an image pull.
+type imagePull struct {
+	lock     sync.Mutex
+	image    string
+	registry string
+	started  time.Time
+	// waiters is the number of PullImage calls waiting for the pull, the
+	// concurrent pulls of an image being done once. It's protected by the
+	// lock of the tracker.
+	waiters int
+	// layers lists the layers of the image in order, either known before
+	// the pull or as their download gets reported.
+	layers []digest.Digest
+	sizes  map[digest.Digest]int64
+	// offsets holds the number of bytes downloaded by blob.
+	offsets map[digest.Digest]uint64
+}
+
+// pullTracker keeps track of the image pulls in progress.
+type pullTracker struct {
+	lock sync.Mutex
+	// pulls holds the pulls in progress by image.
+	pulls map[string]*imagePull
+}
+
+func newPullTracker() *pullTracker {
+	return &pullTracker{
+		pulls: make(map[string]*imagePull),
+	}
+}
+
+// registryOf returns the registry an image gets pulled from.
+func registryOf(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// start starts tracking the pull of the image, whose layers are given if
+// known, or joins the pull of the image already in progress. The returned
+// channel receives the progress reports of the pull, and must be closed once
+// it's done.
+func (t *pullTracker) start(image string, layers []cimage.BlobInfo) (*imagePull, chan cimage.ProgressProperties) {
+	t.lock.Lock()
+	pull, ok := t.pulls[image]
+	if !ok {
+		pull = &imagePull{
+			image:    image,
+			registry: registryOf(image),
+			started:  time.Now(),
+			sizes:    make(map[digest.Digest]int64),
+			offsets:  make(map[digest.Digest]uint64),
+		}
+		t.pulls[image] = pull
+	}
+	pull.waiters++
+	t.lock.Unlock()
+	if !ok {
+		metrics.ImagePullsInProgress.WithLabelValues(pull.registry).Inc()
+	}
+	pull.addLayers(layers)
+
+	progress := make(chan cimage.ProgressProperties)
+	go func() {
+		for p := range progress {
+			pull.update(p)
+		}
+	}()
+	return pull, progress
+}
+
+// finish stops tracking the pull once all its waiters are done.
+func (t *pullTracker) finish(pull *imagePull, err error) {
+	t.lock.Lock()
+	pull.waiters--
+	if pull.waiters > 0 {
+		t.lock.Unlock()
+		return
+	}
+	delete(t.pulls, pull.image)
+	t.lock.Unlock()
+
+	if err == nil {
+		pull.complete()
+	}
+	metrics.ImagePullsInProgress.WithLabelValues(pull.registry).Dec()
+	metrics.ImagePullDuration.WithLabelValues(pull.registry).Observe(time.Since(pull.started).Seconds())
+}
+
+// list returns the progress of the pulls, oldest first.
+func (t *pullTracker) list() []types.ImagePullInfo {
+	t.lock.Lock()
+	pulls := make([]*imagePull, 0, len(t.pulls))
+	for _, pull := range t.pulls {
+		pulls = append(pulls, pull)
+	}
+	t.lock.Unlock()
+
+	sort.Slice(pulls, func(i, j int) bool {
+		return pulls[i].started.Before(pulls[j].started)
+	})
+	infos := make([]types.ImagePullInfo, 0, len(pulls))
+	for _, pull := range pulls {
+		infos = append(infos, pull.info())
+	}
+	return infos
+}
+
+// addLayers adds the layers the pull doesn't know about yet.
+func (p *imagePull) addLayers(layers []cimage.BlobInfo) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, layer := range layers {
+		p.addLayer(layer)
+	}
+}
+
+func (p *imagePull) addLayer(layer cimage.BlobInfo) {
+	if _, ok := p.sizes[layer.Digest]; ok {
+		return
+	}
+	p.layers = append(p.layers, layer.Digest)
+	p.sizes[layer.Digest] = layer.Size
+}
+
+func (p *imagePull) update(progress cimage.ProgressProperties) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	blob := progress.Artifact.Digest
+	p.addLayer(progress.Artifact)
+	if progress.Offset > p.offsets[blob] {
+		metrics.ImagePullBytes.WithLabelValues(p.registry).Add(float64(progress.Offset - p.offsets[blob]))
+		p.offsets[blob] = progress.Offset
+	}
+}
+
+// complete accounts for the end of the blob downloads, which don't get
+// reported.
+func (p *imagePull) complete() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for blob, offset := range p.offsets {
+		size, ok := p.sizes[blob]
+		if ok && size > 0 && uint64(size) > offset {
+			metrics.ImagePullBytes.WithLabelValues(p.registry).Add(float64(uint64(size) - offset))
+			p.offsets[blob] = uint64(size)
+		}
+	}
+}
+
+func (p *imagePull) info() types.ImagePullInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	info := types.ImagePullInfo{
+		Image:          p.image,
+		Registry:       p.registry,
+		StartedTime:    p.started.UnixNano(),
+		ElapsedSeconds: time.Since(p.started).Seconds(),
+		Layers:         []types.LayerPullInfo{},
+	}
+	for _, offset := range p.offsets {
+		info.DownloadedBytes += offset
+	}
+	for _, layer := range p.layers {
+		size := p.sizes[layer]
+		offset := p.offsets[layer]
+		if size <= 0 || offset < uint64(size) {
+			info.LayersPending++
+		}
+		info.Layers = append(info.Layers, types.LayerPullInfo{
+			Digest:          layer.String(),
+			Size:            size,
+			DownloadedBytes: offset,
+		})
+	}
+	return info
+}
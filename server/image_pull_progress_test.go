@@ -0,0 +1,104 @@
+package server
+
+import (
+	"testing"
+
+	cimage "github.com/containers/image/types"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestPullTracker(t *testing.T) {
+	layer1 := digest.FromString("layer1")
+	layer2 := digest.FromString("layer2")
+	tracker := newPullTracker()
+
+	pull, progress := tracker.start("quay.io/crio/redis:alpine", []cimage.BlobInfo{
+		{Digest: layer1, Size: 100},
+		{Digest: layer2, Size: 200},
+	})
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer1}, Offset: 100}
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer2}, Offset: 50}
+	// Make sure the reports got processed.
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer2}, Offset: 50}
+
+	pulls := tracker.list()
+	if len(pulls) != 1 {
+		t.Fatalf("expected 1 pull in progress, got %d", len(pulls))
+	}
+	info := pulls[0]
+	if info.Registry != "quay.io" {
+		t.Fatalf("expected registry quay.io, got %q", info.Registry)
+	}
+	if info.DownloadedBytes != 150 {
+		t.Fatalf("expected 150 bytes downloaded, got %d", info.DownloadedBytes)
+	}
+	if info.LayersPending != 1 {
+		t.Fatalf("expected 1 layer pending, got %d", info.LayersPending)
+	}
+	if len(info.Layers) != 2 || info.Layers[1].Digest != layer2.String() || info.Layers[1].DownloadedBytes != 50 {
+		t.Fatalf("unexpected layers progress: %+v", info.Layers)
+	}
+
+	close(progress)
+	tracker.finish(pull, nil)
+	if pulls := tracker.list(); len(pulls) != 0 {
+		t.Fatalf("expected no pull in progress, got %d", len(pulls))
+	}
+	if info := pull.info(); info.LayersPending != 0 || info.DownloadedBytes != 300 {
+		t.Fatalf("expected completed pull, got %+v", info)
+	}
+}
+
+func TestPullTrackerSharedPull(t *testing.T) {
+	layer1 := digest.FromString("layer1")
+	layer2 := digest.FromString("layer2")
+	tracker := newPullTracker()
+
+	pull, progress := tracker.start("quay.io/crio/redis:alpine", nil)
+	// A concurrent pull of the same image waits for the first one.
+	shared, sharedProgress := tracker.start("quay.io/crio/redis:alpine", nil)
+	if shared != pull {
+		t.Fatal("expected the pulls of the same image to be tracked together")
+	}
+	close(sharedProgress)
+
+	// The layers are learned from the reports.
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer1, Size: 100}, Offset: 10}
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer2, Size: 200}, Offset: 20}
+	// Make sure the reports got processed.
+	progress <- cimage.ProgressProperties{Artifact: cimage.BlobInfo{Digest: layer2, Size: 200}, Offset: 20}
+
+	pulls := tracker.list()
+	if len(pulls) != 1 {
+		t.Fatalf("expected 1 pull in progress, got %d", len(pulls))
+	}
+	if info := pulls[0]; info.DownloadedBytes != 30 || info.LayersPending != 2 || len(info.Layers) != 2 || info.Layers[0].Digest != layer1.String() {
+		t.Fatalf("unexpected pull progress: %+v", info)
+	}
+
+	close(progress)
+	tracker.finish(pull, nil)
+	if pulls := tracker.list(); len(pulls) != 1 {
+		t.Fatalf("expected the pull to be tracked until its waiters are done, got %d pulls", len(pulls))
+	}
+	tracker.finish(shared, nil)
+	if pulls := tracker.list(); len(pulls) != 0 {
+		t.Fatalf("expected no pull in progress, got %d", len(pulls))
+	}
+	if info := pull.info(); info.LayersPending != 0 || info.DownloadedBytes != 300 {
+		t.Fatalf("expected completed pull, got %+v", info)
+	}
+}
+
+func TestRegistryOf(t *testing.T) {
+	for image, registry := range map[string]string{
+		"quay.io/crio/redis:alpine": "quay.io",
+		"redis":                     "docker.io",
+		"localhost:5000/foo@sha256:0000000000000000000000000000000000000000000000000000000000000000": "localhost:5000",
+		"Invalid": "",
+	} {
+		if r := registryOf(image); r != registry {
+			t.Errorf("expected registry %q for %q, got %q", registry, image, r)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/kubernetes-incubator/cri-o/pkg/storage"
+	"github.com/kubernetes-incubator/cri-o/server/events"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -40,12 +41,17 @@ func (s *Server) RemoveImage(ctx context.Context, req *pb.RemoveImageRequest) (r
 		}
 	}
 	for _, img := range images {
+		var imageID string
+		if status, err := s.StorageImageServer().ImageStatus(s.ImageContext(), img); err == nil {
+			imageID = status.ID
+		}
 		err = s.StorageImageServer().UntagImage(s.ImageContext(), img)
 		if err != nil {
 			logrus.Debugf("error deleting image %s: %v", img, err)
 			continue
 		}
 		deleted = true
+		s.publishImageEvent(events.ImageRemoved, imageID, img)
 		break
 	}
 	if !deleted && err != nil {
@@ -68,6 +68,15 @@ func (s *Server) ImageStatus(ctx context.Context, req *pb.ImageStatusRequest) (r
 			resp.Image.Uid = &pb.Int64Value{Value: *uid}
 		}
 		resp.Image.Username = username
+		if req.Verbose {
+			info := map[string]interface{}{
+				"imageSpec": status.OCIConfig,
+			}
+			if status.SignatureVerification != nil {
+				info["signatureVerification"] = status.SignatureVerification
+			}
+			resp.Info = verboseInfo(info)
+		}
 		break
 	}
 	if lastErr != nil && resp == nil {
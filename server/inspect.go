@@ -73,24 +73,33 @@ func (s *Server) getContainerInfo(id string, getContainerFunc func(id string) *o
 		return types.ContainerInfo{}, errSandboxNotFound
 	}
 	image := ctr.Image()
+	var signatureVerification *types.SignatureVerification
 	if s.ContainerServer != nil && s.ContainerServer.StorageImageServer() != nil {
 		if status, err := s.ContainerServer.StorageImageServer().ImageStatus(&cimage.SystemContext{}, ctr.ImageRef()); err == nil {
 			image = status.Name
+			signatureVerification = status.SignatureVerification
 		}
 	}
+	var lastOOMKillTime int64
+	if !ctrState.LastOOMKill.IsZero() {
+		lastOOMKillTime = ctrState.LastOOMKill.UnixNano()
+	}
 	return types.ContainerInfo{
-		Name:            ctr.Name(),
-		Pid:             ctrState.Pid,
-		Image:           image,
-		ImageRef:        ctr.ImageRef(),
-		CreatedTime:     ctrState.Created.UnixNano(),
-		Labels:          ctr.Labels(),
-		Annotations:     ctr.Annotations(),
-		CrioAnnotations: ctr.CrioAnnotations(),
-		Root:            ctr.MountPoint(),
-		LogPath:         ctr.LogPath(),
-		Sandbox:         ctr.Sandbox(),
-		IP:              sb.IP(),
+		Name:                  ctr.Name(),
+		Pid:                   ctrState.Pid,
+		Image:                 image,
+		ImageRef:              ctr.ImageRef(),
+		CreatedTime:           ctrState.Created.UnixNano(),
+		Labels:                ctr.Labels(),
+		Annotations:           ctr.Annotations(),
+		CrioAnnotations:       ctr.CrioAnnotations(),
+		Root:                  ctr.MountPoint(),
+		LogPath:               ctr.LogPath(),
+		Sandbox:               ctr.Sandbox(),
+		IP:                    sb.IP(),
+		OOMKillCount:          ctrState.OOMKillCount,
+		LastOOMKillTime:       lastOOMKillTime,
+		SignatureVerification: signatureVerification,
 	}, nil
 
 }
@@ -135,5 +144,34 @@ func (s *Server) GetInfoMux() *bone.Mux {
 		w.Write(js)
 	}))
 
+	mux.Post("/containers/:id/checkpoint", http.HandlerFunc(s.serveContainerCheckpoint))
+	mux.Post("/containers/:id/restore", http.HandlerFunc(s.serveContainerRestore))
+
+	mux.Get("/oom", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(s.getOOMInfo())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	}))
+
+	mux.Get("/pulls", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(s.pulls.list())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	}))
+
+	mux.Get("/events", http.HandlerFunc(s.serveEvents))
+
+	mux.Get("/sessions", http.HandlerFunc(s.serveSessionRecordings))
+	mux.Get("/containers/:id/sessions", http.HandlerFunc(s.serveSessionRecordings))
+	mux.Get("/containers/:id/sessions/:session", http.HandlerFunc(s.serveSessionRecording))
+
 	return mux
 }
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// CRIOContainerCPUUsageKey is the key for the container CPU usage metrics.
+	CRIOContainerCPUUsageKey = "crio_container_cpu_usage_seconds_total"
+	// CRIOContainerMemoryUsageKey is the key for the container memory usage
+	// metrics.
+	CRIOContainerMemoryUsageKey = "crio_container_memory_usage_bytes"
+	// CRIOContainerMemoryLimitKey is the key for the container memory limit
+	// metrics.
+	CRIOContainerMemoryLimitKey = "crio_container_memory_limit_bytes"
+	// CRIOContainerNetworkReceiveKey is the key for the container received
+	// network bytes metrics.
+	CRIOContainerNetworkReceiveKey = "crio_container_network_receive_bytes_total"
+	// CRIOContainerNetworkTransmitKey is the key for the container
+	// transmitted network bytes metrics.
+	CRIOContainerNetworkTransmitKey = "crio_container_network_transmit_bytes_total"
+	// CRIOContainerBlockIOReadKey is the key for the container block IO read
+	// bytes metrics.
+	CRIOContainerBlockIOReadKey = "crio_container_blkio_read_bytes_total"
+	// CRIOContainerBlockIOWriteKey is the key for the container block IO
+	// written bytes metrics.
+	CRIOContainerBlockIOWriteKey = "crio_container_blkio_write_bytes_total"
+	// CRIOContainerPIDsKey is the key for the container process count
+	// metrics.
+	CRIOContainerPIDsKey = "crio_container_pids"
+	// CRIOContainerLogDroppedLinesKey is the key for the lines of container
+	// output dropped over the log rate limit metrics.
+	CRIOContainerLogDroppedLinesKey = "crio_container_log_dropped_lines_total"
+	// CRIOContainerLogDroppedBytesKey is the key for the bytes of container
+	// output dropped over the log rate limit metrics.
+	CRIOContainerLogDroppedBytesKey = "crio_container_log_dropped_bytes_total"
+)
+
+// containerLabels identify the containers by their ID as well as their names,
+// which a container being recreated shares with the previous one for a while.
+var containerLabels = []string{"namespace", "pod", "container", "id"}
+
+func newContainerDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("", subsystem, name), help, containerLabels, nil)
+}
+
+var (
+	containerCPUUsageDesc        = newContainerDesc(CRIOContainerCPUUsageKey, "Cumulative CPU time consumed by the container in seconds.")
+	containerMemoryUsageDesc     = newContainerDesc(CRIOContainerMemoryUsageKey, "Memory used by the container in bytes.")
+	containerMemoryLimitDesc     = newContainerDesc(CRIOContainerMemoryLimitKey, "Memory limit of the container in bytes.")
+	containerNetworkReceiveDesc  = newContainerDesc(CRIOContainerNetworkReceiveKey, "Cumulative bytes received over the network by the container.")
+	containerNetworkTransmitDesc = newContainerDesc(CRIOContainerNetworkTransmitKey, "Cumulative bytes transmitted over the network by the container.")
+	containerBlockIOReadDesc     = newContainerDesc(CRIOContainerBlockIOReadKey, "Cumulative bytes read from block devices by the container.")
+	containerBlockIOWriteDesc    = newContainerDesc(CRIOContainerBlockIOWriteKey, "Cumulative bytes written to block devices by the container.")
+	containerPIDsDesc            = newContainerDesc(CRIOContainerPIDsKey, "Number of processes running in the container.")
+	containerLogDroppedLinesDesc = newContainerDesc(CRIOContainerLogDroppedLinesKey, "Cumulative lines of output of the container dropped over its log rate limit.")
+	containerLogDroppedBytesDesc = newContainerDesc(CRIOContainerLogDroppedBytesKey, "Cumulative bytes of output of the container dropped over its log rate limit.")
+)
+
+// ContainerStats are the resource usage statistics of a container, along
+// with its ID and names.
+type ContainerStats struct {
+	ID          string
+	Namespace   string
+	Pod         string
+	Container   string
+	CPUNano     uint64
+	MemUsage    uint64
+	MemLimit    uint64
+	NetInput    uint64
+	NetOutput   uint64
+	BlockInput  uint64
+	BlockOutput uint64
+	PIDs        uint64
+
+	LogDroppedLines uint64
+	LogDroppedBytes uint64
+}
+
+// ContainerStatsCollector collects the resource usage metrics of the
+// containers each time it's scraped.
+type ContainerStatsCollector struct {
+	list func() []*ContainerStats
+}
+
+// NewContainerStatsCollector creates a collector exporting the statistics
+// returned by list.
+func NewContainerStatsCollector(list func() []*ContainerStats) *ContainerStatsCollector {
+	return &ContainerStatsCollector{list: list}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ContainerStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- containerCPUUsageDesc
+	ch <- containerMemoryUsageDesc
+	ch <- containerMemoryLimitDesc
+	ch <- containerNetworkReceiveDesc
+	ch <- containerNetworkTransmitDesc
+	ch <- containerBlockIOReadDesc
+	ch <- containerBlockIOWriteDesc
+	ch <- containerPIDsDesc
+	ch <- containerLogDroppedLinesDesc
+	ch <- containerLogDroppedBytesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *ContainerStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stats := range c.list() {
+		labels := []string{stats.Namespace, stats.Pod, stats.Container, stats.ID}
+		ch <- prometheus.MustNewConstMetric(containerCPUUsageDesc, prometheus.CounterValue, float64(stats.CPUNano)/1e9, labels...)
+		ch <- prometheus.MustNewConstMetric(containerMemoryUsageDesc, prometheus.GaugeValue, float64(stats.MemUsage), labels...)
+		ch <- prometheus.MustNewConstMetric(containerMemoryLimitDesc, prometheus.GaugeValue, float64(stats.MemLimit), labels...)
+		ch <- prometheus.MustNewConstMetric(containerNetworkReceiveDesc, prometheus.CounterValue, float64(stats.NetInput), labels...)
+		ch <- prometheus.MustNewConstMetric(containerNetworkTransmitDesc, prometheus.CounterValue, float64(stats.NetOutput), labels...)
+		ch <- prometheus.MustNewConstMetric(containerBlockIOReadDesc, prometheus.CounterValue, float64(stats.BlockInput), labels...)
+		ch <- prometheus.MustNewConstMetric(containerBlockIOWriteDesc, prometheus.CounterValue, float64(stats.BlockOutput), labels...)
+		ch <- prometheus.MustNewConstMetric(containerPIDsDesc, prometheus.GaugeValue, float64(stats.PIDs), labels...)
+		ch <- prometheus.MustNewConstMetric(containerLogDroppedLinesDesc, prometheus.CounterValue, float64(stats.LogDroppedLines), labels...)
+		ch <- prometheus.MustNewConstMetric(containerLogDroppedBytesDesc, prometheus.CounterValue, float64(stats.LogDroppedBytes), labels...)
+	}
+}
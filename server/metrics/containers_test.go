@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestContainerStatsCollector(t *testing.T) {
+	collector := NewContainerStatsCollector(func() []*ContainerStats {
+		return []*ContainerStats{{
+			ID:        "ctrid",
+			Namespace: "default",
+			Pod:       "podname",
+			Container: "ctrname",
+			CPUNano:   1500000000,
+			MemUsage:  4096,
+			PIDs:      3,
+
+			LogDroppedLines: 7,
+		}}
+	})
+
+	ch := make(chan prometheus.Metric, 16)
+	collector.Collect(ch)
+	close(ch)
+
+	values := make(map[string]float64)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		labels := make(map[string]string)
+		for _, l := range metric.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if labels["namespace"] != "default" || labels["pod"] != "podname" || labels["container"] != "ctrname" || labels["id"] != "ctrid" {
+			t.Fatalf("unexpected labels %v", labels)
+		}
+		switch {
+		case metric.Counter != nil:
+			values[m.Desc().String()] = metric.Counter.GetValue()
+		case metric.Gauge != nil:
+			values[m.Desc().String()] = metric.Gauge.GetValue()
+		}
+	}
+	if len(values) != 10 {
+		t.Fatalf("expected 10 metrics, got %d", len(values))
+	}
+	if v := values[containerCPUUsageDesc.String()]; v != 1.5 {
+		t.Fatalf("expected 1.5s of CPU usage, got %v", v)
+	}
+	if v := values[containerMemoryUsageDesc.String()]; v != 4096 {
+		t.Fatalf("expected 4096 bytes of memory usage, got %v", v)
+	}
+	if v := values[containerPIDsDesc.String()]; v != 3 {
+		t.Fatalf("expected 3 processes, got %v", v)
+	}
+	if v := values[containerLogDroppedLinesDesc.String()]; v != 7 {
+		t.Fatalf("expected 7 log lines dropped, got %v", v)
+	}
+}
+
+func TestContainerStatsCollectorRecreated(t *testing.T) {
+	// The container being recreated is listed along with the previous one,
+	// under the same names.
+	collector := NewContainerStatsCollector(func() []*ContainerStats {
+		return []*ContainerStats{
+			{ID: "oldid", Namespace: "default", Pod: "podname", Container: "ctrname"},
+			{ID: "newid", Namespace: "default", Pod: "podname", Container: "ctrname"},
+		}
+	})
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if len(family.GetMetric()) != 2 {
+			t.Fatalf("expected the metrics of both containers in %s, got %v", family.GetName(), family.GetMetric())
+		}
+	}
+}
@@ -14,6 +14,29 @@ const (
 	CRIOOperationsLatencyKey = "crio_operations_latency_microseconds"
 	// CRIOOperationsErrorsKey is the key for the operation error metrics.
 	CRIOOperationsErrorsKey = "crio_operations_errors"
+	// CRIOContainerOOMKillsKey is the key for the container OOM kill metrics.
+	CRIOContainerOOMKillsKey = "crio_container_oom_kills_total"
+	// CRIOPodOOMKillsKey is the key for the pod OOM kill metrics.
+	CRIOPodOOMKillsKey = "crio_pod_oom_kills_total"
+	// ImagePullBytesKey is the key for the image pull bytes metrics.
+	ImagePullBytesKey = "image_pull_bytes_total"
+	// ImagePullsInProgressKey is the key for the in progress image pulls
+	// metrics.
+	ImagePullsInProgressKey = "image_pulls_in_progress"
+	// ImagePullDurationKey is the key for the image pull duration metrics.
+	ImagePullDurationKey = "image_pull_duration_seconds"
+	// ImageGCRunsKey is the key for the image garbage collection runs
+	// metrics.
+	ImageGCRunsKey = "image_gc_runs_total"
+	// ImageGCRemovedImagesKey is the key for the garbage collected images
+	// metrics.
+	ImageGCRemovedImagesKey = "image_gc_removed_images_total"
+	// ImageGCFreedBytesKey is the key for the bytes freed by the image
+	// garbage collection metrics.
+	ImageGCFreedBytesKey = "image_gc_freed_bytes_total"
+	// ImageGCDurationKey is the key for the image garbage collection
+	// duration metrics.
+	ImageGCDurationKey = "image_gc_duration_seconds"
 
 	// TODO(runcom):
 	// timeouts
@@ -51,6 +74,92 @@ var (
 		},
 		[]string{"operation_type"},
 	)
+	// CRIOContainerOOMKills collects the number of processes killed by the
+	// OOM killer in the memory cgroup of containers.
+	CRIOContainerOOMKills = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      CRIOContainerOOMKillsKey,
+			Help:      "Cumulative number of processes killed by the OOM killer. Broken down by container.",
+		},
+		[]string{"namespace", "pod", "container", "id"},
+	)
+	// CRIOPodOOMKills collects the number of processes killed by the OOM
+	// killer in the memory cgroup of pods.
+	CRIOPodOOMKills = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      CRIOPodOOMKillsKey,
+			Help:      "Cumulative number of processes killed by the OOM killer. Broken down by pod.",
+		},
+		[]string{"namespace", "pod", "id"},
+	)
+	// ImagePullBytes collects the number of bytes downloaded by image pulls
+	// by registry.
+	ImagePullBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      ImagePullBytesKey,
+			Help:      "Cumulative number of bytes downloaded by image pulls. Broken down by registry.",
+		},
+		[]string{"registry"},
+	)
+	// ImagePullsInProgress collects the number of image pulls in progress
+	// by registry.
+	ImagePullsInProgress = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      ImagePullsInProgressKey,
+			Help:      "Number of image pulls in progress. Broken down by registry.",
+		},
+		[]string{"registry"},
+	)
+	// ImagePullDuration collects the duration of image pulls by registry.
+	ImagePullDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      ImagePullDurationKey,
+			Help:      "Duration of image pulls in seconds. Broken down by registry.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 14),
+		},
+		[]string{"registry"},
+	)
+	// ImageGCRuns collects the number of image garbage collection runs.
+	ImageGCRuns = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      ImageGCRunsKey,
+			Help:      "Cumulative number of image garbage collection runs.",
+		},
+	)
+	// ImageGCRemovedImages collects the number of images removed by the
+	// image garbage collection.
+	ImageGCRemovedImages = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      ImageGCRemovedImagesKey,
+			Help:      "Cumulative number of images removed by the image garbage collection.",
+		},
+	)
+	// ImageGCFreedBytes collects the number of bytes freed on the image
+	// filesystem by the image garbage collection.
+	ImageGCFreedBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      ImageGCFreedBytesKey,
+			Help:      "Cumulative number of bytes freed on the image filesystem by the image garbage collection.",
+		},
+	)
+	// ImageGCDuration collects the duration of the image garbage collection
+	// runs.
+	ImageGCDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      ImageGCDurationKey,
+			Help:      "Duration of the image garbage collection runs in seconds.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14),
+		},
+	)
 )
 
 var registerMetrics sync.Once
@@ -61,6 +170,15 @@ func Register() {
 		prometheus.MustRegister(CRIOOperations)
 		prometheus.MustRegister(CRIOOperationsLatency)
 		prometheus.MustRegister(CRIOOperationsErrors)
+		prometheus.MustRegister(CRIOContainerOOMKills)
+		prometheus.MustRegister(CRIOPodOOMKills)
+		prometheus.MustRegister(ImagePullBytes)
+		prometheus.MustRegister(ImagePullsInProgress)
+		prometheus.MustRegister(ImagePullDuration)
+		prometheus.MustRegister(ImageGCRuns)
+		prometheus.MustRegister(ImageGCRemovedImages)
+		prometheus.MustRegister(ImageGCFreedBytes)
+		prometheus.MustRegister(ImageGCDuration)
 	})
 }
 
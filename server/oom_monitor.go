@@ -0,0 +1,292 @@
+package server
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/oom"
+	"github.com/kubernetes-incubator/cri-o/server/events"
+	"github.com/kubernetes-incubator/cri-o/server/metrics"
+	"github.com/kubernetes-incubator/cri-o/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// oomMonitorInterval is how often the OOM kill counters of the container and
+// pod memory cgroups are read.
+const oomMonitorInterval = 2 * time.Second
+
+// oomWatch tracks the OOM kill counter of a memory cgroup.
+type oomWatch struct {
+	cgroupPath string
+	// count is the last value read from the counter.
+	count uint64
+	// lastOOMKill is the time an increase of the counter was last seen.
+	lastOOMKill time.Time
+}
+
+// oomMonitor keeps track of the OOM kills in the memory cgroups of the
+// containers and pods. Unlike the oom file written by conmon, which only
+// tells whether the container process itself got killed, it counts every
+// process killed in the cgroup while the container keeps running.
+type oomMonitor struct {
+	lock       sync.Mutex
+	containers map[string]*oomWatch
+	pods       map[string]*oomWatch
+	// containerLabels and podLabels hold the label values of the OOM kill
+	// metrics of the containers and pods, by ID, so that their series get
+	// deleted once they're removed.
+	containerLabels map[string][]string
+	podLabels       map[string][]string
+}
+
+func newOOMMonitor() *oomMonitor {
+	return &oomMonitor{
+		containers:      make(map[string]*oomWatch),
+		pods:            make(map[string]*oomWatch),
+		containerLabels: make(map[string][]string),
+		podLabels:       make(map[string][]string),
+	}
+}
+
+// containerMetric returns the OOM kill counter of the container.
+func (m *oomMonitor) containerMetric(id, namespace, pod, name string) prometheus.Counter {
+	labels := []string{namespace, pod, name, id}
+	m.containerLabels[id] = labels
+	return metrics.CRIOContainerOOMKills.WithLabelValues(labels...)
+}
+
+// podMetric returns the OOM kill counter of the pod.
+func (m *oomMonitor) podMetric(id, namespace, pod string) prometheus.Counter {
+	labels := []string{namespace, pod, id}
+	m.podLabels[id] = labels
+	return metrics.CRIOPodOOMKills.WithLabelValues(labels...)
+}
+
+// deleteMetrics deletes the OOM kill counters of the containers and pods
+// which are no longer listed.
+func (m *oomMonitor) deleteMetrics(containers, pods map[string]bool) {
+	for id, labels := range m.containerLabels {
+		if !containers[id] {
+			metrics.CRIOContainerOOMKills.DeleteLabelValues(labels...)
+			delete(m.containerLabels, id)
+		}
+	}
+	for id, labels := range m.podLabels {
+		if !pods[id] {
+			metrics.CRIOPodOOMKills.DeleteLabelValues(labels...)
+			delete(m.podLabels, id)
+		}
+	}
+}
+
+func oomKillInfo(count uint64, last time.Time) types.OOMKillInfo {
+	info := types.OOMKillInfo{Count: count}
+	if !last.IsZero() {
+		info.LastOOMKillTime = last.UnixNano()
+	}
+	return info
+}
+
+// StartOOMMonitor starts a routine that periodically counts the OOM kills in
+// the memory cgroups of containers and pods.
+func (s *Server) StartOOMMonitor() {
+	ticker := time.NewTicker(oomMonitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkOOMKills()
+		case <-s.monitorsChan:
+			logrus.Debug("closing OOM monitor...")
+			return
+		}
+	}
+}
+
+func (s *Server) checkOOMKills() {
+	s.oomMonitor.lock.Lock()
+	defer s.oomMonitor.lock.Unlock()
+
+	seen := make(map[string]bool)
+	listedContainers := make(map[string]bool)
+	ctrs, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		logrus.Warnf("failed to list containers for the OOM monitor: %v", err)
+		return
+	}
+	for _, c := range ctrs {
+		listedContainers[c.ID()] = true
+		if s.checkContainerOOMKills(c) {
+			seen[c.ID()] = true
+		}
+	}
+	for id := range s.oomMonitor.containers {
+		if !seen[id] {
+			delete(s.oomMonitor.containers, id)
+		}
+	}
+
+	seen = make(map[string]bool)
+	listedPods := make(map[string]bool)
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		listedPods[sb.ID()] = true
+		if s.checkPodOOMKills(sb) {
+			seen[sb.ID()] = true
+		}
+	}
+	for id := range s.oomMonitor.pods {
+		if !seen[id] {
+			delete(s.oomMonitor.pods, id)
+		}
+	}
+
+	// The OOM kills of the exited containers stay in the metrics until the
+	// containers get removed.
+	s.oomMonitor.deleteMetrics(listedContainers, listedPods)
+}
+
+// checkExitedContainerOOMKills records the OOM kills of a container which
+// just exited and stops watching it. The memory cgroup is still around until
+// the container gets deleted, so the kill of the container process itself,
+// or of any other process right before it exited, gets counted. It returns
+// whether new OOM kills got recorded.
+func (s *Server) checkExitedContainerOOMKills(c *oci.Container) bool {
+	s.oomMonitor.lock.Lock()
+	defer s.oomMonitor.lock.Unlock()
+
+	count := s.Runtime().ContainerStatus(c).OOMKillCount
+	if !s.checkContainerOOMKills(c) {
+		delete(s.oomMonitor.containers, c.ID())
+	}
+	return s.Runtime().ContainerStatus(c).OOMKillCount > count
+}
+
+// checkContainerOOMKills records the new OOM kills of the container, and
+// returns whether it's still being watched. The OOM kill counter of a
+// container which is no longer running is read one last time.
+func (s *Server) checkContainerOOMKills(c *oci.Container) bool {
+	state := s.Runtime().ContainerStatus(c)
+	running := state.Status == oci.ContainerStateRunning || state.Status == oci.ContainerStatePaused
+
+	namespace, pod := "", ""
+	if sb := s.getSandbox(c.Sandbox()); sb != nil {
+		namespace, pod = sb.Namespace(), sb.KubeName()
+	}
+	metric := s.oomMonitor.containerMetric(c.ID(), namespace, pod, c.Metadata().GetName())
+
+	w, ok := s.oomMonitor.containers[c.ID()]
+	if !ok {
+		if !running {
+			return false
+		}
+		cgroupPath, err := oom.MemoryCgroupPath(state.Pid)
+		if err != nil {
+			logrus.Debugf("failed to get memory cgroup of container %s: %v", c.ID(), err)
+			return false
+		}
+		// The count restored from the container state already got
+		// recorded, but not in the metrics of this daemon.
+		w = &oomWatch{cgroupPath: cgroupPath, count: state.OOMKillCount}
+		s.oomMonitor.containers[c.ID()] = w
+		metric.Add(float64(w.count))
+	}
+
+	count, err := oom.ReadOOMKillCount(w.cgroupPath)
+	if err != nil {
+		logrus.Debugf("failed to read OOM kills of container %s: %v", c.ID(), err)
+		return running
+	}
+	if count <= w.count {
+		return running
+	}
+
+	logrus.Infof("%d process(es) of container %s killed by the OOM killer", count-w.count, c.ID())
+	c.AddOOMKills(count-w.count, time.Now())
+	metric.Add(float64(count - w.count))
+	w.count = count
+	if err := s.ContainerStateToDisk(c); err != nil {
+		logrus.Warnf("failed to save state of container %s: %v", c.ID(), err)
+	}
+	s.publishContainerEvent(events.ContainerOOMKilled, c)
+	return running
+}
+
+// checkPodOOMKills records the new OOM kills of the pod, and returns whether
+// it's still being watched.
+func (s *Server) checkPodOOMKills(sb *sandbox.Sandbox) bool {
+	if sb.CgroupParent() == "" {
+		// The infra container doesn't live in a pod cgroup.
+		return false
+	}
+	metric := s.oomMonitor.podMetric(sb.ID(), sb.Namespace(), sb.KubeName())
+
+	w, ok := s.oomMonitor.pods[sb.ID()]
+	if !ok {
+		infra := sb.InfraContainer()
+		if infra == nil {
+			return false
+		}
+		state := s.Runtime().ContainerStatus(infra)
+		if state.Status != oci.ContainerStateRunning {
+			return false
+		}
+		infraCgroupPath, err := oom.MemoryCgroupPath(state.Pid)
+		if err != nil {
+			logrus.Debugf("failed to get memory cgroup of pod %s: %v", sb.ID(), err)
+			return false
+		}
+		w = &oomWatch{cgroupPath: filepath.Dir(infraCgroupPath)}
+		s.oomMonitor.pods[sb.ID()] = w
+
+		// The pod cgroup may have been around before the daemon
+		// started, in which case we can't tell when its OOM kills
+		// happened.
+		if w.count, err = oom.ReadOOMKillCount(w.cgroupPath); err != nil {
+			logrus.Debugf("failed to read OOM kills of pod %s: %v", sb.ID(), err)
+		}
+		metric.Add(float64(w.count))
+		return true
+	}
+
+	count, err := oom.ReadOOMKillCount(w.cgroupPath)
+	if err != nil {
+		logrus.Debugf("failed to read OOM kills of pod %s: %v", sb.ID(), err)
+		return true
+	}
+	if count <= w.count {
+		return true
+	}
+
+	logrus.Infof("%d process(es) of pod %s killed by the OOM killer", count-w.count, sb.ID())
+	metric.Add(float64(count - w.count))
+	w.count = count
+	w.lastOOMKill = time.Now()
+	return true
+}
+
+// getOOMInfo returns the OOM kills of all the containers and pods.
+func (s *Server) getOOMInfo() types.OOMInfo {
+	info := types.OOMInfo{
+		Containers: make(map[string]types.OOMKillInfo),
+		Pods:       make(map[string]types.OOMKillInfo),
+	}
+	ctrs, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		logrus.Warnf("failed to list containers: %v", err)
+	}
+	for _, c := range ctrs {
+		state := s.Runtime().ContainerStatus(c)
+		info.Containers[c.ID()] = oomKillInfo(state.OOMKillCount, state.LastOOMKill)
+	}
+
+	s.oomMonitor.lock.Lock()
+	defer s.oomMonitor.lock.Unlock()
+	for id, w := range s.oomMonitor.pods {
+		info.Pods[id] = oomKillInfo(w.count, w.lastOOMKill)
+	}
+	return info
+}
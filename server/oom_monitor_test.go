@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/cri-o/server/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func collected(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric, 16)
+	c.Collect(ch)
+	close(ch)
+	return len(ch)
+}
+
+func TestOOMMonitorDeleteMetrics(t *testing.T) {
+	m := newOOMMonitor()
+	m.containerMetric("removedid", "default", "podname", "ctrname").Add(1)
+	m.containerMetric("ctrid", "default", "podname", "ctrname").Add(2)
+	m.podMetric("removedpodid", "default", "podname")
+	m.podMetric("podid", "default", "podname")
+	defer m.deleteMetrics(nil, nil)
+
+	m.deleteMetrics(map[string]bool{"ctrid": true}, map[string]bool{"podid": true})
+	if n := collected(metrics.CRIOContainerOOMKills); n != 1 {
+		t.Fatalf("expected the OOM kills of 1 container, got %d", n)
+	}
+	if n := collected(metrics.CRIOPodOOMKills); n != 1 {
+		t.Fatalf("expected the OOM kills of 1 pod, got %d", n)
+	}
+	if _, ok := m.containerLabels["removedid"]; ok {
+		t.Fatal("expected the labels of the removed container to be dropped")
+	}
+}
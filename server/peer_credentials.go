@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/kubernetes-incubator/cri-o/server/audit"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// peerAuthInfo holds the credentials of the process at the other end of a
+// connection to the unix socket of the server.
+type peerAuthInfo struct {
+	pid int32
+	uid uint32
+	gid uint32
+}
+
+// AuthType implements credentials.AuthInfo.
+func (peerAuthInfo) AuthType() string {
+	return "peer"
+}
+
+// peerCredentials are transport credentials which don't secure anything, but
+// identify the processes connecting to the unix socket of the server.
+type peerCredentials struct{}
+
+// NewPeerCredentials returns the transport credentials identifying the
+// processes connecting to the server, for them to show up in the audit log.
+func NewPeerCredentials() credentials.TransportCredentials {
+	return peerCredentials{}
+}
+
+func (peerCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials only identify the clients of the server")
+}
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	info, err := getPeerAuthInfo(conn)
+	if err != nil {
+		// Not knowing who connected is no reason to turn them away.
+		logrus.Debugf("failed to get the credentials of the client: %v", err)
+		return conn, nil, nil
+	}
+	return conn, info, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peer"}
+}
+
+func (c peerCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// clientFromContext identifies the client which sent the request, as far as
+// the server knows.
+func clientFromContext(ctx context.Context) *audit.Client {
+	client := &audit.Client{}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(*peerAuthInfo); ok {
+			client.Pid, client.UID, client.GID = info.pid, info.uid, info.gid
+			if comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", info.pid)); err == nil {
+				client.Command = strings.TrimSpace(string(comm))
+			}
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgent := md["user-agent"]; len(userAgent) > 0 {
+			client.UserAgent = userAgent[0]
+		}
+	}
+	return client
+}
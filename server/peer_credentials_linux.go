@@ -0,0 +1,36 @@
+// +build linux
+
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// getPeerAuthInfo returns the credentials of the process at the other end of
+// the unix socket connection.
+func getPeerAuthInfo(conn net.Conn) (*peerAuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("%s connections have no peer credentials", conn.LocalAddr().Network())
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ucred    *unix.Ucred
+		ucredErr error
+	)
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, ucredErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if ucredErr != nil {
+		return nil, ucredErr
+	}
+	return &peerAuthInfo{pid: ucred.Pid, uid: ucred.Uid, gid: ucred.Gid}, nil
+}
@@ -0,0 +1,12 @@
+// +build !linux
+
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+func getPeerAuthInfo(conn net.Conn) (*peerAuthInfo, error) {
+	return nil, fmt.Errorf("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-incubator/cri-o/lib"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// TestProcessRuntimeContainer runs a container through the server with the
+// process runtime, which needs neither root privileges nor an OCI runtime.
+func TestProcessRuntimeContainer(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "runtime-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, sandboxID, teardown := setupServer(t, func(config *lib.Config) {
+		config.Runtimes = map[string]oci.RuntimeHandler{
+			"process": {RuntimeType: oci.RuntimeTypeProcess},
+		}
+		config.ContainerExitsDir = dir
+	})
+	defer teardown()
+
+	c, err := oci.NewContainer("processid", "processname", dir, filepath.Join(dir, "ctr.log"), "", nil, nil, nil, "", "", "", &pb.ContainerMetadata{Name: "process"}, sandboxID, false, false, false, false, false, "process", dir, time.Now(), "SIGTERM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetSpec(&specs.Spec{Process: &specs.Process{
+		Args: []string{"/bin/sh", "-c", "trap 'exit 2' TERM; while :; do sleep 0.1; done"},
+	}})
+	if err := s.Runtime().CreateContainer(c, ""); err != nil {
+		t.Fatal(err)
+	}
+	s.AddContainer(c)
+	if err := s.CtrIDIndex().Add(c.ID()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Runtime().DeleteContainer(c)
+
+	status := func(state pb.ContainerState) *pb.ContainerStatus {
+		resp, err := s.ContainerStatus(ctx, &pb.ContainerStatusRequest{ContainerId: c.ID()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status.State != state {
+			t.Fatalf("expected the container to be %s, got %s", state, resp.Status.State)
+		}
+		return resp.Status
+	}
+	status(pb.ContainerState_CONTAINER_CREATED)
+
+	if _, err := s.StartContainer(ctx, &pb.StartContainerRequest{ContainerId: c.ID()}); err != nil {
+		t.Fatal(err)
+	}
+	status(pb.ContainerState_CONTAINER_RUNNING)
+
+	resp, err := s.ExecSync(ctx, &pb.ExecSyncRequest{
+		ContainerId: c.ID(),
+		Cmd:         []string{"/bin/sh", "-c", "echo out; exit 3"},
+		Timeout:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Stdout) != "out\n" || resp.ExitCode != 3 {
+		t.Fatalf("unexpected exec sync response: %q %d", resp.Stdout, resp.ExitCode)
+	}
+
+	if err := s.Runtime().StopContainer(ctx, c, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Runtime().WaitContainerStateStopped(ctx, c, 10); err != nil {
+		t.Fatal(err)
+	}
+	if exited := status(pb.ContainerState_CONTAINER_EXITED); exited.ExitCode != 2 || exited.Reason != errorReason {
+		t.Fatalf("expected the container to exit with 2, got %d (%s)", exited.ExitCode, exited.Reason)
+	}
+	if _, err := os.Stat(filepath.Join(dir, c.ID())); err != nil {
+		t.Errorf("expected the exit file of the container to be written: %v", err)
+	}
+}
@@ -68,6 +68,21 @@ func (s *Server) trustedSandbox(req *pb.RunPodSandboxRequest) bool {
 	return isTrue(trustedAnnotation)
 }
 
+// runtimeHandler returns the name of the runtime handler the sandbox should
+// run with, or an empty string if it should use the default runtime.
+func (s *Server) runtimeHandler(req *pb.RunPodSandboxRequest) (string, error) {
+	handler, ok := req.GetConfig().GetAnnotations()[annotations.RuntimeHandler]
+	if !ok || handler == "" {
+		return "", nil
+	}
+
+	if _, err := s.Runtime().ValidateRuntimeHandler(handler); err != nil {
+		return "", err
+	}
+
+	return handler, nil
+}
+
 var (
 	conflictRE = regexp.MustCompile(`already reserved for pod "([0-9a-z]+)"`)
 )
@@ -109,6 +124,12 @@ func (s *Server) configureIntermediateNamespace(g *generate.Generator, container
 
 // RunPodSandbox creates and runs a pod-level sandbox.
 func (s *Server) RunPodSandbox(ctx context.Context, req *pb.RunPodSandboxRequest) (resp *pb.RunPodSandboxResponse, err error) {
+	if record := s.sandboxAuditRecord(req); record != nil {
+		defer func() {
+			record.PodSandboxID = resp.GetPodSandboxId()
+			s.recordAudit(ctx, record, err)
+		}()
+	}
 	// platform dependent call
 	return s.runPodSandbox(ctx, req)
 }
@@ -1,9 +1,9 @@
 package server
 
 import (
-	"encoding/json"
 	"time"
 
+	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
 	"github.com/kubernetes-incubator/cri-o/oci"
 	"github.com/kubernetes-incubator/cri-o/version"
 	"github.com/sirupsen/logrus"
@@ -58,7 +58,7 @@ func (s *Server) PodSandboxStatus(ctx context.Context, req *pb.PodSandboxStatusR
 	}
 
 	if req.Verbose {
-		resp = amendVerboseInfo(resp)
+		resp = s.amendVerboseInfo(resp, sb)
 	}
 
 	logrus.Debugf("PodSandboxStatusResponse: %+v", resp)
@@ -70,12 +70,9 @@ type VersionPayload struct {
 	Version string `json:"version"`
 }
 
-func amendVerboseInfo(resp *pb.PodSandboxStatusResponse) *pb.PodSandboxStatusResponse {
-	resp.Info = make(map[string]string)
-	bs, err := json.Marshal(VersionPayload{Version: version.Version})
-	if err != nil {
-		return resp // Just ignore the error and don't marshal the info
-	}
-	resp.Info["version"] = string(bs)
+func (s *Server) amendVerboseInfo(resp *pb.PodSandboxStatusResponse, sb *sandbox.Sandbox) *pb.PodSandboxStatusResponse {
+	info := s.containerVerboseValues(sb.InfraContainer(), sb, sb.SeccompProfilePath())
+	info["version"] = VersionPayload{Version: version.Version}
+	resp.Info = verboseInfo(info)
 	return resp
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
 	"github.com/kubernetes-incubator/cri-o/oci"
 	"github.com/kubernetes-incubator/cri-o/version"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
@@ -21,7 +22,7 @@ func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
 
-func newTestContainerServerOrFailNow(t *testing.T) (cs *lib.ContainerServer, dirsToCleanUp []string) {
+func newTestContainerServerOrFailNow(t *testing.T, configure ...func(*lib.Config)) (cs *lib.ContainerServer, dirsToCleanUp []string) {
 	ctx := context.Background()
 	tmpdir := os.Getenv("TMPDIR")
 
@@ -38,6 +39,9 @@ func newTestContainerServerOrFailNow(t *testing.T) (cs *lib.ContainerServer, dir
 	config.RootConfig.Root = root
 	config.RootConfig.Storage = "vfs"
 	config.HooksDirPath = ""
+	for _, f := range configure {
+		f(config)
+	}
 	cs, err = lib.New(ctx, config)
 	if err != nil {
 		t.Fatal(err)
@@ -48,7 +52,7 @@ func newTestContainerServerOrFailNow(t *testing.T) (cs *lib.ContainerServer, dir
 func newTestSandboxOrFailNow(t *testing.T) (string, *sandbox.Sandbox) {
 	id := fmt.Sprintf("id-for-sandbox-%d", rand.Int())
 
-	sb, err := sandbox.New(id, "", "", "", "", nil, nil, "", "", nil, "", "", false, false, "", "", nil, false)
+	sb, err := sandbox.New(id, "", "", "", "", nil, nil, "", "", nil, "", "", false, false, "", "", "", nil, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -58,15 +62,19 @@ func newTestSandboxOrFailNow(t *testing.T) (string, *sandbox.Sandbox) {
 func newTestContainerOrFailNow(t *testing.T) *oci.Container {
 	id := fmt.Sprintf("id-for-container-%d", rand.Int())
 
-	c, err := oci.NewContainer(id, "", "", "", "", nil, nil, nil, "", "", "", nil, "", false, false, false, false, false, "", time.Now(), "")
+	c, err := oci.NewContainer(id, "", "", "", "", nil, nil, nil, "", "", "", nil, "", false, false, false, false, false, "", "", time.Now(), "")
 	if err != nil {
 		t.Fatal(err)
 	}
+	c.SetSpec(&specs.Spec{
+		Process: &specs.Process{},
+		Linux:   &specs.Linux{CgroupsPath: "/crio-" + id},
+	})
 	return c
 }
 
-func setupServer(t *testing.T) (*Server, string, func()) {
-	containerServer, fs := newTestContainerServerOrFailNow(t)
+func setupServer(t *testing.T, configure ...func(*lib.Config)) (*Server, string, func()) {
+	containerServer, fs := newTestContainerServerOrFailNow(t, configure...)
 	teardown := func() {
 		for _, f := range fs {
 			defer os.RemoveAll(f)
@@ -119,5 +127,16 @@ func TestPodSandboxStatus(t *testing.T) {
 		if version.Version != versionPayload.Version {
 			t.Errorf("expected: %s\ngot: %s", version.Version, versionPayload.Version)
 		}
+
+		for _, key := range []string{"runtimeSpec", "runtimePath", "pid", "cgroupPath", "netNsPath", "mountPoint", "selinuxLabels", "seccompProfile"} {
+			value, ok := resp.Info[key]
+			if !ok {
+				t.Errorf("expected verbose info %q", key)
+				continue
+			}
+			if !json.Valid([]byte(value)) {
+				t.Errorf("expected verbose info %q to be JSON, got %q", key, value)
+			}
+		}
 	})
 }
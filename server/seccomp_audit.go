@@ -0,0 +1,281 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/annotations"
+	"github.com/kubernetes-incubator/cri-o/pkg/seccomp"
+	"github.com/opencontainers/runtime-tools/generate"
+	"github.com/sirupsen/logrus"
+)
+
+// seccompAudit collects the syscalls logged for the containers running in
+// seccomp audit mode, until they stop.
+type seccompAudit struct {
+	lock sync.Mutex
+	// containers maps the IDs of the audited containers to the syscalls
+	// logged for them.
+	containers map[string]map[string]bool
+	// pids maps the processes found in the audited containers to their
+	// IDs, so that the records of a process are still recorded once it
+	// exited.
+	pids map[int]string
+	// log is the log the syscalls are read from, once being followed.
+	log *seccomp.AuditLog
+}
+
+func newSeccompAudit() *seccompAudit {
+	return &seccompAudit{
+		containers: make(map[string]map[string]bool),
+		pids:       make(map[int]string),
+	}
+}
+
+func (a *seccompAudit) watch(id string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, ok := a.containers[id]; !ok {
+		a.containers[id] = make(map[string]bool)
+	}
+}
+
+func (a *seccompAudit) isWatched(id string) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	_, ok := a.containers[id]
+	return ok
+}
+
+func (a *seccompAudit) watched() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	ids := make([]string, 0, len(a.containers))
+	for id := range a.containers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (a *seccompAudit) record(pid int, id, syscall string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if syscalls, ok := a.containers[id]; ok {
+		syscalls[syscall] = true
+		a.pids[pid] = id
+	}
+}
+
+// lookup returns the ID of the audited container the process got found in.
+func (a *seccompAudit) lookup(pid int) (string, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	id, ok := a.pids[pid]
+	return id, ok
+}
+
+func (a *seccompAudit) setLog(log *seccomp.AuditLog) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.log = log
+}
+
+// flush waits for the syscalls logged so far to be recorded.
+func (a *seccompAudit) flush() {
+	a.lock.Lock()
+	log := a.log
+	a.lock.Unlock()
+	if log != nil {
+		log.Flush()
+	}
+}
+
+// finish stops watching the container, and returns the syscalls logged for
+// it.
+func (a *seccompAudit) finish(id string) ([]string, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	logged, ok := a.containers[id]
+	if !ok {
+		return nil, false
+	}
+	delete(a.containers, id)
+	for pid, pidID := range a.pids {
+		if pidID == id {
+			delete(a.pids, pid)
+		}
+	}
+	syscalls := make([]string, 0, len(logged))
+	for syscall := range logged {
+		syscalls = append(syscalls, syscall)
+	}
+	sort.Strings(syscalls)
+	return syscalls, true
+}
+
+// seccompAuditMode returns the seccomp audit mode requested by the
+// annotations of the container, or else by the ones of its pod.
+func seccompAuditMode(containerAnnotations, podAnnotations map[string]string) string {
+	if mode, ok := containerAnnotations[annotations.SeccompAudit]; ok {
+		return mode
+	}
+	return podAnnotations[annotations.SeccompAudit]
+}
+
+// seccompAuditProfilePath returns where the profile generated by the seccomp
+// audit of the container gets written, making sure it's in dir.
+func seccompAuditProfilePath(dir, containerName string) (string, error) {
+	if containerName == "" || strings.ContainsAny(containerName, "/\x00") {
+		return "", fmt.Errorf("invalid container name %q for a seccomp audit profile", containerName)
+	}
+	return filepath.Join(dir, containerName+".json"), nil
+}
+
+// setupSeccompAudit sets up the seccomp profile of a container in audit mode:
+// the syscalls which the profile doesn't allow get logged instead of denied.
+// A container running unconfined gets all its syscalls logged.
+func (s *Server) setupSeccompAudit(specgen *generate.Generator, profile, mode string) error {
+	switch mode {
+	case seccomp.AuditModeLog:
+	case seccomp.AuditModeNotify:
+		return fmt.Errorf("seccomp audit mode %q needs the runtime to hand over a seccomp listener, which the OCI runtime spec in use doesn't support", mode)
+	default:
+		return fmt.Errorf("unknown seccomp audit mode %q, use %q", mode, seccomp.AuditModeLog)
+	}
+	if !s.seccompEnabled {
+		return fmt.Errorf("seccomp is not enabled in your kernel, cannot audit syscalls")
+	}
+	config, err := s.resolveSeccompProfile(profile)
+	if err != nil {
+		return err
+	}
+	audited := seccomp.Seccomp{DefaultAction: seccomp.ActLog}
+	if config != nil {
+		audited = *config
+		audited.DefaultAction = seccomp.ActLog
+	}
+	return seccomp.LoadProfileFromStruct(audited, specgen)
+}
+
+// StartSeccompAuditMonitor starts a routine that reads the syscalls logged
+// for the containers running in seccomp audit mode.
+func (s *Server) StartSeccompAuditMonitor() {
+	if !s.seccompEnabled || s.config.SeccompAuditLog == "" {
+		return
+	}
+	// The syscalls the containers audited before a restart used in the
+	// meantime are lost, the profiles written for them may be incomplete.
+	ctrs, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		logrus.Warnf("failed to list containers for the seccomp audit: %v", err)
+	}
+	for _, c := range ctrs {
+		if _, ok := c.CrioAnnotations()[annotations.SeccompAuditProfilePath]; !ok {
+			continue
+		}
+		state := s.Runtime().ContainerStatus(c)
+		if state.Status == oci.ContainerStateCreated || state.Status == oci.ContainerStateRunning || state.Status == oci.ContainerStatePaused {
+			s.seccompAudit.watch(c.ID())
+		}
+	}
+
+	log, err := seccomp.OpenAuditLog(s.config.SeccompAuditLog)
+	if err != nil {
+		logrus.Warnf("failed to open seccomp audit log %s: %v", s.config.SeccompAuditLog, err)
+		return
+	}
+	s.seccompAudit.setLog(log)
+	if err := log.Follow(s.monitorsChan, s.recordSeccompAudit); err != nil {
+		logrus.Warnf("failed to read seccomp audit records from %s: %v", s.config.SeccompAuditLog, err)
+		return
+	}
+	logrus.Debug("closing seccomp audit monitor...")
+}
+
+// recordSeccompAudit records the syscall of the audit record for the audited
+// container the process belongs to. It's called as soon as the record gets
+// written, while the process is most likely still around.
+func (s *Server) recordSeccompAudit(record seccomp.AuditRecord) {
+	id, ok := s.seccompAuditContainer(record.Pid)
+	if !ok {
+		return
+	}
+	syscall, err := seccomp.SyscallName(record)
+	if err != nil {
+		logrus.Warnf("failed to get the name of syscall %d logged for container %s: %v", record.Syscall, id, err)
+		return
+	}
+	s.seccompAudit.record(record.Pid, id, syscall)
+}
+
+// seccompAuditContainer returns the ID of the audited container the process
+// belongs to.
+func (s *Server) seccompAuditContainer(pid int) (string, bool) {
+	ids := s.seccompAudit.watched()
+	if len(ids) == 0 {
+		return "", false
+	}
+	// The cgroups of the containers are named after their ID, whether they
+	// are managed by cgroupfs or systemd.
+	cgroups, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		// The process exited already, it can only be found if one of
+		// its previous syscalls got recorded.
+		if id, ok := s.seccompAudit.lookup(pid); ok {
+			return id, true
+		}
+		logrus.Debugf("failed to find the container of process %d for the seccomp audit: %v", pid, err)
+		return "", false
+	}
+	for _, id := range ids {
+		if strings.Contains(string(cgroups), scopePrefix+"-"+id) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// finishSeccompAudit writes the profile allowing the syscalls the container
+// used during its seccomp audit, once it stopped. The syscalls logged before
+// it stopped get recorded first.
+func (s *Server) finishSeccompAudit(c *oci.Container) {
+	if !s.seccompAudit.isWatched(c.ID()) {
+		return
+	}
+	s.seccompAudit.flush()
+	syscalls, ok := s.seccompAudit.finish(c.ID())
+	if !ok {
+		return
+	}
+	path := c.CrioAnnotations()[annotations.SeccompAuditProfilePath]
+	if err := s.writeSeccompAuditProfile(c, path, syscalls); err != nil {
+		logrus.Warnf("failed to write the seccomp audit profile of container %s: %v", c.ID(), err)
+		return
+	}
+	logrus.Infof("wrote the seccomp profile of container %s, with %d syscalls logged by the seccomp audit, to %s", c.ID(), len(syscalls), path)
+}
+
+func (s *Server) writeSeccompAuditProfile(c *oci.Container, path string, syscalls []string) error {
+	base, err := s.resolveSeccompProfile(c.SeccompProfilePath())
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		base = &seccomp.Seccomp{DefaultAction: seccomp.ActErrno}
+	}
+	data, err := json.MarshalIndent(seccomp.AuditProfile(base, syscalls), "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
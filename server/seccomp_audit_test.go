@@ -0,0 +1,20 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestSeccompAuditProfilePath(t *testing.T) {
+	path, err := seccompAuditProfilePath("/var/lib/crio/seccomp-audit", "k8s_ctr_pod_default_uid_0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/var/lib/crio/seccomp-audit/k8s_ctr_pod_default_uid_0.json" {
+		t.Errorf("unexpected seccomp audit profile path %q", path)
+	}
+	for _, name := range []string{"", "../../etc/passwd", "k8s_../ctr_pod", "/ctr", "ctr\x00"} {
+		if path, err := seccompAuditProfilePath("/var/lib/crio/seccomp-audit", name); err == nil {
+			t.Errorf("expected container name %q to be invalid, got %q", name, path)
+		}
+	}
+}
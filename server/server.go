@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -26,6 +25,8 @@ import (
 	"github.com/kubernetes-incubator/cri-o/pkg/apparmor"
 	"github.com/kubernetes-incubator/cri-o/pkg/seccomp"
 	"github.com/kubernetes-incubator/cri-o/pkg/storage"
+	"github.com/kubernetes-incubator/cri-o/server/audit"
+	"github.com/kubernetes-incubator/cri-o/server/events"
 	"github.com/kubernetes-incubator/cri-o/server/metrics"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -66,8 +67,10 @@ type Server struct {
 	netPlugin       ocicni.CNIPlugin
 	hostportManager hostport.HostPortManager
 
-	seccompEnabled bool
-	seccompProfile seccomp.Seccomp
+	seccompEnabled  bool
+	seccompProfile  seccomp.Seccomp
+	seccompProfiles *seccomp.ProfileCache
+	seccompAudit    *seccompAudit
 
 	appArmorEnabled bool
 	appArmorProfile string
@@ -77,6 +80,13 @@ type Server struct {
 	monitorsChan chan struct{}
 
 	defaultIDMappings *idtools.IDMappings
+
+	events     *events.Bus
+	oomMonitor *oomMonitor
+	pulls      *pullTracker
+	imageGC    *imageGC
+	auditLog   *audit.Logger
+	recordings sessionRecordings
 }
 
 type certConfigCache struct {
@@ -167,6 +177,14 @@ func (s *Server) restore() {
 	for containerID := range podContainers {
 		if err := s.LoadContainer(containerID); err != nil {
 			logrus.Warnf("could not restore container %s: %v", containerID, err)
+			continue
+		}
+		// The clients of the exec sessions went away with the previous
+		// daemon, the processes they left behind are reaped.
+		if c := s.GetContainer(containerID); c != nil {
+			if err := s.Runtime().ReapExecSessions(c); err != nil {
+				logrus.Warnf("could not reap exec sessions of container %s: %v", containerID, err)
+			}
 		}
 	}
 	// Restore sandbox IPs
@@ -200,6 +218,9 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	// notice this won't trigger just on system halt but also on normal
 	// crio.service restart!!!
 	s.cleanupSandboxesOnShutdown(ctx)
+	if err := s.auditLog.Close(); err != nil {
+		logrus.Warnf("failed to close the audit log: %v", err)
+	}
 	return s.ContainerServer.Shutdown()
 }
 
@@ -302,28 +323,42 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		return nil, err
 	}
 
+	var (
+		imageGCMinAge time.Duration
+		imageGCPath   string
+	)
+	if config.ImageGCHighThreshold != 0 {
+		imageGCMinAge, err = time.ParseDuration(config.ImageGCMinAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image gc min age %q: %v", config.ImageGCMinAge, err)
+		}
+		imageGCPath = filepath.Join(config.Root, imageGCStateFile)
+	}
+
 	s := &Server{
 		ContainerServer:   containerServer,
 		netPlugin:         netPlugin,
 		hostportManager:   hostportManager,
 		config:            *config,
 		seccompEnabled:    seccomp.IsEnabled(),
+		seccompProfiles:   seccomp.NewProfileCache(),
+		seccompAudit:      newSeccompAudit(),
 		appArmorEnabled:   apparmor.IsEnabled(),
 		appArmorProfile:   config.ApparmorProfile,
 		monitorsChan:      make(chan struct{}),
 		defaultIDMappings: idMappings,
+		events:            events.NewBus(),
+		oomMonitor:        newOOMMonitor(),
+		pulls:             newPullTracker(),
+		imageGC:           newImageGC(imageGCMinAge, imageGCPath),
 	}
 
 	if s.seccompEnabled {
-		seccompProfile, fileErr := ioutil.ReadFile(config.SeccompProfile)
-		if fileErr != nil {
-			return nil, fmt.Errorf("opening seccomp profile (%s) failed: %v", config.SeccompProfile, fileErr)
+		seccompProfile, seccompErr := s.seccompProfiles.Load(config.SeccompProfile)
+		if seccompErr != nil {
+			return nil, fmt.Errorf("loading seccomp profile (%s) failed: %v", config.SeccompProfile, seccompErr)
 		}
-		var seccompConfig seccomp.Seccomp
-		if jsonErr := json.Unmarshal(seccompProfile, &seccompConfig); jsonErr != nil {
-			return nil, fmt.Errorf("decoding seccomp profile failed: %v", jsonErr)
-		}
-		s.seccompProfile = seccompConfig
+		s.seccompProfile = *seccompProfile
 	}
 
 	if s.appArmorEnabled && s.appArmorProfile == apparmor.DefaultApparmorProfile {
@@ -331,6 +366,21 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 			return nil, fmt.Errorf("ensuring the default apparmor profile is installed failed: %v", apparmorErr)
 		}
 	}
+	if s.appArmorEnabled && config.ApparmorProfileDir != "" {
+		if apparmorErr := apparmor.LoadProfileDir(config.ApparmorProfileDir); apparmorErr != nil {
+			logrus.Warn(apparmorErr)
+		}
+	}
+
+	if config.AuditLogPath != "" {
+		if err := os.MkdirAll(filepath.Dir(config.AuditLogPath), 0700); err != nil {
+			return nil, err
+		}
+		s.auditLog, err = audit.New(config.AuditLogPath, config.AuditLogMaxSize, config.AuditLogMaxFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if err := configureMaxThreads(); err != nil {
 		return nil, err
@@ -450,6 +500,9 @@ func (s *Server) getPodSandboxFromRequest(podSandboxID string) (*sandbox.Sandbox
 // for prometheus monitoring
 func (s *Server) CreateMetricsEndpoint() (*http.ServeMux, error) {
 	metrics.Register()
+	if err := prometheus.Register(metrics.NewContainerStatsCollector(s.listContainerMetrics)); err != nil {
+		return nil, err
+	}
 	mux := &http.ServeMux{}
 	mux.Handle("/metrics", prometheus.Handler())
 	return mux, nil
@@ -490,7 +543,15 @@ func (s *Server) StartExitMonitor() {
 						if err != nil {
 							logrus.Warnf("Failed to update container status %s: %v", containerID, err)
 						} else {
+							// New OOM kills get published along with
+							// the last read of the counter.
+							oomKilled := s.checkExitedContainerOOMKills(c)
 							s.ContainerStateToDisk(c)
+							s.finishSeccompAudit(c)
+							if !oomKilled && s.Runtime().ContainerStatus(c).OOMKilled {
+								s.publishContainerEvent(events.ContainerOOMKilled, c)
+							}
+							s.publishContainerEvent(events.ContainerExited, c)
 						}
 					} else {
 						sb := s.GetSandbox(containerID)
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zoo/bone"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/pkg/asciicast"
+	"github.com/kubernetes-incubator/cri-o/types"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	sessionTypeExec   = "exec"
+	sessionTypeAttach = "attach"
+
+	sessionRecordingExt = ".cast"
+
+	// The size of the terminal recordings start with, until the client
+	// resizes it.
+	sessionRecordingWidth  = 80
+	sessionRecordingHeight = 24
+)
+
+// sessionRecordings keeps track of the session recordings in progress, which
+// don't get removed to fit the maximum size of the recordings.
+type sessionRecordings struct {
+	lock   sync.Mutex
+	active map[string]bool
+}
+
+func (r *sessionRecordings) start(path string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.active == nil {
+		r.active = make(map[string]bool)
+	}
+	r.active[path] = true
+}
+
+func (r *sessionRecordings) finish(path string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.active, path)
+}
+
+func (r *sessionRecordings) isActive(path string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.active[path]
+}
+
+// sessionRecording tees the streams of an exec or attach session into an
+// asciicast recording. Failing to record never interrupts the session.
+type sessionRecording struct {
+	server *Server
+	path   string
+	file   *os.File
+	cast   *asciicast.Writer
+	done   chan struct{}
+	failed sync.Once
+}
+
+// startSessionRecording starts the recording of a session of the container
+// in the session recording directory, if any. The recordings of a container
+// are named after the time their session started.
+func (s *Server) startSessionRecording(c *oci.Container, sessionType string, cmd []string) (*sessionRecording, error) {
+	if s.config.SessionRecordingDir == "" {
+		return nil, nil
+	}
+	dir := filepath.Join(s.config.SessionRecordingDir, c.ID())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("%d-%s%s", start.UnixNano(), sessionType, sessionRecordingExt))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	cast, err := asciicast.NewWriter(f, asciicast.Header{
+		Width:     sessionRecordingWidth,
+		Height:    sessionRecordingHeight,
+		Timestamp: start.Unix(),
+		Command:   strings.Join(cmd, " "),
+		Title:     fmt.Sprintf("%s %s", sessionType, c.Name()),
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	logrus.Debugf("recording %s session of container %s to %s", sessionType, c.ID(), path)
+	s.recordings.start(path)
+	return &sessionRecording{
+		server: s,
+		path:   path,
+		file:   f,
+		cast:   cast,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// fail logs the first error the recording fails with. The streams of an
+// attach session may still get written to once the recording stopped, which
+// isn't worth a warning.
+func (r *sessionRecording) fail(err error) {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	r.failed.Do(func() {
+		logrus.Warnf("failed to record session to %s: %v", r.path, err)
+	})
+}
+
+// tee returns the streams of the session, recording what goes through them.
+func (r *sessionRecording) tee(stdin io.Reader, stdout, stderr io.WriteCloser, resize <-chan remotecommand.TerminalSize) (io.Reader, io.WriteCloser, io.WriteCloser, <-chan remotecommand.TerminalSize) {
+	if stdin != nil {
+		stdin = io.TeeReader(stdin, r.stream(asciicast.EventInput))
+	}
+	// asciicast has no notion of stderr, both end up on the terminal.
+	if stdout != nil {
+		stdout = r.teeWriter(stdout)
+	}
+	if stderr != nil {
+		stderr = r.teeWriter(stderr)
+	}
+	if resize != nil {
+		resize = r.teeResize(resize)
+	}
+	return stdin, stdout, stderr, resize
+}
+
+type teeWriteCloser struct {
+	io.Writer
+	io.Closer
+}
+
+func (r *sessionRecording) teeWriter(w io.WriteCloser) io.WriteCloser {
+	return teeWriteCloser{
+		Writer: io.MultiWriter(w, r.stream(asciicast.EventOutput)),
+		Closer: w,
+	}
+}
+
+func (r *sessionRecording) teeResize(resize <-chan remotecommand.TerminalSize) <-chan remotecommand.TerminalSize {
+	out := make(chan remotecommand.TerminalSize)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case size, ok := <-resize:
+				if !ok {
+					return
+				}
+				if err := r.cast.WriteResize(size.Width, size.Height); err != nil {
+					r.fail(err)
+				}
+				select {
+				case out <- size:
+				case <-r.done:
+					return
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// stream returns a writer recording events of the type, which never fails.
+func (r *sessionRecording) stream(eventType asciicast.EventType) io.Writer {
+	return recordingStream{r: r, w: r.cast.Stream(eventType)}
+}
+
+type recordingStream struct {
+	r *sessionRecording
+	w io.Writer
+}
+
+func (s recordingStream) Write(p []byte) (int, error) {
+	if _, err := s.w.Write(p); err != nil {
+		s.r.fail(err)
+	}
+	return len(p), nil
+}
+
+// Close stops the recording once the session ended, and removes the oldest
+// recordings if they take more than their maximum size.
+func (r *sessionRecording) Close() error {
+	close(r.done)
+	err := r.file.Close()
+	r.server.recordings.finish(r.path)
+	r.server.pruneSessionRecordings()
+	return err
+}
+
+// pruneSessionRecordings removes the oldest recordings of the sessions which
+// ended, until the recordings fit their maximum size.
+func (s *Server) pruneSessionRecordings() {
+	if s.config.SessionRecordingMaxSize <= 0 {
+		return
+	}
+	recordings, err := s.listSessionRecordings("")
+	if err != nil {
+		logrus.Warnf("failed to list the session recordings to prune: %v", err)
+		return
+	}
+	var size int64
+	for _, recording := range recordings {
+		size += recording.Size
+	}
+	for _, recording := range recordings {
+		if size <= s.config.SessionRecordingMaxSize {
+			return
+		}
+		path := filepath.Join(s.config.SessionRecordingDir, recording.ContainerID, recording.ID+sessionRecordingExt)
+		if s.recordings.isActive(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("failed to remove session recording %s: %v", path, err)
+			continue
+		}
+		logrus.Debugf("removed session recording %s to fit the maximum size of the recordings", path)
+		size -= recording.Size
+	}
+}
+
+// removeSessionRecordings removes the recordings of the sessions of the
+// container.
+func (s *Server) removeSessionRecordings(id string) {
+	if s.config.SessionRecordingDir == "" || !validPathElement(id) {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(s.config.SessionRecordingDir, id)); err != nil {
+		logrus.Warnf("failed to remove the session recordings of container %s: %v", id, err)
+	}
+}
+
+// listSessionRecordings returns the session recordings of the container, or
+// of all the containers if id is empty, sorted by start time.
+func (s *Server) listSessionRecordings(id string) ([]types.SessionRecording, error) {
+	recordings := []types.SessionRecording{}
+	if s.config.SessionRecordingDir == "" {
+		return recordings, nil
+	}
+	ids := []string{id}
+	if id == "" {
+		dirs, err := ioutil.ReadDir(s.config.SessionRecordingDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		ids = ids[:0]
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				ids = append(ids, dir.Name())
+			}
+		}
+	}
+	for _, id := range ids {
+		files, err := ioutil.ReadDir(filepath.Join(s.config.SessionRecordingDir, id))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, file := range files {
+			recording, ok := s.sessionRecordingInfo(id, file)
+			if ok {
+				recordings = append(recordings, recording)
+			}
+		}
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedTime < recordings[j].StartedTime
+	})
+	return recordings, nil
+}
+
+// sessionRecordingInfo returns the information about the recording file of
+// the container, read from its name and header.
+func (s *Server) sessionRecordingInfo(id string, file os.FileInfo) (types.SessionRecording, bool) {
+	session := strings.TrimSuffix(file.Name(), sessionRecordingExt)
+	parts := strings.SplitN(session, "-", 2)
+	if file.IsDir() || session == file.Name() || len(parts) != 2 {
+		return types.SessionRecording{}, false
+	}
+	started, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return types.SessionRecording{}, false
+	}
+	recording := types.SessionRecording{
+		ID:          session,
+		ContainerID: id,
+		Type:        parts[1],
+		StartedTime: started,
+		Size:        file.Size(),
+	}
+	f, err := os.Open(filepath.Join(s.config.SessionRecordingDir, id, file.Name()))
+	if err != nil {
+		return recording, true
+	}
+	defer f.Close()
+	var header asciicast.Header
+	if line, err := bufio.NewReader(f).ReadBytes('\n'); err == nil && json.Unmarshal(line, &header) == nil {
+		recording.Command = header.Command
+	}
+	return recording, true
+}
+
+// validPathElement returns whether name can be used as a path element without
+// leaving the directory it's joined to.
+func validPathElement(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsRune(name, os.PathSeparator)
+}
+
+func (s *Server) serveSessionRecordings(w http.ResponseWriter, req *http.Request) {
+	id := bone.GetValue(req, "id")
+	if id != "" && !validPathElement(id) {
+		http.Error(w, fmt.Sprintf("invalid container id %q", id), http.StatusBadRequest)
+		return
+	}
+	recordings, err := s.listSessionRecordings(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	js, err := json.Marshal(recordings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func (s *Server) serveSessionRecording(w http.ResponseWriter, req *http.Request) {
+	id := bone.GetValue(req, "id")
+	session := bone.GetValue(req, "session")
+	if s.config.SessionRecordingDir == "" || !validPathElement(id) || !validPathElement(session) {
+		http.Error(w, fmt.Sprintf("can't find the session %s of container %s", session, id), http.StatusNotFound)
+		return
+	}
+	f, err := os.Open(filepath.Join(s.config.SessionRecordingDir, id, session+sessionRecordingExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, fmt.Sprintf("can't find the session %s of container %s", session, id), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	if _, err := io.Copy(w, f); err != nil {
+		logrus.Debugf("failed to serve the session %s of container %s: %v", session, id, err)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"k8s.io/client-go/tools/remotecommand"
+	runtime "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestSessionRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := &Server{}
+	s.config.SessionRecordingDir = dir
+	c, err := oci.NewContainer("ctrid", "ctrname", "", "", "", nil, nil, nil, "image", "imageName", "imageRef", &runtime.ContainerMetadata{}, "sandboxid", false, false, false, false, false, "", dir, time.Now(), "SIGKILL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recording, err := s.startSessionRecording(c, sessionTypeExec, []string{"sh", "-c", "cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout nopWriteCloser
+	resize := make(chan remotecommand.TerminalSize)
+	stdin, out, stderr, recordedResize := recording.tee(strings.NewReader("input"), &stdout, nil, resize)
+	if stderr != nil {
+		t.Error("expected no stderr stream to be made up")
+	}
+	resize <- remotecommand.TerminalSize{Width: 100, Height: 30}
+	if size := <-recordedResize; size.Width != 100 || size.Height != 30 {
+		t.Errorf("expected the resize to be passed on, got %+v", size)
+	}
+	input, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write(input); err != nil {
+		t.Fatal(err)
+	}
+	if err := recording.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "input" {
+		t.Errorf("expected the output to be passed on, got %q", stdout.String())
+	}
+
+	recordings, err := s.listSessionRecordings("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 1 {
+		t.Fatalf("expected 1 recording, got %+v", recordings)
+	}
+	r := recordings[0]
+	if r.ContainerID != "ctrid" || r.Type != sessionTypeExec || r.Command != "sh -c cat" || r.StartedTime == 0 {
+		t.Errorf("unexpected recording %+v", r)
+	}
+	data, err := ioutil.ReadFile(recording.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{`"r","100x30"`, `"i","input"`, `"o","input"`} {
+		if !strings.Contains(string(data), event) {
+			t.Errorf("expected the recording to hold %s, got %s", event, data)
+		}
+	}
+
+	if recordings, err := s.listSessionRecordings("otherid"); err != nil || len(recordings) != 0 {
+		t.Errorf("expected no recording of another container, got %+v, %v", recordings, err)
+	}
+}
+
+func TestSessionRecordingRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := &Server{}
+	s.config.SessionRecordingDir = dir
+	c, err := oci.NewContainer("ctrid", "ctrname", "", "", "", nil, nil, nil, "image", "imageName", "imageRef", &runtime.ContainerMetadata{}, "sandboxid", false, false, false, false, false, "", dir, time.Now(), "SIGKILL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := func(output string) *sessionRecording {
+		recording, err := s.startSessionRecording(c, sessionTypeExec, []string{"cat"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, out, _, _ := recording.tee(nil, &nopWriteCloser{}, nil, nil)
+		if _, err := out.Write([]byte(output)); err != nil {
+			t.Fatal(err)
+		}
+		return recording
+	}
+
+	// The oldest recording is still in progress when the others end.
+	active := record(strings.Repeat("a", 1000))
+	first := record(strings.Repeat("b", 1000))
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recordings, err := s.listSessionRecordings("ctrid")
+	if err != nil || len(recordings) != 2 {
+		t.Fatalf("expected 2 recordings, got %+v, %v", recordings, err)
+	}
+	// The sizes of the recordings vary with the times of their events, by a
+	// few bytes.
+	s.config.SessionRecordingMaxSize = recordings[0].Size + recordings[1].Size + 100
+	second := record(strings.Repeat("c", 1000))
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for path, exists := range map[string]bool{active.path: true, first.path: false, second.path: true} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Errorf("expected recording %s to exist: %v, got %v", path, exists, err)
+		}
+	}
+	if err := active.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.removeSessionRecordings("ctrid")
+	if recordings, err := s.listSessionRecordings(""); err != nil || len(recordings) != 0 {
+		t.Errorf("expected the recordings to be removed with the container, got %+v, %v", recordings, err)
+	}
+}
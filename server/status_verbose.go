@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/kubernetes-incubator/cri-o/lib/sandbox"
+	"github.com/kubernetes-incubator/cri-o/oci"
+	"github.com/kubernetes-incubator/cri-o/types"
+	"github.com/sirupsen/logrus"
+)
+
+// selinuxLabels are the SELinux labels a container runs with.
+type selinuxLabels struct {
+	Process string `json:"process"`
+	Mount   string `json:"mount"`
+}
+
+// verboseInfo builds the info map of verbose status responses, with the
+// values encoded as JSON.
+func verboseInfo(values map[string]interface{}) map[string]string {
+	info := make(map[string]string, len(values))
+	for key, value := range values {
+		bs, err := json.Marshal(value)
+		if err != nil {
+			logrus.Debugf("failed to encode verbose info %s: %v", key, err)
+			continue
+		}
+		info[key] = string(bs)
+	}
+	return info
+}
+
+// containerVerboseValues returns the values describing how the container,
+// or the infra container of a pod sandbox, runs in verbose status responses.
+func (s *Server) containerVerboseValues(c *oci.Container, sb *sandbox.Sandbox, seccompProfile string) map[string]interface{} {
+	spec := c.Spec()
+	values := map[string]interface{}{
+		"runtimeSpec":    spec,
+		"mountPoint":     c.MountPoint(),
+		"seccompProfile": seccompProfile,
+	}
+	if runtimePath, err := s.Runtime().Path(c); err != nil {
+		logrus.Debugf("failed to get runtime path of container %s: %v", c.ID(), err)
+	} else {
+		values["runtimePath"] = runtimePath
+	}
+	if state := c.State(); state != nil {
+		values["pid"] = state.Pid
+	}
+
+	labels := selinuxLabels{}
+	if spec.Process != nil {
+		labels.Process = spec.Process.SelinuxLabel
+	}
+	if spec.Linux != nil {
+		labels.Mount = spec.Linux.MountLabel
+		values["cgroupPath"] = spec.Linux.CgroupsPath
+	}
+	values["selinuxLabels"] = labels
+
+	netNsPath := ""
+	if sb != nil {
+		netNsPath = sb.NetNsPath()
+	}
+	if netNsPath == "" {
+		// The container doesn't join a network namespace managed by CRI-O.
+		netNsPath, _ = c.NetNsPath()
+	}
+	values["netNsPath"] = netNsPath
+
+	if mappings := c.IDMappings(); mappings != nil {
+		values["idMappings"] = types.IDMappings{
+			Uids: mappings.UIDs(),
+			Gids: mappings.GIDs(),
+		}
+	}
+	return values
+}
@@ -159,3 +159,68 @@ function teardown() {
     cleanup_pods
     stop_crio
 }
+
+# 6. test running with a localhost/ apparmor profile loaded from the apparmor profile directory.
+# test that the profile gets loaded at startup and applied to the container.
+@test "run a container with a localhost apparmor profile of the apparmor profile directory" {
+    # this test requires apparmor, so skip this test if apparmor is not enabled.
+    enabled=$(is_apparmor_enabled)
+    if [[ "$enabled" -eq 0 ]]; then
+        skip "skip this test since apparmor is not enabled."
+    fi
+
+    mkdir -p "$TESTDIR"/apparmor.d
+    cp "$APPARMOR_TEST_PROFILE_PATH" "$TESTDIR"/apparmor.d/
+    export OVERRIDE_OPTIONS="--apparmor-profile-dir $TESTDIR/apparmor.d"
+    start_crio
+
+    python -c 'import json,sys;obj=json.load(sys.stdin);obj["linux"]["security_context"]["apparmor_profile"] = "localhost/'"$APPARMOR_TEST_PROFILE_NAME"'"; json.dump(obj, sys.stdout)' < "$TESTDATA"/container_redis.json > "$TESTDIR"/apparmor6.json
+
+    run crictl runp "$TESTDATA"/sandbox_config.json
+    echo "$output"
+    [ "$status" -eq 0 ]
+    pod_id="$output"
+    run crictl create "$pod_id" "$TESTDIR"/apparmor6.json "$TESTDATA"/sandbox_config.json
+    echo "$output"
+    [ "$status" -eq 0 ]
+    ctr_id="$output"
+    run crictl start "$ctr_id"
+    echo "$output"
+    [ "$status" -eq 0 ]
+    run crictl exec --sync "$ctr_id" touch test.txt
+    echo "$output"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "Permission denied" ]]
+
+    cleanup_ctrs
+    cleanup_pods
+    stop_crio
+    remove_apparmor_profile "$APPARMOR_TEST_PROFILE_PATH"
+}
+
+# 7. test running with a localhost/ apparmor profile which isn't loaded.
+# test that creating the container fails with a clear error.
+@test "run a container with a localhost apparmor profile which isn't loaded" {
+    # this test requires apparmor, so skip this test if apparmor is not enabled.
+    enabled=$(is_apparmor_enabled)
+    if [[ "$enabled" -eq 0 ]]; then
+        skip "skip this test since apparmor is not enabled."
+    fi
+
+    start_crio
+
+    python -c 'import json,sys;obj=json.load(sys.stdin);obj["linux"]["security_context"]["apparmor_profile"] = "localhost/not-exists"; json.dump(obj, sys.stdout)' < "$TESTDATA"/container_redis.json > "$TESTDIR"/apparmor7.json
+
+    run crictl runp "$TESTDATA"/sandbox_config.json
+    echo "$output"
+    [ "$status" -eq 0 ]
+    pod_id="$output"
+    run crictl create "$pod_id" "$TESTDIR"/apparmor7.json "$TESTDATA"/sandbox_config.json
+    echo "$output"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "apparmor profile \"not-exists\" is not loaded" ]]
+
+    cleanup_ctrs
+    cleanup_pods
+    stop_crio
+}
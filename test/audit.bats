@@ -0,0 +1,74 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function teardown() {
+	cleanup_test
+}
+
+@test "audit log records exec sessions and host namespaces" {
+	export OVERRIDE_OPTIONS="--audit-log $TESTDIR/audit.log"
+	start_crio
+	python -c 'import json,sys;obj=json.load(sys.stdin);obj["linux"]["security_context"]["namespace_options"]["network"]=2;json.dump(obj, sys.stdout)' \
+		< "$TESTDATA"/sandbox_config.json > "$TESTDIR"/sandbox_hostnet.json
+	run crictl runp "$TESTDIR"/sandbox_hostnet.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDIR"/sandbox_hostnet.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl exec --sync "$ctr_id" echo hello
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	cat "$TESTDIR"/audit.log
+	run grep '"operation":"run_pod_sandbox"' "$TESTDIR"/audit.log
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "\"pod_sandbox_id\":\"$pod_id\"" ]]
+	[[ "$output" =~ '"host_namespaces":["network"]' ]]
+	run grep '"operation":"exec_sync"' "$TESTDIR"/audit.log
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "\"container_id\":\"$ctr_id\"" ]]
+	[[ "$output" =~ '"command":["echo","hello"]' ]]
+	[[ "$output" =~ '"command":"crictl"' ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "audit log gets rotated" {
+	export OVERRIDE_OPTIONS="--audit-log $TESTDIR/audit.log --audit-log-max-size 1024 --audit-log-max-files 1"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	for i in $(seq 10); do
+		run crictl exec --sync "$ctr_id" echo "$i"
+		echo "$output"
+		[ "$status" -eq 0 ]
+	done
+
+	[ -f "$TESTDIR"/audit.log.1 ]
+	[ ! -f "$TESTDIR"/audit.log.2 ]
+	[ "$(stat -c %s "$TESTDIR"/audit.log)" -le 1024 ]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
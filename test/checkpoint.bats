@@ -0,0 +1,110 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function teardown() {
+	cleanup_test
+}
+
+@test "ctr checkpoint and restore" {
+	# this test requires CRIU, so skip this test if it can't run here.
+	if ! criu check > /dev/null 2>&1; then
+		skip "skip this test since criu is not available."
+	fi
+
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl exec --sync "$ctr_id" sh -c "echo checkpointed > /data/marker"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	out=`echo -e "POST /containers/$ctr_id/checkpoint?target=checkpoint.tar HTTP/1.1\r\nHost: crio\r\nContent-Length: 0\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "204 No Content" ]]
+	archive=$(find "$TESTDIR" -path "*/$ctr_id/userdata/checkpoints/checkpoint.tar")
+	run tar -tf "$archive"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "checkpoint/" ]]
+	[[ "$output" =~ "config.json" ]]
+	[[ "$output" =~ "state.dump" ]]
+	[[ "$output" =~ "rootfs-diff.tar" ]]
+
+	run crictl inspect "$ctr_id" --output table
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "CONTAINER_EXITED" ]]
+
+	out=`echo -e "POST /containers/$ctr_id/restore?archive=checkpoint.tar HTTP/1.1\r\nHost: crio\r\nContent-Length: 0\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"sandbox\":\"$pod_id\"" ]]
+
+	run crictl inspect "$ctr_id" --output table
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "CONTAINER_RUNNING" ]]
+	run crictl exec --sync "$ctr_id" cat /data/marker
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "checkpointed" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "ctr checkpoint not running" {
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+
+	out=`echo -e "POST /containers/$ctr_id/checkpoint HTTP/1.1\r\nHost: crio\r\nContent-Length: 0\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "cannot checkpoint container $ctr_id in state created" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "ctr checkpoint archive outside of the checkpoints directory" {
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+
+	for target in "$TESTDIR/checkpoint.tar" "../checkpoint.tar"; do
+		out=`echo -e "POST /containers/$ctr_id/checkpoint?target=$target HTTP/1.1\r\nHost: crio\r\nContent-Length: 0\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+		echo "$out"
+		[[ "$out" =~ "400 Bad Request" ]]
+		out=`echo -e "POST /containers/$ctr_id/restore?archive=$target HTTP/1.1\r\nHost: crio\r\nContent-Length: 0\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+		echo "$out"
+		[[ "$out" =~ "400 Bad Request" ]]
+	done
+	[ ! -e "$TESTDIR/checkpoint.tar" ]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
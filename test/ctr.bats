@@ -293,6 +293,58 @@ function teardown() {
 	stop_crio
 }
 
+@test "ctr log rotation" {
+	export OVERRIDE_OPTIONS="--log-rotate-files 2 --log-rotate-size 10000"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+
+	newconfig=$(mktemp --tmpdir crio-config.XXXXXX.json)
+	cp "$TESTDATA"/container_config_logging.json "$newconfig"
+	sed -i 's|"%shellcommand%"|"for i in $(seq 1000); do echo $i; done"|' "$newconfig"
+	run crictl create "$pod_id" "$newconfig" "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run wait_until_exit "$ctr_id"
+	[ "$status" -eq 0 ]
+
+	# Only the last generations are kept, compressed once rotated.
+	logpath="$DEFAULT_LOG_PATH/$pod_id/$ctr_id.log"
+	[ -f "$logpath" ]
+	[ "$(stat -c %s "$logpath")" -le 10000 ]
+	[ -f "$logpath.1.gz" ] || [ -f "$logpath.1" ]
+	[ -f "$logpath.2.gz" ]
+	[ ! -e "$logpath.3.gz" ]
+	[ ! -e "$logpath.3" ]
+	tail -n 1 "$logpath" | grep -q " F 1000$"
+
+	# The logs are read across the generations.
+	run crictl logs --tail 300 "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[ "$output" = "$(seq 701 1000)" ]
+
+	run crictl rm "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl stopp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl rmp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
 @test "ctr partial line logging" {
 	start_crio
 	run crictl runp "$TESTDATA"/sandbox_config.json
@@ -1172,3 +1224,150 @@ function teardown() {
 	cleanup_pods
 	stop_crio
 }
+
+@test "ctr log journald" {
+	if ! journalctl --quiet --lines 0 >/dev/null 2>&1; then
+		skip "journald is not available"
+	fi
+	export OVERRIDE_OPTIONS="--log-driver journald --log-driver k8s-file"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+
+	newconfig=$(mktemp --tmpdir crio-config.XXXXXX.json)
+	cp "$TESTDATA"/container_config_logging.json "$newconfig"
+	sed -i 's|"%shellcommand%"|"echo here is some output; echo and some from stderr >\&2"|' "$newconfig"
+	run crictl create "$pod_id" "$newconfig" "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run wait_until_exit "$ctr_id"
+	[ "$status" -eq 0 ]
+
+	# The output is written both to the journal and to the log file.
+	journalctl --sync || true
+	run journalctl --output cat CONTAINER_ID_FULL="$ctr_id" PRIORITY=6
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[ "$output" = "here is some output" ]
+	run journalctl --output cat CONTAINER_ID_FULL="$ctr_id" PRIORITY=3
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[ "$output" = "and some from stderr" ]
+	run journalctl --output export CONTAINER_ID_FULL="$ctr_id"
+	echo "$output"
+	[[ "$output" =~ "POD_NAME=podsandbox1" ]]
+	[[ "$output" =~ "POD_NAMESPACE=redhat.test.crio" ]]
+	logpath="$DEFAULT_LOG_PATH/$pod_id/$ctr_id.log"
+	grep -q "stdout F here is some output" "$logpath"
+
+	run crictl rm "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl stopp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl rmp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "ctr log syslog" {
+	syslog_log="$TESTDIR"/syslog.log
+	socat -u UDP4-RECV:5514,bind=127.0.0.1 OPEN:"$syslog_log",creat,append &
+	socat_pid=$!
+	export OVERRIDE_OPTIONS="--log-driver syslog --log-syslog-address udp://127.0.0.1:5514"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+
+	newconfig=$(mktemp --tmpdir crio-config.XXXXXX.json)
+	cp "$TESTDATA"/container_config_logging.json "$newconfig"
+	sed -i 's|"%shellcommand%"|"echo here is some output; echo and some from stderr >\&2"|' "$newconfig"
+	run crictl create "$pod_id" "$newconfig" "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run wait_until_exit "$ctr_id"
+	[ "$status" -eq 0 ]
+
+	# The output is sent to the syslog server only.
+	cat "$syslog_log"
+	grep -q "<14>1 .* ${ctr_id:0:12} - - - here is some output" "$syslog_log"
+	grep -q "<11>1 .* ${ctr_id:0:12} - - - and some from stderr" "$syslog_log"
+	[ ! -e "$DEFAULT_LOG_PATH/$pod_id/$ctr_id.log" ]
+
+	run crictl rm "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl stopp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl rmp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	kill "$socat_pid"
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "ctr log rate limit" {
+	export OVERRIDE_OPTIONS="--log-rate-limit-lines 10"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+
+	newconfig=$(mktemp --tmpdir crio-config.XXXXXX.json)
+	cp "$TESTDATA"/container_config_logging.json "$newconfig"
+	sed -i 's|"%shellcommand%"|"for i in $(seq 1000); do echo $i; done"|' "$newconfig"
+	run crictl create "$pod_id" "$newconfig" "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run wait_until_exit "$ctr_id"
+	[ "$status" -eq 0 ]
+
+	# The output over the limit is dropped, and the lines dropped counted.
+	logpath="$DEFAULT_LOG_PATH/$pod_id/$ctr_id.log"
+	[ -f "$logpath" ]
+	echo "$logpath :: $(cat "$logpath")"
+	len=$(grep -cv "conmon: dropped" "$logpath")
+	[ "$len" -lt 1000 ]
+	dropped=$(sed -n 's/.*\[conmon: dropped \([0-9]*\) lines.*/\1/p' "$logpath" | awk '{ sum += $1 } END { print sum }')
+	[ $((len + dropped)) -eq 1000 ]
+
+	run crictl rm "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl stopp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl rmp "$pod_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
@@ -219,3 +219,74 @@ function teardown() {
 	cleanup_pods
 	stop_crio
 }
+
+# 7. test running with ctr localhost/profile_name relative to the seccomp profile root
+@test "ctr seccomp profiles localhost/profile_name in the profile root" {
+	# this test requires seccomp, so skip this test if seccomp is not enabled.
+	enabled=$(is_seccomp_enabled)
+	if [[ "$enabled" -eq 0 ]]; then
+		skip "skip this test since seccomp is not enabled."
+	fi
+
+	mkdir -p "$TESTDIR"/seccomp
+	sed -e 's/"chmod",//' "$SECCOMP_PROFILE" > "$TESTDIR"/seccomp/profile1.json
+	sed -i 's/"fchmod",//' "$TESTDIR"/seccomp/profile1.json
+	sed -i 's/"fchmodat",//g' "$TESTDIR"/seccomp/profile1.json
+
+	export OVERRIDE_OPTIONS="--seccomp-profile-root $TESTDIR/seccomp"
+	start_crio
+
+	sed -e 's@%VALUE%@localhost/profile1.json@g' "$TESTDATA"/container_config_seccomp.json > "$TESTDIR"/seccomp1.json
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDIR"/seccomp1.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl exec --sync "$ctr_id" chmod 777 .
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ "Exit code: 1" ]]
+	[[ "$output" =~ "Operation not permitted" ]]
+
+	sed -e 's@%VALUE%@localhost/'"$SECCOMP_PROFILE"'@g' "$TESTDATA"/container_config_seccomp.json > "$TESTDIR"/seccomp2.json
+	run crictl create "$pod_id" "$TESTDIR"/seccomp2.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -ne 0 ]
+	[[ "$output" =~ "outside of the seccomp profile root" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+# 8. test running with an invalid ctr localhost/profile_name
+@test "ctr seccomp profiles invalid localhost/profile_name" {
+	# this test requires seccomp, so skip this test if seccomp is not enabled.
+	enabled=$(is_seccomp_enabled)
+	if [[ "$enabled" -eq 0 ]]; then
+		skip "skip this test since seccomp is not enabled."
+	fi
+
+	start_crio
+
+	sed -e 's/SCMP_ACT_ERRNO/SCMP_ACT_NOPE/' "$SECCOMP_PROFILE" > "$TESTDIR"/seccomp_profile1.json
+
+	sed -e 's@%VALUE%@localhost/'"$TESTDIR"'/seccomp_profile1.json@g' "$TESTDATA"/container_config_seccomp.json > "$TESTDIR"/seccomp1.json
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDIR"/seccomp1.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -ne 0 ]
+	[[ "$output" =~ "invalid default action" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
@@ -61,6 +61,45 @@ function teardown() {
 	stop_crio
 }
 
+@test "exec session recording" {
+	export OVERRIDE_OPTIONS="--session-recording-dir $TESTDIR/sessions"
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+	run crictl exec "$ctr_id" echo recorded
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	out=`echo -e "GET /containers/$ctr_id/sessions HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"container_id\":\"$ctr_id\"" ]]
+	[[ "$out" =~ "\"type\":\"exec\"" ]]
+	[[ "$out" =~ "\"command\":\"echo recorded\"" ]]
+	session_id=`echo "$out" | tail -n 1 | python -c 'import json,sys;print(json.load(sys.stdin)[0]["id"])'`
+
+	out=`echo -e "GET /containers/$ctr_id/sessions/$session_id HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"version\":2" ]]
+	[[ "$out" =~ "\"o\",\"recorded" ]]
+
+	out=`echo -e "GET /sessions HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"id\":\"$session_id\"" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
 @test "ctr inspect not found" {
 	start_crio
 	out=`echo -e "GET /containers/notexists HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
@@ -69,3 +108,68 @@ function teardown() {
 
 	stop_crio
 }
+
+@test "events stream" {
+	start_crio
+	(echo -e "GET /events?type=sandbox_created,container_created,container_started HTTP/1.1\r\nHost: crio\r\n"; sleep 10) | socat - UNIX-CONNECT:$CRIO_SOCKET > "$TESTDIR"/events.out &
+	events_pid=$!
+	sleep 1
+
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_config.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	sleep 1
+	kill "$events_pid" || true
+	out=`cat "$TESTDIR"/events.out`
+	echo "$out"
+	[[ "$out" =~ "\"type\":\"sandbox_created\",\"time\":\"[^\"]*\",\"id\":\"$pod_id\"" ]]
+	[[ "$out" =~ "\"type\":\"container_created\",\"time\":\"[^\"]*\",\"id\":\"$ctr_id\"" ]]
+	[[ "$out" =~ "\"type\":\"container_started\",\"time\":\"[^\"]*\",\"id\":\"$ctr_id\"" ]]
+	[[ ! "$out" =~ "sandbox_started" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
+
+@test "events stream unknown type" {
+	start_crio
+	out=`echo -e "GET /events?type=notexists HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "unknown event type \"notexists\"" ]]
+
+	stop_crio
+}
+
+@test "oom inspect" {
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_config.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+
+	out=`echo -e "GET /oom HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"$ctr_id\":{\"count\":0}" ]]
+
+	out=`echo -e "GET /containers/$ctr_id HTTP/1.1\r\nHost: crio\r\n" | socat - UNIX-CONNECT:$CRIO_SOCKET`
+	echo "$out"
+	[[ "$out" =~ "\"oom_kill_count\":0" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
@@ -0,0 +1,35 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function teardown() {
+	cleanup_test
+}
+
+@test "port forward to a sandbox" {
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	crictl port-forward "$pod_id" 16379:6379 &
+	forward_pid=$!
+	sleep 2
+
+	out=`echo -e "PING\r" | socat -t 2 - TCP4:localhost:16379`
+	echo "$out"
+	kill "$forward_pid" || true
+	[[ "$out" =~ "PONG" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
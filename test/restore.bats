@@ -271,3 +271,43 @@ function teardown() {
 	cleanup_pods
 	stop_crio
 }
+
+@test "crio restore reaps exec sessions" {
+	start_crio
+	run crictl runp "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	pod_id="$output"
+	run crictl create "$pod_id" "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json
+	echo "$output"
+	[ "$status" -eq 0 ]
+	ctr_id="$output"
+	run crictl start "$ctr_id"
+	echo "$output"
+	[ "$status" -eq 0 ]
+
+	crictl exec --sync --timeout 0 "$ctr_id" sleep 4321 &
+	exec_sync_pid=$!
+	crictl exec "$ctr_id" sleep 4322 &
+	exec_pid=$!
+	for i in $(seq 50); do
+		pgrep -f "sleep 432[2]" && pgrep -f "sleep 432[1]" && break
+		sleep 0.1
+	done
+	pgrep -f "sleep 432[1]"
+	pgrep -f "sleep 432[2]"
+
+	restart_crio
+	# The clients lost their sessions with the daemon.
+	wait "$exec_sync_pid" "$exec_pid" || true
+	! pgrep -f "sleep 432[12]"
+
+	run crictl exec --sync "$ctr_id" echo hello
+	echo "$output"
+	[ "$status" -eq 0 ]
+	[[ "$output" == "hello" ]]
+
+	cleanup_ctrs
+	cleanup_pods
+	stop_crio
+}
@@ -6,18 +6,34 @@ import (
 
 // ContainerInfo stores information about containers
 type ContainerInfo struct {
-	Name            string            `json:"name"`
-	Pid             int               `json:"pid"`
-	Image           string            `json:"image"`
-	ImageRef        string            `json:"image_ref"`
-	CreatedTime     int64             `json:"created_time"`
-	Labels          map[string]string `json:"labels"`
-	Annotations     map[string]string `json:"annotations"`
-	CrioAnnotations map[string]string `json:"crio_annotations"`
-	LogPath         string            `json:"log_path"`
-	Root            string            `json:"root"`
-	Sandbox         string            `json:"sandbox"`
-	IP              string            `json:"ip_address"`
+	Name                  string                 `json:"name"`
+	Pid                   int                    `json:"pid"`
+	Image                 string                 `json:"image"`
+	ImageRef              string                 `json:"image_ref"`
+	CreatedTime           int64                  `json:"created_time"`
+	Labels                map[string]string      `json:"labels"`
+	Annotations           map[string]string      `json:"annotations"`
+	CrioAnnotations       map[string]string      `json:"crio_annotations"`
+	LogPath               string                 `json:"log_path"`
+	Root                  string                 `json:"root"`
+	Sandbox               string                 `json:"sandbox"`
+	IP                    string                 `json:"ip_address"`
+	OOMKillCount          uint64                 `json:"oom_kill_count"`
+	LastOOMKillTime       int64                  `json:"last_oom_kill_time,omitempty"`
+	SignatureVerification *SignatureVerification `json:"signature_verification,omitempty"`
+}
+
+// OOMKillInfo stores the OOM kills of the memory cgroup of a container or a
+// pod
+type OOMKillInfo struct {
+	Count           uint64 `json:"count"`
+	LastOOMKillTime int64  `json:"last_oom_kill_time,omitempty"`
+}
+
+// OOMInfo stores the OOM kills of all the containers and pods, by ID
+type OOMInfo struct {
+	Containers map[string]OOMKillInfo `json:"containers"`
+	Pods       map[string]OOMKillInfo `json:"pods"`
 }
 
 // IDMappings specifies the ID mappings used for containers.
@@ -33,3 +49,50 @@ type CrioInfo struct {
 	CgroupDriver      string     `json:"cgroup_driver"`
 	DefaultIDMappings IDMappings `json:"default_id_mappings"`
 }
+
+// LayerPullInfo stores the progress of a layer download
+type LayerPullInfo struct {
+	Digest          string `json:"digest"`
+	Size            int64  `json:"size"`
+	DownloadedBytes uint64 `json:"downloaded_bytes"`
+}
+
+// ImagePullInfo stores the progress of an image pull
+type ImagePullInfo struct {
+	Image           string          `json:"image"`
+	Registry        string          `json:"registry"`
+	StartedTime     int64           `json:"started_time"`
+	ElapsedSeconds  float64         `json:"elapsed_seconds"`
+	DownloadedBytes uint64          `json:"downloaded_bytes"`
+	LayersPending   int             `json:"layers_pending"`
+	Layers          []LayerPullInfo `json:"layers"`
+}
+
+// SignatureInfo stores a signature of an image accepted by the signature
+// policy
+type SignatureInfo struct {
+	KeyIdentifier  string `json:"key_identifier"`
+	Identity       string `json:"identity"`
+	ManifestDigest string `json:"manifest_digest"`
+}
+
+// SignatureVerification stores how an image satisfied the signature policy
+// when it got pulled
+type SignatureVerification struct {
+	Source       string          `json:"source"`
+	PolicyScope  string          `json:"policy_scope"`
+	Requirements []string        `json:"requirements"`
+	Signatures   []SignatureInfo `json:"signatures"`
+	VerifiedTime int64           `json:"verified_time"`
+}
+
+// SessionRecording stores where and when an exec or attach session of a
+// container got recorded
+type SessionRecording struct {
+	ID          string `json:"id"`
+	ContainerID string `json:"container_id"`
+	Type        string `json:"type"`
+	Command     string `json:"command,omitempty"`
+	StartedTime int64  `json:"started_time"`
+	Size        int64  `json:"size"`
+}